@@ -0,0 +1,167 @@
+package ast
+
+// Children returns the nodes directly inside node, in source order
+func Children(node Node) []Node {
+	var children []Node
+	add := func(nodes ...Node) {
+		for _, n := range nodes {
+			if n != nil {
+				children = append(children, n)
+			}
+		}
+	}
+	addIdentifiers := func(idents []*Identifier) {
+		for _, ident := range idents {
+			if ident != nil {
+				children = append(children, ident)
+			}
+		}
+	}
+	addExpressions := func(exprs []Expression) {
+		for _, expr := range exprs {
+			add(expr)
+		}
+	}
+	addStatements := func(stmts []Statement) {
+		for _, stmt := range stmts {
+			add(stmt)
+		}
+	}
+	// Các field con trỏ có thể nil: kiểm tra trước khi đổi sang Node (interface chứa con trỏ nil khác nil)
+	addBlock := func(block *BlockStatement) {
+		if block != nil {
+			children = append(children, block)
+		}
+	}
+	addElse := func(block *ElseStatement) {
+		if block != nil {
+			children = append(children, block)
+		}
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		addStatements(n.Statements)
+	case *BlockStatement:
+		addStatements(n.Statements)
+
+	// Biểu thức
+	case *UnaryExpression:
+		add(n.Value)
+	case *BinaryExpression:
+		add(n.Left, n.Right)
+	case *ArrayExpression:
+		addExpressions(n.Elements)
+	case *TupleExpression:
+		addExpressions(n.Elements)
+	case *SetExpression:
+		addExpressions(n.Elements)
+	case *ArrayIndexExpression:
+		add(n.Array, n.Index)
+	case *MethodCallExpression:
+		add(n.Caller)
+		addExpressions(n.Arguments)
+	case *FunctionCallExpression:
+		add(n.Function)
+		addExpressions(n.Arguments)
+	case *IncDecExpression:
+		add(n.Value)
+	case *TemplateExpression:
+		addExpressions(n.Parts)
+	case *InterpolationExpression:
+		add(n.Value)
+	case *PropertyExpression:
+		add(n.Object)
+	case *ConditionalExpression:
+		add(n.Condition, n.Consequence, n.Alternative)
+	case *IfExpression:
+		if n.Statement != nil {
+			children = append(children, n.Statement)
+		}
+	case *MatchExpression:
+		if n.Statement != nil {
+			children = append(children, n.Statement)
+		}
+	case *BlockExpression:
+		addBlock(n.Block)
+	case *AssignExpression:
+		if n.Name != nil {
+			children = append(children, n.Name)
+		}
+		add(n.Value)
+
+	// Câu lệnh
+	case *AssignStatement:
+		add(n.Name, n.Value)
+	case *ExpressionStatement:
+		add(n.Expression)
+	case *IfStatement:
+		add(n.Condition)
+		addBlock(n.Body)
+		for _, elif := range n.ElseIfs {
+			if elif != nil {
+				children = append(children, elif)
+			}
+		}
+		addElse(n.ElseBlock)
+	case *ElifStatement:
+		add(n.Condition)
+		addBlock(n.Body)
+	case *ElseStatement:
+		addBlock(n.Body)
+	case *ForStatement:
+		add(n.Init, n.Condition, n.Update)
+		addBlock(n.Body)
+		addElse(n.ElseBlock)
+	case *ForInStatement:
+		addIdentifiers([]*Identifier{n.Variable})
+		add(n.Iterable)
+		addBlock(n.Body)
+		addElse(n.ElseBlock)
+	case *WhileStatement:
+		add(n.Condition)
+		addBlock(n.Body)
+		addElse(n.ElseBlock)
+	case *UntilStatement:
+		add(n.Condition)
+		addBlock(n.Body)
+		addElse(n.ElseBlock)
+	case *FunctionDefinitionStatement:
+		addIdentifiers([]*Identifier{n.Name})
+		addIdentifiers(n.Parameters)
+		addBlock(n.Body)
+	case *MethodDefinitionStatement:
+		addIdentifiers([]*Identifier{n.Receiver, n.Name})
+		addIdentifiers(n.Parameters)
+		addBlock(n.Body)
+	case *RecordStatement:
+		addIdentifiers([]*Identifier{n.Name})
+		addIdentifiers(n.Fields)
+	case *EnumStatement:
+		addIdentifiers([]*Identifier{n.Name})
+		for _, variant := range n.Variants {
+			if variant != nil {
+				children = append(children, variant)
+			}
+		}
+	case *EnumVariant:
+		addIdentifiers([]*Identifier{n.Name})
+		addIdentifiers(n.Fields)
+	case *MatchStatement:
+		add(n.Subject)
+		for _, arm := range n.Arms {
+			if arm != nil {
+				children = append(children, arm)
+			}
+		}
+		addElse(n.ElseBlock)
+	case *MatchArm:
+		addExpressions(n.Patterns)
+		addBlock(n.Body)
+	case *ReturnStatement:
+		add(n.Value)
+	case *DeferStatement:
+		add(n.Call)
+	}
+	return children
+}
@@ -1,6 +1,9 @@
 package ast
 
-import "fmt"
+import (
+	"fmt"
+	"math/big"
+)
 
 // Expression represents an expression (like math operations, function calls)
 type Expression interface {
@@ -12,6 +15,7 @@ type Expression interface {
 type Identifier struct {
 	Value string
 	Line  int
+	Span
 }
 
 func (i *Identifier) expressionNode()      {}
@@ -21,15 +25,37 @@ func (i *Identifier) TokenLiteral() string { return i.Value }
 type NumberExpression struct {
 	Value float64 // Đổi từ string -> float64
 	Line  int
+	Span
 }
 
 func (n *NumberExpression) expressionNode()      {}
 func (n *NumberExpression) TokenLiteral() string { return fmt.Sprintf("%v", n.Value) }
 
+// BigIntExpression represents a bigint literal (123n)
+type BigIntExpression struct {
+	Value *big.Int
+	Line  int
+	Span
+}
+
+func (b *BigIntExpression) expressionNode()      {}
+func (b *BigIntExpression) TokenLiteral() string { return b.Value.String() + "n" }
+
+// DecimalExpression represents a decimal literal (12.30d); Value is the text without the suffix
+type DecimalExpression struct {
+	Value string
+	Line  int
+	Span
+}
+
+func (d *DecimalExpression) expressionNode()      {}
+func (d *DecimalExpression) TokenLiteral() string { return d.Value + "d" }
+
 // StringExpression represents a string value
 type StringExpression struct {
 	Value string
 	Line  int
+	Span
 }
 
 func (s *StringExpression) expressionNode()      {}
@@ -38,6 +64,7 @@ func (s *StringExpression) TokenLiteral() string { return s.Value }
 type BooleanExpression struct {
 	Value bool
 	Line  int
+	Span
 }
 
 func (b *BooleanExpression) expressionNode() {
@@ -51,6 +78,7 @@ type UnaryExpression struct {
 	Operator string
 	Value    Expression
 	Line     int
+	Span
 }
 
 func (u UnaryExpression) TokenLiteral() string {
@@ -66,6 +94,7 @@ type BinaryExpression struct {
 	Operator string
 	Right    Expression
 	Line     int
+	Span
 }
 
 func (b *BinaryExpression) expressionNode() {}
@@ -75,7 +104,9 @@ func (b *BinaryExpression) TokenLiteral() string {
 
 type ArrayExpression struct {
 	Elements []Expression
+	Frozen   bool // #[...]: array bất biến
 	Line     int
+	Span
 }
 
 func (a ArrayExpression) TokenLiteral() string {
@@ -87,9 +118,11 @@ func (a ArrayExpression) expressionNode() {
 }
 
 type ArrayIndexExpression struct {
-	Array Expression
-	Index Expression
-	Line  int
+	Array    Expression
+	Index    Expression
+	Optional bool // a?.[i]: trả về nothing nếu a là nothing
+	Line     int
+	Span
 }
 
 func (a ArrayIndexExpression) TokenLiteral() string {
@@ -103,7 +136,9 @@ type MethodCallExpression struct {
 	Caller    Expression   // Thằng gọi method (ví dụ: array trong array.inject())
 	Method    string       // Tên method ("inject" hoặc "vomit")
 	Arguments []Expression // Danh sách đối số (nếu có)
+	Optional  bool         // a?.m(): trả về nothing nếu a là nothing
 	Line      int
+	Span
 }
 
 func (m MethodCallExpression) TokenLiteral() string {
@@ -117,6 +152,7 @@ type FunctionCallExpression struct {
 	Function  Expression   // Hàm cần gọi (có thể là biến hoặc một biểu thức)
 	Arguments []Expression // Danh sách tham số
 	Line      int
+	Span
 }
 
 func (f FunctionCallExpression) TokenLiteral() string {
@@ -128,6 +164,7 @@ func (f FunctionCallExpression) expressionNode() {
 
 type NothingExpression struct {
 	Line int
+	Span
 }
 
 func (n NothingExpression) TokenLiteral() string {
@@ -143,6 +180,7 @@ type IncDecExpression struct {
 	Value    Expression
 	IsPrefix bool
 	Line     int
+	Span
 }
 
 func (i IncDecExpression) TokenLiteral() string {
@@ -152,3 +190,135 @@ func (i IncDecExpression) TokenLiteral() string {
 func (i IncDecExpression) expressionNode() {
 
 }
+
+// TemplateExpression represents an interpolated string ("Hello ${name}")
+type TemplateExpression struct {
+	Parts []Expression // Xen kẽ StringExpression (phần chữ) và InterpolationExpression (phần ${...})
+	Line  int
+	Span
+}
+
+func (t TemplateExpression) TokenLiteral() string {
+	return "template"
+}
+
+func (t TemplateExpression) expressionNode() {
+}
+
+// InterpolationExpression represents one ${value:format} inside a template
+type InterpolationExpression struct {
+	Value  Expression
+	Format string // Format spec sau dấu : (rỗng nếu không có)
+	Line   int
+	Span
+}
+
+func (i InterpolationExpression) TokenLiteral() string {
+	return "${}"
+}
+
+func (i InterpolationExpression) expressionNode() {
+}
+
+// PropertyExpression represents a property access (a.b or a?.b)
+type PropertyExpression struct {
+	Object   Expression
+	Property string
+	Optional bool // a?.b: trả về nothing nếu a là nothing
+	Line     int
+	Span
+}
+
+func (p PropertyExpression) TokenLiteral() string {
+	return "."
+}
+
+func (p PropertyExpression) expressionNode() {
+}
+
+// ConditionalExpression represents cond ? consequence : alternative
+type ConditionalExpression struct {
+	Condition   Expression
+	Consequence Expression
+	Alternative Expression
+	Line        int
+	Span
+}
+
+func (c ConditionalExpression) TokenLiteral() string {
+	return "?:"
+}
+
+func (c ConditionalExpression) expressionNode() {
+}
+
+// TupleExpression: (1, "a"), (x,) hoặc () - dãy giá trị bất biến
+type TupleExpression struct {
+	Elements []Expression
+	Line     int
+	Span
+}
+
+func (t *TupleExpression) expressionNode()      {}
+func (t *TupleExpression) TokenLiteral() string { return "(" }
+
+// SetExpression: #{1, 2, 3}
+type SetExpression struct {
+	Elements []Expression
+	Line     int
+	Span
+}
+
+func (s *SetExpression) expressionNode()      {}
+func (s *SetExpression) TokenLiteral() string { return "#{" }
+
+// IfExpression is an if used as a value: x = if a > b { a } else { b }.
+// The taken branch yields the value of its last statement (nothing if no branch is taken).
+type IfExpression struct {
+	Statement *IfStatement
+	Line      int
+	Span
+}
+
+func (i *IfExpression) expressionNode()      {}
+func (i *IfExpression) TokenLiteral() string { return "if" }
+
+// MatchExpression is a match used as a value; the matching arm yields its last value
+type MatchExpression struct {
+	Statement *MatchStatement
+	Line      int
+	Span
+}
+
+func (m *MatchExpression) expressionNode()      {}
+func (m *MatchExpression) TokenLiteral() string { return "match" }
+
+// BlockExpression: { a = 2; a * a } - chạy các lệnh trong scope riêng, giá trị là lệnh cuối
+type BlockExpression struct {
+	Block *BlockStatement
+	Line  int
+	Span
+}
+
+func (b *BlockExpression) expressionNode()      {}
+func (b *BlockExpression) TokenLiteral() string { return "{" }
+
+// AssignExpression: (line := ask("> ")) - gán rồi trả về giá trị vừa gán
+type AssignExpression struct {
+	Name  *Identifier
+	Value Expression
+	Line  int
+	Span
+}
+
+func (a *AssignExpression) expressionNode()      {}
+func (a *AssignExpression) TokenLiteral() string { return ":=" }
+
+// BadExpression takes the place of an expression that could not be parsed (the error is already reported)
+type BadExpression struct {
+	Line int
+	Span
+}
+
+func (b *BadExpression) expressionNode()      {}
+func (b *BadExpression) TokenLiteral() string { return "<bad>" }
@@ -1,14 +1,35 @@
 package ast
 
+import "pun/lexer"
+
 // Node is the base interface for all AST nodes
 type Node interface {
 	TokenLiteral() string
+	SourceSpan() Span
+	SetSpan(span Span)
+}
+
+// Span is the part of the source a node was parsed from. End is just after its last character.
+// Every node embeds a Span, which the parser fills in.
+type Span struct {
+	File  string
+	Start lexer.Position
+	End   lexer.Position
+}
+
+func (s *Span) SourceSpan() Span  { return *s }
+func (s *Span) SetSpan(span Span) { *s = span }
+
+// IsZero reports whether the span has not been set
+func (s Span) IsZero() bool {
+	return s.End.Line == 0
 }
 
 // Program is the root node of our AST
 // It contains a list of statements
 type Program struct {
 	Statements []Statement
+	Span
 }
 
 func (p *Program) TokenLiteral() string {
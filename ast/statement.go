@@ -10,6 +10,7 @@ type AssignStatement struct {
 	Name  Expression
 	Value Expression
 	Line  int
+	Span
 }
 
 func (as *AssignStatement) statementNode() {}
@@ -20,6 +21,7 @@ func (as *AssignStatement) TokenLiteral() string {
 type BlockStatement struct {
 	Statements []Statement
 	Line       int
+	Span
 }
 
 func (b BlockStatement) TokenLiteral() string {
@@ -35,6 +37,7 @@ type IfStatement struct {
 	ElseIfs   []*ElifStatement
 	ElseBlock *ElseStatement
 	Line      int
+	Span
 }
 
 func (w IfStatement) TokenLiteral() string {
@@ -48,6 +51,7 @@ type ElifStatement struct {
 	Condition Expression
 	Body      *BlockStatement
 	Line      int
+	Span
 }
 
 func (m ElifStatement) TokenLiteral() string {
@@ -60,6 +64,7 @@ func (m ElifStatement) statementNode() {
 type ElseStatement struct {
 	Body *BlockStatement
 	Line int
+	Span
 }
 
 func (o ElseStatement) TokenLiteral() string {
@@ -70,29 +75,53 @@ func (o ElseStatement) statementNode() {
 }
 
 type ForStatement struct {
+	Label     string     // Nhãn của vòng lặp (outer: for ...), rỗng nếu không có
 	Init      Statement  // Khởi tạo biến (i = 0)
 	Condition Expression // Điều kiện (i < 10)
 	Update    Statement  // Cập nhật biến (i = i + 1)
 	Body      *BlockStatement
+	ElseBlock *ElseStatement // Chạy khi vòng lặp kết thúc mà không break
 	Line      int
+	Span
 }
 
 func (f *ForStatement) statementNode()       {}
 func (f *ForStatement) TokenLiteral() string { return "for" }
 
+// ForInStatement is `for x in xs { ... }`: the body runs once per element of an array, tuple,
+// set (in insertion order) or string (per character)
+type ForInStatement struct {
+	Label     string
+	Variable  *Identifier // Biến nhận từng phần tử
+	Iterable  Expression  // Giá trị được duyệt, tính một lần trước vòng lặp
+	Body      *BlockStatement
+	ElseBlock *ElseStatement
+	Line      int
+	Span
+}
+
+func (f *ForInStatement) statementNode()       {}
+func (f *ForInStatement) TokenLiteral() string { return "for" }
+
 type WhileStatement struct {
+	Label     string
 	Condition Expression
 	Body      *BlockStatement
+	ElseBlock *ElseStatement
 	Line      int
+	Span
 }
 
 func (w *WhileStatement) statementNode()       {}
 func (w *WhileStatement) TokenLiteral() string { return "while" }
 
 type UntilStatement struct {
+	Label     string
 	Condition Expression
 	Body      *BlockStatement
+	ElseBlock *ElseStatement
 	Line      int
+	Span
 }
 
 func (u *UntilStatement) statementNode()       {}
@@ -101,6 +130,7 @@ func (u *UntilStatement) TokenLiteral() string { return "until" }
 type ExpressionStatement struct {
 	Expression Expression
 	Line       int
+	Span
 }
 
 func (e ExpressionStatement) TokenLiteral() string {
@@ -116,6 +146,7 @@ type FunctionDefinitionStatement struct {
 	Parameters []*Identifier   // Danh sách tham số
 	Body       *BlockStatement // Thân hàm
 	Line       int
+	Span
 }
 
 func (f FunctionDefinitionStatement) TokenLiteral() string {
@@ -126,12 +157,73 @@ func (f FunctionDefinitionStatement) statementNode() {
 
 }
 
+// RecordStatement declares a record type: record Vec(x, y)
+type RecordStatement struct {
+	Name   *Identifier
+	Fields []*Identifier
+	Line   int
+	Span
+}
+
+func (r *RecordStatement) statementNode()       {}
+func (r *RecordStatement) TokenLiteral() string { return "record" }
+
+// EnumStatement declares an enum: enum Result { Ok(value), Err(message) }
+type EnumStatement struct {
+	Name     *Identifier
+	Variants []*EnumVariant
+	Line     int
+	Span
+}
+
+func (e *EnumStatement) statementNode()       {}
+func (e *EnumStatement) TokenLiteral() string { return "enum" }
+
+// EnumVariant is one variant of an enum, with the names of its associated values (nếu có)
+type EnumVariant struct {
+	Name   *Identifier
+	Fields []*Identifier
+	Span
+}
+
+func (v *EnumVariant) TokenLiteral() string { return v.Name.Value }
+
+// MatchStatement runs the first arm whose pattern matches the subject:
+//
+//	match result {
+//	    Result.Ok(value) { ... }
+//	    Result.Err(msg), Result.Timeout { ... }
+//	    else { ... }
+//	}
+type MatchStatement struct {
+	Subject   Expression
+	Arms      []*MatchArm
+	ElseBlock *ElseStatement
+	Line      int
+	Span
+}
+
+func (m *MatchStatement) statementNode()       {}
+func (m *MatchStatement) TokenLiteral() string { return "match" }
+
+// MatchArm is a list of patterns with the block that runs when one of them matches.
+// A pattern is an enum variant (Color.Red, Result.Ok(value) binds value) or any value compared with ==.
+type MatchArm struct {
+	Patterns []Expression
+	Body     *BlockStatement
+	Line     int
+	Span
+}
+
+func (m *MatchArm) TokenLiteral() string { return "" }
+
 type MethodDefinitionStatement struct {
 	Receiver   *Identifier   // Tên của object (ví dụ: String)
 	Name       *Identifier   // Tên method (ví dụ: uppercase)
 	Parameters []*Identifier // Danh sách tham số
 	Body       *BlockStatement
 	Line       int
+	Span
 }
 
 func (m MethodDefinitionStatement) TokenLiteral() string {
@@ -142,7 +234,11 @@ func (m MethodDefinitionStatement) statementNode() {
 
 }
 
-type BreakStatement struct{ Line int }
+type BreakStatement struct {
+	Label string // break outer
+	Line  int
+	Span
+}
 
 func (s BreakStatement) TokenLiteral() string {
 	return "break"
@@ -152,7 +248,11 @@ func (s BreakStatement) statementNode() {
 
 }
 
-type ContinueStatement struct{ Line int }
+type ContinueStatement struct {
+	Label string // continue outer
+	Line  int
+	Span
+}
 
 func (c ContinueStatement) TokenLiteral() string {
 	return "continue"
@@ -165,6 +265,7 @@ func (c ContinueStatement) statementNode() {
 type ReturnStatement struct {
 	Value Expression
 	Line  int
+	Span
 }
 
 func (r ReturnStatement) TokenLiteral() string {
@@ -174,3 +275,27 @@ func (r ReturnStatement) TokenLiteral() string {
 func (r ReturnStatement) statementNode() {
 
 }
+
+// DeferStatement: defer f(x) - gọi f(x) khi hàm chứa nó kết thúc
+type DeferStatement struct {
+	Call Expression // FunctionCallExpression hoặc MethodCallExpression
+	Line int
+	Span
+}
+
+func (d DeferStatement) TokenLiteral() string {
+	return "defer"
+}
+
+func (d DeferStatement) statementNode() {
+
+}
+
+// BadStatement takes the place of a statement that could not be parsed (the error is already reported)
+type BadStatement struct {
+	Line int
+	Span
+}
+
+func (b *BadStatement) statementNode()       {}
+func (b *BadStatement) TokenLiteral() string { return "<bad>" }
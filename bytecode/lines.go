@@ -0,0 +1,50 @@
+package bytecode
+
+import "sort"
+
+// LineEntry says that the code from PC up to the next entry was compiled from the node
+// spanning Line:Col to EndLine:EndCol
+type LineEntry struct {
+	PC      int
+	Line    int
+	Col     int
+	EndLine int
+	EndCol  int
+}
+
+// LineTable maps positions in the bytecode back to the source, for error messages and stack traces
+type LineTable struct {
+	File    string
+	Entries []LineEntry // Theo thứ tự PC tăng dần
+}
+
+// Add records that the code starting at entry.PC comes from the position of entry
+func (t *LineTable) Add(entry LineEntry) {
+	if entry.Line == 0 {
+		return
+	}
+	if n := len(t.Entries); n > 0 {
+		last := &t.Entries[n-1]
+		if last.Line == entry.Line && last.Col == entry.Col && last.EndLine == entry.EndLine && last.EndCol == entry.EndCol {
+			return // Vẫn cùng vị trí: entry trước đã bao phủ
+		}
+		if last.PC == entry.PC {
+			*last = entry // Chưa sinh lệnh nào cho vị trí cũ
+			return
+		}
+	}
+	t.Entries = append(t.Entries, entry)
+}
+
+// Lookup returns the source position of the instruction at pc
+func (t *LineTable) Lookup(pc int) (LineEntry, bool) {
+	if t == nil {
+		return LineEntry{}, false
+	}
+	// Entry cuối cùng có PC <= pc
+	i := sort.Search(len(t.Entries), func(i int) bool { return t.Entries[i].PC > pc }) - 1
+	if i < 0 {
+		return LineEntry{}, false
+	}
+	return t.Entries[i], true
+}
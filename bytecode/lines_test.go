@@ -0,0 +1,46 @@
+package bytecode
+
+import "testing"
+
+func TestLineTable(t *testing.T) {
+	var table LineTable
+	table.Add(LineEntry{PC: 0, Line: 1, Col: 1, EndLine: 1, EndCol: 6})
+	table.Add(LineEntry{PC: 3, Line: 1, Col: 1, EndLine: 1, EndCol: 6}) // Cùng vị trí: bỏ qua
+	table.Add(LineEntry{PC: 5, Line: 2, Col: 1, EndLine: 2, EndCol: 4})
+	table.Add(LineEntry{PC: 5, Line: 2, Col: 3, EndLine: 2, EndCol: 4}) // Cùng PC: thay entry trước
+	table.Add(LineEntry{PC: 7, Line: 0})                                // Không có vị trí: bỏ qua
+	table.Add(LineEntry{PC: 9, Line: 4, Col: 5, EndLine: 4, EndCol: 9})
+
+	if len(table.Entries) != 3 {
+		t.Fatalf("got %d entries, want 3: %+v", len(table.Entries), table.Entries)
+	}
+
+	tests := []struct {
+		pc   int
+		line int
+		col  int
+	}{
+		{0, 1, 1},
+		{4, 1, 1},
+		{5, 2, 3},
+		{8, 2, 3},
+		{9, 4, 5},
+		{100, 4, 5},
+	}
+	for _, tt := range tests {
+		entry, ok := table.Lookup(tt.pc)
+		if !ok || entry.Line != tt.line || entry.Col != tt.col {
+			t.Errorf("Lookup(%d) = %+v, %v; want %d:%d", tt.pc, entry, ok, tt.line, tt.col)
+		}
+	}
+
+	// Trước entry đầu tiên, hoặc không có bảng: không có vị trí
+	table = LineTable{Entries: []LineEntry{{PC: 2, Line: 1}}}
+	if _, ok := table.Lookup(1); ok {
+		t.Errorf("Lookup before the first entry found a position")
+	}
+	var none *LineTable
+	if _, ok := none.Lookup(0); ok {
+		t.Errorf("Lookup on a nil table found a position")
+	}
+}
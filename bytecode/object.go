@@ -6,3 +6,39 @@ type Function struct {
 	LocalSize int //Số lượng biến local (số lượng param + số lượng biến tạo trong hàm)
 	StartPC   int //Địa chỉ bắt đầu thân hàm
 }
+
+// RecordType is a type declared with `record Name(field, ...)`.
+// Its methods (func Name.method(...)) take the record as an extra first parameter, self.
+type RecordType struct {
+	Name    string
+	Fields  []string
+	Methods map[string]*Function
+}
+
+// EnumType is a type declared with `enum Name { Variant, Variant(field, ...) }`
+type EnumType struct {
+	Name     string
+	Variants []*EnumVariant
+}
+
+// Variant returns the variant called name
+func (e *EnumType) Variant(name string) (*EnumVariant, bool) {
+	for _, variant := range e.Variants {
+		if variant.Name == name {
+			return variant, true
+		}
+	}
+	return nil, false
+}
+
+// EnumVariant describes one variant. A variant without fields is itself the runtime value
+// (Color.Red), so two such values are equal exactly when they are the same variant.
+type EnumVariant struct {
+	Enum   *EnumType
+	Name   string
+	Fields []string // Tên các giá trị đi kèm (rỗng nếu không có)
+}
+
+func (v *EnumVariant) String() string {
+	return v.Name
+}
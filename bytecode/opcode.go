@@ -35,42 +35,86 @@ const (
 	OP_ARRAY_GET
 	OP_ARRAY_SET
 	OP_MAKE_FUNCTION
+	OP_BUILD_STRING // Nối n giá trị trên stack thành một chuỗi
+	OP_FORMAT       // Định dạng giá trị trên đỉnh stack theo format spec (constant)
+	OP_POP
+	OP_JUMP_IF_NOTHING     // Nhảy nếu đỉnh stack là nothing (không pop)
+	OP_JUMP_IF_NOT_NOTHING // Nhảy nếu đỉnh stack khác nothing (không pop)
+	OP_GET_PROPERTY        // Lấy property (tên là constant) của giá trị trên đỉnh stack
+	OP_CALL_METHOD         // Gọi method: operand = (tên constant << 8) | số argument
+	OP_DEFER               // Hoãn lời gọi hàm đến khi hàm hiện tại return: operand = số argument
+	OP_DEFER_METHOD        // Hoãn lời gọi method: operand giống OP_CALL_METHOD
+	OP_TAIL_CALL           // return f(...): dùng lại frame hiện tại, operand = số argument
+	OP_BIT_AND
+	OP_BIT_OR
+	OP_BIT_XOR
+	OP_BIT_NOT
+	OP_SHL
+	OP_SHR
+	OP_MAKE_TUPLE // Tạo tuple từ n giá trị trên stack
+	OP_MAKE_SET   // Tạo set từ n giá trị trên stack (bỏ giá trị trùng)
+	OP_FREEZE     // Đóng băng (deep) giá trị trên đỉnh stack
+	OP_IS_VARIANT // Thay giá trị trên đỉnh stack bằng true nếu nó thuộc variant (constant) của enum
+	OP_ITER       // Thay array/tuple/set trên đỉnh stack bằng một iterator cho for-in
+	OP_ITER_NEXT  // Pop iterator: hết phần tử thì nhảy tới operand, không thì push phần tử tiếp theo
 )
 
 // Số byte operand ứng với mỗi opcode
 var OperandWidths = map[Opcode]int{
-	OP_LOAD_CONST:    1,
-	OP_LOAD_NOTHING:  0,
-	OP_LOAD_GLOBAL:   1,
-	OP_STORE_GLOBAL:  1,
-	OP_LOAD_LOCAL:    2,
-	OP_STORE_LOCAL:   2,
-	OP_ENTER_SCOPE:   1,
-	OP_LEAVE_SCOPE:   0,
-	OP_ADD:           0,
-	OP_SUB:           0,
-	OP_MUL:           0,
-	OP_DIV:           0,
-	OP_MOD:           0,
-	OP_POW:           0,
-	OP_EQ:            0,
-	OP_NEQ:           0,
-	OP_GTE:           0,
-	OP_LTE:           0,
-	OP_GT:            0,
-	OP_LT:            0,
-	OP_AND:           0,
-	OP_OR:            0,
-	OP_NOT:           0,
-	OP_NEG:           0,
-	OP_JUMP:          2,
-	OP_JUMP_IF_FALSE: 2,
-	OP_CALL:          1,
-	OP_RETURN:        0,
-	OP_MAKE_ARRAY:    1,
-	OP_ARRAY_GET:     0,
-	OP_ARRAY_SET:     0,
-	OP_MAKE_FUNCTION: 1,
+	OP_LOAD_CONST:          1,
+	OP_LOAD_NOTHING:        0,
+	OP_LOAD_GLOBAL:         1,
+	OP_STORE_GLOBAL:        1,
+	OP_LOAD_LOCAL:          2,
+	OP_STORE_LOCAL:         2,
+	OP_ENTER_SCOPE:         1,
+	OP_LEAVE_SCOPE:         0,
+	OP_ADD:                 0,
+	OP_SUB:                 0,
+	OP_MUL:                 0,
+	OP_DIV:                 0,
+	OP_MOD:                 0,
+	OP_POW:                 0,
+	OP_EQ:                  0,
+	OP_NEQ:                 0,
+	OP_GTE:                 0,
+	OP_LTE:                 0,
+	OP_GT:                  0,
+	OP_LT:                  0,
+	OP_AND:                 0,
+	OP_OR:                  0,
+	OP_NOT:                 0,
+	OP_NEG:                 0,
+	OP_JUMP:                2,
+	OP_JUMP_IF_FALSE:       2,
+	OP_CALL:                1,
+	OP_RETURN:              0,
+	OP_MAKE_ARRAY:          1,
+	OP_ARRAY_GET:           0,
+	OP_ARRAY_SET:           0,
+	OP_MAKE_FUNCTION:       0,
+	OP_BUILD_STRING:        1,
+	OP_FORMAT:              1,
+	OP_POP:                 0,
+	OP_JUMP_IF_NOTHING:     2,
+	OP_JUMP_IF_NOT_NOTHING: 2,
+	OP_GET_PROPERTY:        1,
+	OP_CALL_METHOD:         2,
+	OP_DEFER:               1,
+	OP_DEFER_METHOD:        2,
+	OP_TAIL_CALL:           1,
+	OP_BIT_AND:             0,
+	OP_BIT_OR:              0,
+	OP_BIT_XOR:             0,
+	OP_BIT_NOT:             0,
+	OP_SHL:                 0,
+	OP_SHR:                 0,
+	OP_MAKE_TUPLE:          1,
+	OP_MAKE_SET:            1,
+	OP_FREEZE:              0,
+	OP_IS_VARIANT:          1,
+	OP_ITER:                0,
+	OP_ITER_NEXT:           2,
 }
 
 // Encode opcode + operands thành []byte
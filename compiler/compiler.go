@@ -14,19 +14,32 @@ type Label struct {
 	Name string
 }
 
+// loopContext tracks the jumps of one enclosing loop
+type loopContext struct {
+	label             string // Nhãn của vòng lặp (rỗng nếu không có)
+	scopeDepth        int    // Số scope đang mở ở thân vòng lặp
+	valueDepth        int    // Số if/match/block expression đang bao quanh vòng lặp
+	breakPositions    []int  // Positions of break jumps to patch
+	continuePositions []int  // Positions of continue jumps to patch
+}
+
 type Compiler struct {
-	Constants         []interface{}    // Pool hằng số
-	Code              []byte           // Chương trình bytecode
-	GlobalSymbols     map[string]int   // Chỉ cho biến global
-	CurrentScope      map[string]int   //Scope hiện tại
-	Scopes            []map[string]int // Chỉ cho local scopes (không chứa global)
-	LocalInitDepth    map[string]int   //Lưu depth của scope mà biến local lần đầu được tạo (dùng cho nested scope)
-	BuiltinFuncs      map[string]bool  //Lưu tên các hàm built-in
-	BuiltinConstants  map[string]int   //Lưu tên hằng số và index trong constants pool
-	IsInsideFunction  bool             //Kiểm tra xem có đang trong hàm không (quản lí return)
-	breakPositions    []int            // Positions of break jumps to patch
-	continuePositions []int            // Positions of continue jumps to patch
-	Errors            []customError.CompilationError
+	Constants        []interface{}                   // Pool hằng số
+	Code             []byte                          // Chương trình bytecode
+	GlobalSymbols    map[string]int                  // Chỉ cho biến global
+	CurrentScope     map[string]int                  //Scope hiện tại
+	Scopes           []map[string]int                // Chỉ cho local scopes (không chứa global)
+	BuiltinFuncs     map[string]bool                 //Lưu tên các hàm built-in
+	BuiltinConstants map[string]int                  //Lưu tên hằng số và index trong constants pool
+	IsInsideFunction bool                            //Kiểm tra xem có đang trong hàm không (quản lí return)
+	TailCalls        bool                            // Tối ưu return f(...) thành tail call (tắt khi cần debug call stack)
+	loops            []*loopContext                  // Các vòng lặp đang bao quanh (trong cùng ở cuối)
+	valueDepth       int                             // Số if/match/block expression đang compile
+	records          map[string]*bytecode.RecordType // Các record đã khai báo, để gắn method vào
+	enums            map[string]*bytecode.EnumType   // Các enum đã khai báo, để kiểm tra match
+	Lines            *bytecode.LineTable             // Vị trí trong source của từng đoạn bytecode
+	span             ast.Span                        // Vị trí của node đang compile
+	Errors           []customError.CompilationError
 }
 
 // Dùng để lưu biến local cùng depth của scope chứa nó (giúp vm xác định đúng)
@@ -36,13 +49,26 @@ func NewCompiler() *Compiler {
 		BuiltinFuncs:     make(map[string]bool),
 		BuiltinConstants: make(map[string]int),
 		GlobalSymbols:    make(map[string]int),
+		records:          make(map[string]*bytecode.RecordType),
+		enums:            make(map[string]*bytecode.EnumType),
 		Scopes:           make([]map[string]int, 0), // Bắt đầu với empty stack
-		LocalInitDepth:   make(map[string]int),
+		Lines:            &bytecode.LineTable{},
 		IsInsideFunction: false,
+		TailCalls:        true,
 	}
 	//Thêm hàm builtin
 	c.registerBuiltinFunc("print")
 	c.registerBuiltinFunc("ask")
+	c.registerBuiltinFunc("bigint")
+	c.registerBuiltinFunc("number")
+	c.registerBuiltinFunc("decimal")
+	c.registerBuiltinFunc("round")
+	c.registerBuiltinFunc("rounding")
+	c.registerBuiltinFunc("set")
+	c.registerBuiltinFunc("freeze")
+	c.registerBuiltinFunc("isFrozen")
+	c.registerBuiltinFunc("copy")
+	c.registerBuiltinFunc("sort")
 
 	//Thêm hằng số
 	c.registerBuiltinConstant("PI", math.Pi)
@@ -62,6 +88,7 @@ func (c *Compiler) registerBuiltinFunc(name string) {
 }
 
 func (c *Compiler) CompileProgram(program *ast.Program) {
+	c.Lines.File = program.File
 	for _, stmt := range program.Statements {
 		c.compileStatement(stmt)
 	}
@@ -79,16 +106,42 @@ func (c *Compiler) addConstant(value interface{}) int {
 	return len(c.Constants) - 1
 }
 
+// at makes node the source of the code emitted next and returns a func that restores the previous
+// position, so an operator compiled after its operands still points at the operator's node
+func (c *Compiler) at(node ast.Node) func() {
+	previous := c.span
+	if node == nil {
+		return func() {}
+	}
+	if span := node.SourceSpan(); !span.IsZero() {
+		c.span = span
+	}
+	return func() { c.span = previous }
+}
+
 // Tạo instruction mới
 func (c *Compiler) emit(op bytecode.Opcode, operands ...int) int {
 	ins := bytecode.Make(op, operands...)
 	pos := len(c.Code)
+	c.markPosition(pos)
 	c.Code = append(c.Code, ins...)
 	return pos
 }
 
+// markPosition records that the instruction at pc comes from the node being compiled
+func (c *Compiler) markPosition(pc int) {
+	c.Lines.Add(bytecode.LineEntry{
+		PC:      pc,
+		Line:    c.span.Start.Line,
+		Col:     c.span.Start.Col,
+		EndLine: c.span.End.Line,
+		EndCol:  c.span.End.Col,
+	})
+}
+
 func (c *Compiler) emitWithPatch(op bytecode.Opcode) int {
 	pos := len(c.Code)
+	c.markPosition(pos)
 	switch bytecode.OperandWidths[op] {
 	case 1:
 		c.Code = append(c.Code, byte(op), 0)
@@ -105,7 +158,7 @@ func (c *Compiler) patchOperand(pos int, operand int) {
 	switch bytecode.OperandWidths[op] {
 	case 1:
 		if operand > 255 {
-			c.addError(fmt.Sprintf("operand %d too large for opcode %d", operand, op), 0, 0, "compiler")
+			c.addError(customError.OperandTooLarge, c.span, "compiler", operand, op)
 			return
 		}
 		c.Code[pos+1] = byte(operand)
@@ -151,24 +204,51 @@ func (c *Compiler) resolveVariable(name string) (slot int, depth int, isGlobal b
 	}
 
 	//Không tìm thấy ở cả local và global => Biến chưa được tạo ở scope hiện tại
-	// => Depth của scope hiện tại = độ dài mảng scope (do depth bắt đầu từ 1)
-	return 0, len(c.Scopes), false, false
+	// => Depth của scope hiện tại = 1
+	return 0, 1, false, false
+}
+
+// visibleNames lists every name resolveVariable can find from the current scope, with the builtins
+func (c *Compiler) visibleNames() []string {
+	var names []string
+	for _, scope := range c.Scopes {
+		for name := range scope {
+			names = append(names, name)
+		}
+	}
+	for name := range c.GlobalSymbols {
+		names = append(names, name)
+	}
+	for name := range c.BuiltinFuncs {
+		names = append(names, name)
+	}
+	for name := range c.BuiltinConstants {
+		names = append(names, name)
+	}
+	return names
 }
 
-func (c *Compiler) addError(message string, line, col int, context string) {
+// addError reports the error code at span, with its message formatted from args in the current
+// language, and returns it so that the caller can add notes or a hint
+func (c *Compiler) addError(code customError.Code, span ast.Span, context string, args ...interface{}) *customError.CompilationError {
 	err := customError.CompilationError{
 		PunError: customError.PunError{
-			Message: message,
-			Line:    line,
-			Column:  col,
+			Code:      code,
+			Message:   customError.Message(code, args...),
+			Line:      span.Start.Line,
+			Column:    span.Start.Col,
+			EndLine:   span.End.Line,
+			EndColumn: span.End.Col,
 		},
-		Context: context,
+		Context: customError.Text(context),
 	}
 	c.Errors = append(c.Errors, err)
+	return &c.Errors[len(c.Errors)-1]
 }
 func (c *Compiler) isValidVariableName(name string) bool {
-	if c.BuiltinFuncs[name] || c.BuiltinConstants[name] != 0 {
-		c.addError("Cannot redeclare built-in name", 0, 0, name)
+	if _, constant := c.BuiltinConstants[name]; c.BuiltinFuncs[name] || constant {
+		err := c.addError(customError.BuiltinRedeclared, c.span, name, name)
+		err.Notes = append(err.Notes, customError.Text("`%s` is built into Pun; choose another name", name))
 		return false
 	}
 	return true
@@ -177,14 +257,23 @@ func (c *Compiler) HasErrors() bool {
 	return len(c.Errors) > 0
 }
 
-func (c *Compiler) PrintErrors() {
+// Diagnostics returns the compilation errors as diagnostics, for tools that report them themselves
+func (c *Compiler) Diagnostics() []customError.Diagnostic {
+	diagnostics := make([]customError.Diagnostic, len(c.Errors))
+	for i := range c.Errors {
+		diagnostics[i] = &c.Errors[i]
+	}
+	return diagnostics
+}
+
+func (c *Compiler) PrintErrors(r *customError.Renderer) {
 	if !c.HasErrors() {
 		return
 	}
 
-	fmt.Println("🚨 COMPILATION ERRORS:")
-	for i, err := range c.Errors {
-		fmt.Printf("%d. %s\n", i+1, err.Error())
+	fmt.Println("🚨 " + customError.Text("COMPILATION ERRORS:"))
+	for i := range c.Errors {
+		fmt.Printf("%d. %s\n", i+1, r.Render(&c.Errors[i]))
 		fmt.Println(strings.Repeat("─", 60))
 	}
 }
@@ -0,0 +1,217 @@
+package compiler
+
+import (
+	"pun/bytecode"
+	"pun/error"
+	"pun/lexer"
+	"pun/parser"
+	"slices"
+	"testing"
+)
+
+// compile compiles input, which must parse without errors
+func compile(t *testing.T, input string) *Compiler {
+	t.Helper()
+	p := parser.NewParser(lexer.NewLexer(input))
+	program := p.ParseProgram()
+	if p.HasErrors() {
+		t.Fatalf("parse %q: %v", input, p.Diagnostics())
+	}
+	c := NewCompiler()
+	c.CompileProgram(program)
+	return c
+}
+
+func TestBuiltinRedeclared(t *testing.T) {
+	defer customError.SetLanguage(customError.Language())
+
+	tests := []struct {
+		input    string
+		lang     string
+		expected string
+	}{
+		{"print = 3", customError.English, `Cannot redeclare built-in name "print"`},
+		{"PI = 3", customError.English, `Cannot redeclare built-in name "PI"`},
+		{"func sort(a) { return a }", customError.English, `Cannot redeclare built-in name "sort"`},
+		{"for set in [1] {}", customError.English, `Cannot redeclare built-in name "set"`},
+		{"print = 3", customError.Vietnamese, `Không thể khai báo lại tên có sẵn "print"`},
+	}
+	for _, tt := range tests {
+		customError.SetLanguage(tt.lang)
+		c := compile(t, tt.input)
+		if len(c.Errors) != 1 {
+			t.Errorf("%q: got %d errors, want 1", tt.input, len(c.Errors))
+			continue
+		}
+		err := c.Errors[0]
+		if err.Code != customError.BuiltinRedeclared || err.Message != tt.expected {
+			t.Errorf("%q (%s): got %s %q, want %s %q", tt.input, tt.lang, err.Code, err.Message,
+				customError.BuiltinRedeclared, tt.expected)
+		}
+	}
+}
+
+// errorCodes compiles input and returns the codes of the compilation errors
+func errorCodes(t *testing.T, input string) []customError.Code {
+	t.Helper()
+	var codes []customError.Code
+	for _, err := range compile(t, input).Errors {
+		codes = append(codes, err.Code)
+	}
+	return codes
+}
+
+func TestExhaustiveness(t *testing.T) {
+	const enum = "enum Color { Red, Green, Blue }\nc = Color.Red\n"
+	tests := []struct {
+		name     string
+		input    string
+		expected []customError.Code
+	}{
+		{"match with every variant", "match c {\n Color.Red { 1 }\n Color.Green, Color.Blue { 2 }\n}", nil},
+		{"match missing a variant", "match c {\n Color.Red { 1 }\n Color.Green { 2 }\n}", []customError.Code{customError.NonExhaustiveMatch}},
+		{"match with else", "match c {\n Color.Red { 1 }\n else { 2 }\n}", nil},
+		{"match expression missing a variant", "x = match c {\n Color.Red { 1 }\n}", []customError.Code{customError.NonExhaustiveMatch}},
+		{"match on values", "match 1 {\n 1 { 1 }\n}", nil},
+		{"match repeating a variant", "match c {\n Color.Red { 1 }\n Color.Red { 2 }\n else { 3 }\n}", []customError.Code{customError.UnreachableArm}},
+		{"if expression with every variant", "x = if c == Color.Red { 1 } elif c == Color.Green { 2 } elif Color.Blue == c { 3 }", nil},
+		{"if expression missing a variant", "x = if c == Color.Red { 1 } elif c == Color.Green { 2 }", []customError.Code{customError.NonExhaustiveIf}},
+		{"if expression with else", "x = if c == Color.Red { 1 } else { 2 }", nil},
+		{"if statement missing variants", "if c == Color.Red { print(1) }", nil},
+		{"if expression on other conditions", "x = if c == Color.Red { 1 } elif true { 2 }", nil},
+		{"if expression on two variables", "d = c\nx = if c == Color.Red { 1 } elif d == Color.Green { 2 }", nil},
+		{"if as the value of a block", "x = { if c == Color.Red { 1 } }", []customError.Code{customError.NonExhaustiveIf}},
+	}
+	for _, tt := range tests {
+		if got := errorCodes(t, enum+tt.input); !slices.Equal(got, tt.expected) {
+			t.Errorf("%s: got errors %v, want %v", tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestDeferOutsideFunction(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []customError.Code
+	}{
+		{"defer print(1)", []customError.Code{customError.DeferOutsideFunction}},
+		{"if true { defer print(1) }", []customError.Code{customError.DeferOutsideFunction}},
+		{"func f() { defer print(1) }", nil},
+		{"func f() { while true { defer print(1) } }", nil},
+	}
+	for _, tt := range tests {
+		if got := errorCodes(t, tt.input); !slices.Equal(got, tt.expected) {
+			t.Errorf("%q: got errors %v, want %v", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestLoopLabels(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []customError.Code
+	}{
+		{"break", []customError.Code{customError.OutsideLoop}},
+		{"func f() { continue }", []customError.Code{customError.OutsideLoop}},
+		{"if true { break }", []customError.Code{customError.OutsideLoop}},
+		{"while true { break outer }", []customError.Code{customError.UnknownLoopLabel}},
+		{"a: while true { a: for x in [1] { break a } }", []customError.Code{customError.DuplicateLoopLabel}},
+		{"a: while true { b: until false { continue a } }", nil},
+		{"a: while true {}\na: while true { break a }", nil},
+	}
+	for _, tt := range tests {
+		if got := errorCodes(t, tt.input); !slices.Equal(got, tt.expected) {
+			t.Errorf("%q: got errors %v, want %v", tt.input, got, tt.expected)
+		}
+	}
+}
+
+// opcodes decodes the instructions of code
+func opcodes(code []byte) []bytecode.Opcode {
+	var ops []bytecode.Opcode
+	for ip := 0; ip < len(code); {
+		op := bytecode.Opcode(code[ip])
+		ops = append(ops, op)
+		ip += 1 + bytecode.OperandWidths[op]
+	}
+	return ops
+}
+
+func TestShortCircuitJumps(t *testing.T) {
+	// ?:, ?? và ?. là các lệnh nhảy, không phải lời gọi hàm
+	tests := []struct {
+		input string
+		jump  bytecode.Opcode
+	}{
+		{"x = 1\ny = x > 0 ? 1 : 2", bytecode.OP_JUMP_IF_FALSE},
+		{"x = 1\ny = x ?? 2", bytecode.OP_JUMP_IF_NOT_NOTHING},
+		{"x = 1\ny = x?.size", bytecode.OP_JUMP_IF_NOTHING},
+		{"x = 1\ny = x?.[0]", bytecode.OP_JUMP_IF_NOTHING},
+	}
+	for _, tt := range tests {
+		c := compile(t, tt.input)
+		ops := opcodes(c.Code)
+		if !slices.Contains(ops, tt.jump) || slices.Contains(ops, bytecode.OP_CALL) {
+			t.Errorf("%q: got opcodes %v, want %v and no call", tt.input, ops, tt.jump)
+		}
+	}
+}
+
+func TestSpecialMethods(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []customError.Code
+	}{
+		{"record A(x)\nfunc A.__add__(other) { return 1 }\nfunc A.__str__() { return \"a\" }", nil},
+		{"record A(x)\nfunc A.__add__(a, b) { return 1 }", []customError.Code{customError.SpecialMethodArity}},
+		{"record A(x)\nfunc A.__foo__() {}", []customError.Code{customError.UnknownSpecialMethod}},
+		{"func B.m() {}", []customError.Code{customError.MethodOnNonRecord}},
+	}
+	for _, tt := range tests {
+		if got := errorCodes(t, tt.input); !slices.Equal(got, tt.expected) {
+			t.Errorf("%q: got errors %v, want %v", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestLeaveValueExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []customError.Code
+	}{
+		{"while true { x = { break } }", []customError.Code{customError.LeaveValueExpression}},
+		{"while true { x = if true { continue } else { 1 } }", []customError.Code{customError.LeaveValueExpression}},
+		{"while true { x = { while true { break } } }", nil},
+		{"while true { if true { break } }", nil},
+	}
+	for _, tt := range tests {
+		if got := errorCodes(t, tt.input); !slices.Equal(got, tt.expected) {
+			t.Errorf("%q: got errors %v, want %v", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestUndefinedVariableSuggestion(t *testing.T) {
+	defer customError.SetLanguage(customError.Language())
+	customError.SetLanguage(customError.English)
+
+	tests := []struct {
+		input string
+		help  string
+	}{
+		{"count = 1\nprint(cuont)", "did you mean `count`?"},
+		{"func f(total) {\n    return totl\n}", "did you mean `total`?"},
+		{"print(pritn)", "did you mean `print`?"},
+		{"print(zzz)", ""},
+	}
+	for _, tt := range tests {
+		c := compile(t, tt.input)
+		if len(c.Errors) != 1 || c.Errors[0].Code != customError.UndefinedVariable {
+			t.Errorf("%q: got errors %v, want %s", tt.input, c.Errors, customError.UndefinedVariable)
+			continue
+		}
+		if err := c.Errors[0]; err.Help != tt.help {
+			t.Errorf("%q: got help %q, want %q", tt.input, err.Help, tt.help)
+		}
+	}
+}
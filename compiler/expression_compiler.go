@@ -3,24 +3,58 @@ package compiler
 import (
 	"pun/ast"
 	"pun/bytecode"
+	"pun/decimal"
+	"pun/error"
 )
 
 func (c *Compiler) compileExpression(expr ast.Expression) {
+	defer c.at(expr)()
 	switch e := expr.(type) {
 	case *ast.NumberExpression:
 		constIndex := c.addConstant(e.Value)
 		c.emit(bytecode.OP_LOAD_CONST, constIndex)
 
+	case *ast.BigIntExpression:
+		constIndex := c.addConstant(e.Value)
+		c.emit(bytecode.OP_LOAD_CONST, constIndex)
+
+	case *ast.DecimalExpression:
+		value, err := decimal.Parse(e.Value)
+		if err != nil {
+			c.addError(customError.InvalidDecimal, e.Span, e.Value+"d", e.Value)
+			return
+		}
+		c.emit(bytecode.OP_LOAD_CONST, c.addConstant(value))
+
 	case *ast.StringExpression:
 		constIndex := c.addConstant(e.Value)
 		c.emit(bytecode.OP_LOAD_CONST, constIndex)
 
+	case *ast.TemplateExpression:
+		if len(e.Parts) > 255 {
+			c.addError(customError.TooManyTemplateParts, e.Span, "template")
+			return
+		}
+		for _, part := range e.Parts {
+			c.compileExpression(part)
+		}
+		// Nối tất cả các phần bằng một lệnh duy nhất (chỉ cấp phát một lần)
+		c.emit(bytecode.OP_BUILD_STRING, len(e.Parts))
+
+	case *ast.InterpolationExpression:
+		c.compileExpression(e.Value)
+		if e.Format != "" {
+			c.emit(bytecode.OP_FORMAT, c.addConstant(e.Format))
+		}
+
 	case *ast.BooleanExpression:
 		constIndex := c.addConstant(e.Value)
 		c.emit(bytecode.OP_LOAD_CONST, constIndex)
 
 	case *ast.NothingExpression:
 		c.emit(bytecode.OP_LOAD_NOTHING)
+	case *ast.BadExpression:
+		c.emit(bytecode.OP_LOAD_NOTHING) // Giữ stack cân bằng, lỗi cú pháp đã được báo
 	case *ast.Identifier:
 		// 1. Kiểm tra nếu là built-in constant
 		if index, ok := c.BuiltinConstants[e.Value]; ok {
@@ -46,7 +80,8 @@ func (c *Compiler) compileExpression(expr ast.Expression) {
 				c.emit(bytecode.OP_LOAD_LOCAL, operand)
 			}
 		} else {
-			c.addError("undefined variable", 0, 0, e.Value)
+			err := c.addError(customError.UndefinedVariable, c.span, e.Value)
+			err.SuggestName(e.Value, c.visibleNames())
 		}
 
 	case *ast.UnaryExpression:
@@ -56,6 +91,8 @@ func (c *Compiler) compileExpression(expr ast.Expression) {
 			c.emit(bytecode.OP_NEG)
 		case "!":
 			c.emit(bytecode.OP_NOT)
+		case "~":
+			c.emit(bytecode.OP_BIT_NOT)
 		}
 
 	case *ast.ArrayExpression:
@@ -65,11 +102,48 @@ func (c *Compiler) compileExpression(expr ast.Expression) {
 		}
 		// Tạo array với số lượng element
 		c.emit(bytecode.OP_MAKE_ARRAY, len(e.Elements))
+		if e.Frozen {
+			c.emit(bytecode.OP_FREEZE)
+		}
 
-	case *ast.ArrayIndexExpression:
-		c.compileExpression(e.Array)
-		c.compileExpression(e.Index)
-		c.emit(bytecode.OP_ARRAY_GET)
+	case *ast.TupleExpression:
+		c.compileElements(e.Elements, bytecode.OP_MAKE_TUPLE, "tuple")
+
+	case *ast.SetExpression:
+		c.compileElements(e.Elements, bytecode.OP_MAKE_SET, "set")
+
+	case *ast.ArrayIndexExpression, *ast.PropertyExpression, *ast.MethodCallExpression:
+		c.compileChain(e)
+
+	case *ast.IfExpression:
+		c.valueDepth++
+		c.compileIf(e.Statement, true)
+		c.valueDepth--
+
+	case *ast.MatchExpression:
+		c.valueDepth++
+		c.compileMatch(e.Statement, true)
+		c.valueDepth--
+
+	case *ast.BlockExpression:
+		c.valueDepth++
+		c.compileValueBlock(e.Block)
+		c.valueDepth--
+
+	case *ast.AssignExpression:
+		// Gán rồi đọc lại biến để giá trị vẫn nằm trên stack
+		c.compileExpression(e.Value)
+		c.storeVariable(e.Name.Value)
+		c.compileExpression(e.Name)
+
+	case *ast.ConditionalExpression:
+		c.compileExpression(e.Condition)
+		elsePos := c.emitWithPatch(bytecode.OP_JUMP_IF_FALSE)
+		c.compileExpression(e.Consequence)
+		endPos := c.emitWithPatch(bytecode.OP_JUMP)
+		c.patchOperand(elsePos, len(c.Code))
+		c.compileExpression(e.Alternative)
+		c.patchOperand(endPos, len(c.Code))
 
 	case *ast.FunctionCallExpression:
 		// Compile từng argument
@@ -82,6 +156,15 @@ func (c *Compiler) compileExpression(expr ast.Expression) {
 		c.emit(bytecode.OP_CALL, len(e.Arguments))
 
 	case *ast.BinaryExpression:
+		if e.Operator == "??" {
+			// a ?? b: chỉ tính b khi a là nothing
+			c.compileExpression(e.Left)
+			endPos := c.emitWithPatch(bytecode.OP_JUMP_IF_NOT_NOTHING)
+			c.emit(bytecode.OP_POP)
+			c.compileExpression(e.Right)
+			c.patchOperand(endPos, len(c.Code))
+			return
+		}
 		c.compileExpression(e.Left)
 		c.compileExpression(e.Right)
 		switch e.Operator {
@@ -113,6 +196,79 @@ func (c *Compiler) compileExpression(expr ast.Expression) {
 			c.emit(bytecode.OP_AND)
 		case "||":
 			c.emit(bytecode.OP_OR)
+		case "&":
+			c.emit(bytecode.OP_BIT_AND)
+		case "|":
+			c.emit(bytecode.OP_BIT_OR)
+		case "^":
+			c.emit(bytecode.OP_BIT_XOR)
+		case "<<":
+			c.emit(bytecode.OP_SHL)
+		case ">>":
+			c.emit(bytecode.OP_SHR)
 		}
 	}
 }
+
+// compileElements pushes every element, then builds the collection with op
+func (c *Compiler) compileElements(elements []ast.Expression, op bytecode.Opcode, kind string) {
+	if len(elements) > 255 {
+		c.addError(customError.TooManyElements, c.span, kind, kind)
+		return
+	}
+	for _, elem := range elements {
+		c.compileExpression(elem)
+	}
+	c.emit(op, len(elements))
+}
+
+// compileChain compiles a chain of index/property/method accesses.
+// Every ?. in the chain jumps to the end of the whole chain when its object is nothing,
+// so a?.b.c gives nothing instead of failing on .c
+func (c *Compiler) compileChain(expr ast.Expression) {
+	var nothingJumps []int
+	c.compileChainLink(expr, &nothingJumps)
+
+	endPos := len(c.Code)
+	for _, pos := range nothingJumps {
+		c.patchOperand(pos, endPos)
+	}
+}
+
+func (c *Compiler) compileChainLink(expr ast.Expression, nothingJumps *[]int) {
+	switch e := expr.(type) {
+	case *ast.ArrayIndexExpression:
+		c.compileChainObject(e.Array, e.Optional, nothingJumps)
+		c.compileExpression(e.Index)
+		c.emit(bytecode.OP_ARRAY_GET)
+
+	case *ast.PropertyExpression:
+		c.compileChainObject(e.Object, e.Optional, nothingJumps)
+		c.emit(bytecode.OP_GET_PROPERTY, c.addConstant(e.Property))
+
+	case *ast.MethodCallExpression:
+		c.compileChainObject(e.Caller, e.Optional, nothingJumps)
+		for _, arg := range e.Arguments {
+			c.compileExpression(arg)
+		}
+		nameIndex := c.addConstant(e.Method)
+		if nameIndex > 255 || len(e.Arguments) > 255 {
+			c.addError(customError.TooManyMethodArguments, e.Span, e.Method)
+			return
+		}
+		c.emit(bytecode.OP_CALL_METHOD, nameIndex<<8|len(e.Arguments))
+	}
+}
+
+func (c *Compiler) compileChainObject(object ast.Expression, optional bool, nothingJumps *[]int) {
+	switch object.(type) {
+	case *ast.ArrayIndexExpression, *ast.PropertyExpression, *ast.MethodCallExpression:
+		c.compileChainLink(object, nothingJumps)
+	default:
+		c.compileExpression(object)
+	}
+
+	if optional {
+		*nothingJumps = append(*nothingJumps, c.emitWithPatch(bytecode.OP_JUMP_IF_NOTHING))
+	}
+}
@@ -1,34 +1,56 @@
 package compiler
 
 import (
-	"fmt"
 	"pun/ast"
 	"pun/bytecode"
+	"pun/error"
+	"strings"
 )
 
 func (c *Compiler) compileStatement(stmt ast.Statement) {
+	defer c.at(stmt)()
 	switch s := stmt.(type) {
 	case *ast.ExpressionStatement:
 		c.compileExpression(s.Expression)
+		// Bỏ kết quả không dùng để stack không phình ra (++/-- chưa sinh giá trị)
+		if _, ok := s.Expression.(*ast.IncDecExpression); !ok {
+			c.emit(bytecode.OP_POP)
+		}
 	case *ast.AssignStatement:
 
 		c.compileAssign(s)
+	case *ast.BadStatement:
+		// Lỗi cú pháp đã được báo, chương trình có lỗi parse thì không được compile
 	case *ast.IfStatement:
-		c.compileIf(s)
+		c.compileIf(s, false)
 	case *ast.ForStatement:
 		c.compileFor(s)
+	case *ast.ForInStatement:
+		c.compileForIn(s)
 	case *ast.WhileStatement:
 		c.compileWhile(s)
+	case *ast.UntilStatement:
+		c.compileUntil(s)
 	case *ast.FunctionDefinitionStatement:
 		c.compileFuncDef(s)
 	case *ast.ReturnStatement:
 		c.compileReturn(s)
 	case *ast.BreakStatement:
-		c.compileBreak()
+		c.compileBreak(s)
 	case *ast.ContinueStatement:
-		c.compileContinue()
+		c.compileContinue(s)
+	case *ast.DeferStatement:
+		c.compileDefer(s)
+	case *ast.RecordStatement:
+		c.compileRecord(s)
+	case *ast.MethodDefinitionStatement:
+		c.compileMethodDef(s)
+	case *ast.EnumStatement:
+		c.compileEnum(s)
+	case *ast.MatchStatement:
+		c.compileMatch(s, false)
 	default:
-		c.addError(fmt.Sprintf("Unsupported statement type: %T", stmt), 0, 0, "compile statement")
+		c.addError(customError.UnsupportedStatement, c.span, "compile statement", stmt)
 	}
 }
 
@@ -39,41 +61,7 @@ func (c *Compiler) compileAssign(s *ast.AssignStatement) {
 	// Xử lý target assignment
 	switch target := s.Name.(type) {
 	case *ast.Identifier:
-		name := target.Value
-
-		// Check tên biến hợp lệ (không trùng built-in)
-		if !c.isValidVariableName(name) {
-			return // Đã có error trong isValidVariableName
-		}
-
-		// Global scope (không có thì tạo mới, có thì cho operand = slot của cái đang có)
-		if len(c.Scopes) == 0 {
-			idx, exists := c.GlobalSymbols[name]
-			if !exists {
-				idx = len(c.GlobalSymbols)
-				c.GlobalSymbols[name] = idx
-			}
-			c.emit(bytecode.OP_STORE_GLOBAL, idx)
-			return
-		}
-
-		// Dùng resolveVariable để xử lí biến trong scope
-		slot, depth, isGlobal, exists := c.resolveVariable(name)
-
-		if isGlobal {
-			c.emit(bytecode.OP_STORE_GLOBAL, slot) // Global override
-		} else if exists {
-			initDepth := c.LocalInitDepth[name] // lay depth cua scope ma bien nay duoc khoi tao (de gan cho bien do)
-			operand := initDepth<<8 | slot
-			c.emit(bytecode.OP_STORE_LOCAL, operand) // Local reassign
-		} else {
-			// Tạo local mới nếu biến chưa tồn tại anywhere
-			newSlot := len(c.CurrentScope)
-			c.CurrentScope[name] = newSlot
-			c.LocalInitDepth[name] = depth
-			operand := depth<<8 | newSlot
-			c.emit(bytecode.OP_STORE_LOCAL, operand)
-		}
+		c.storeVariable(target.Value)
 
 	case *ast.ArrayIndexExpression:
 		// Thêm check kiểu array trước khi gán
@@ -82,7 +70,43 @@ func (c *Compiler) compileAssign(s *ast.AssignStatement) {
 		c.emit(bytecode.OP_ARRAY_SET)
 
 	default:
-		c.addError(fmt.Sprintf("Unsupported assignment target: %T", target), 0, 0, "")
+		c.addError(customError.UnsupportedAssignTarget, c.span, "", target)
+	}
+}
+
+// storeVariable pops the top of the stack into the variable name,
+// creating it in the current scope if it does not exist yet
+func (c *Compiler) storeVariable(name string) {
+	// Check tên biến hợp lệ (không trùng built-in)
+	if !c.isValidVariableName(name) {
+		return // Đã có error trong isValidVariableName
+	}
+
+	// Global scope (không có thì tạo mới, có thì cho operand = slot của cái đang có)
+	if len(c.Scopes) == 0 {
+		idx, exists := c.GlobalSymbols[name]
+		if !exists {
+			idx = len(c.GlobalSymbols)
+			c.GlobalSymbols[name] = idx
+		}
+		c.emit(bytecode.OP_STORE_GLOBAL, idx)
+		return
+	}
+
+	// Dùng resolveVariable để xử lí biến trong scope (depth tính tương đối từ scope hiện tại)
+	slot, depth, isGlobal, exists := c.resolveVariable(name)
+
+	if isGlobal {
+		c.emit(bytecode.OP_STORE_GLOBAL, slot) // Global override
+	} else if exists {
+		operand := depth<<8 | slot
+		c.emit(bytecode.OP_STORE_LOCAL, operand) // Local reassign
+	} else {
+		// Tạo local mới trong scope hiện tại (depth = 1) nếu biến chưa tồn tại anywhere
+		newSlot := len(c.CurrentScope)
+		c.CurrentScope[name] = newSlot
+		operand := 1<<8 | newSlot
+		c.emit(bytecode.OP_STORE_LOCAL, operand)
 	}
 }
 
@@ -110,7 +134,54 @@ func (c *Compiler) compileIfBlock(s *ast.BlockStatement) {
 	c.emit(bytecode.OP_LEAVE_SCOPE)
 }
 
-func (c *Compiler) compileIf(s *ast.IfStatement) {
+// compileValueBlock compiles a block in its own scope, leaving the value of the block on the stack
+func (c *Compiler) compileValueBlock(s *ast.BlockStatement) {
+	c.enterScope()
+	enterScopePos := c.emitWithPatch(bytecode.OP_ENTER_SCOPE)
+	c.compileBlockValue(s)
+	c.patchOperand(enterScopePos, len(c.CurrentScope))
+	c.leaveScope()
+	c.emit(bytecode.OP_LEAVE_SCOPE)
+}
+
+// compileBlockValue compiles the statements of a block so that exactly one value is left on
+// the stack: the value of the last statement, or nothing if that statement has no value
+func (c *Compiler) compileBlockValue(s *ast.BlockStatement) {
+	if len(s.Statements) == 0 {
+		c.emit(bytecode.OP_LOAD_NOTHING)
+		return
+	}
+	last := len(s.Statements) - 1
+	for _, stmt := range s.Statements[:last] {
+		c.compileStatement(stmt)
+	}
+
+	switch stmt := s.Statements[last].(type) {
+	case *ast.ExpressionStatement:
+		if _, ok := stmt.Expression.(*ast.IncDecExpression); ok {
+			c.compileStatement(stmt)
+			c.emit(bytecode.OP_LOAD_NOTHING)
+			return
+		}
+		c.compileExpression(stmt.Expression)
+	case *ast.IfStatement:
+		c.compileIf(stmt, true)
+	case *ast.MatchStatement:
+		c.compileMatch(stmt, true)
+	default:
+		c.compileStatement(stmt)
+		c.emit(bytecode.OP_LOAD_NOTHING)
+	}
+}
+
+// compileIf compiles an if statement. As a value (asValue = true) the taken branch
+// leaves its value on the stack, and nothing is pushed if no branch is taken.
+func (c *Compiler) compileIf(s *ast.IfStatement, asValue bool) {
+	compileBranch := c.compileIfBlock
+	if asValue {
+		compileBranch = c.compileValueBlock
+	}
+
 	// Compile condition
 	c.compileExpression(s.Condition)
 
@@ -118,7 +189,7 @@ func (c *Compiler) compileIf(s *ast.IfStatement) {
 	jumpToElsePos := c.emitWithPatch(bytecode.OP_JUMP_IF_FALSE)
 
 	// Compile if body
-	c.compileIfBlock(s.Body)
+	compileBranch(s.Body)
 
 	// Emit jump-to-end with temporary operand
 	jumpToEndPos := c.emitWithPatch(bytecode.OP_JUMP)
@@ -134,7 +205,7 @@ func (c *Compiler) compileIf(s *ast.IfStatement) {
 		c.compileExpression(elif.Condition)
 		jumpToNextPos := c.emitWithPatch(bytecode.OP_JUMP_IF_FALSE)
 
-		c.compileIfBlock(elif.Body)
+		compileBranch(elif.Body)
 
 		// Add jump to end
 		endJumps = append(endJumps, c.emitWithPatch(bytecode.OP_JUMP))
@@ -146,7 +217,10 @@ func (c *Compiler) compileIf(s *ast.IfStatement) {
 
 	// Handle else block
 	if s.ElseBlock != nil {
-		c.compileIfBlock(s.ElseBlock.Body)
+		compileBranch(s.ElseBlock.Body)
+	} else if asValue {
+		c.emit(bytecode.OP_LOAD_NOTHING)
+		c.checkVariantBranches(s)
 	}
 
 	// Record end position
@@ -159,17 +233,69 @@ func (c *Compiler) compileIf(s *ast.IfStatement) {
 	}
 }
 
+// checkVariantBranches reports an if without else, used as a value, whose conditions compare one
+// variable with the variants of an enum (if c == Color.Red { ... } elif c == Color.Green { ... })
+// but leave some variants out: like a match, it must handle all of them
+func (c *Compiler) checkVariantBranches(s *ast.IfStatement) {
+	conditions := []ast.Expression{s.Condition}
+	for _, elif := range s.ElseIfs {
+		conditions = append(conditions, elif.Condition)
+	}
+
+	var subject string
+	var enum *bytecode.EnumType
+	covered := map[*bytecode.EnumVariant]bool{}
+	for _, condition := range conditions {
+		name, variant := c.variantComparison(condition)
+		if variant == nil || (subject != "" && name != subject) || (enum != nil && variant.Enum != enum) {
+			return // Không phải một chuỗi so sánh cùng một biến với variant của cùng một enum
+		}
+		subject, enum = name, variant.Enum
+		covered[variant] = true
+	}
+
+	var missing []string
+	for _, variant := range enum.Variants {
+		if !covered[variant] {
+			missing = append(missing, enum.Name+"."+variant.Name)
+		}
+	}
+	if len(missing) > 0 {
+		c.addError(customError.NonExhaustiveIf, s.Span, "if", enum.Name, strings.Join(missing, ", "))
+	}
+}
+
+// variantComparison recognizes name == Enum.Variant and Enum.Variant == name
+func (c *Compiler) variantComparison(condition ast.Expression) (string, *bytecode.EnumVariant) {
+	comparison, ok := condition.(*ast.BinaryExpression)
+	if !ok || comparison.Operator != "==" {
+		return "", nil
+	}
+	for _, sides := range [][2]ast.Expression{{comparison.Left, comparison.Right}, {comparison.Right, comparison.Left}} {
+		name, ok := sides[0].(*ast.Identifier)
+		property, isProperty := sides[1].(*ast.PropertyExpression)
+		if !ok || !isProperty || property.Optional {
+			continue
+		}
+		enumName, ok := property.Object.(*ast.Identifier)
+		if !ok {
+			continue
+		}
+		if enum, ok := c.enums[enumName.Value]; ok {
+			if variant, ok := enum.Variant(property.Property); ok {
+				return name.Value, variant
+			}
+		}
+	}
+	return "", nil
+}
+
 func (c *Compiler) compileFor(s *ast.ForStatement) {
-	// Save current break positions stack
-	oldBreakPositions := c.breakPositions
-	c.breakPositions = make([]int, 0)
-	// Save current continue positions stack
-	oldContinuePositions := c.continuePositions
-	c.continuePositions = make([]int, 0)
 	// 1. Create new scope for loop variables
 	c.enterScope()
 	// Save position for ENTER_SCOPE instruction - will patch with final local var count
 	enterScopePos := c.emitWithPatch(bytecode.OP_ENTER_SCOPE)
+	loop := c.beginLoop(s.Label, s.Line)
 
 	// 2. Compile initialization statement (runs once before loop)
 	c.compileStatement(s.Init)
@@ -182,123 +308,237 @@ func (c *Compiler) compileFor(s *ast.ForStatement) {
 
 	// 5. Emit conditional jump to end (if condition is false)
 	// Save position to patch later with end position
-	endJumpPos := c.emitWithPatch(bytecode.OP_JUMP_IF_FALSE)
+	exitJumpPos := c.emitWithPatch(bytecode.OP_JUMP_IF_FALSE)
 
 	// 6. Compile loop body
 	c.compileBlock(s.Body)
 
-	// 7. Compile update statement (runs after each iteration)
+	// 7. Compile update statement (runs after each iteration, continue jumps here)
+	updatePos := len(c.Code)
 	c.compileStatement(s.Update)
 
 	// 8. Jump back to condition check
 	c.emit(bytecode.OP_JUMP, startPos)
 
-	// 9. Record end position and patch the conditional jump
-	endPos := len(c.Code)
-	c.patchOperand(endJumpPos, endPos)
+	// 9. Condition false => loop finished without break => run else block
+	c.patchOperand(exitJumpPos, len(c.Code))
+	if s.ElseBlock != nil {
+		c.compileIfBlock(s.ElseBlock.Body)
+	}
 
-	// Patch all break jumps to end position
-	for _, pos := range c.breakPositions {
-		c.patchOperand(pos, endPos)
+	// 10. Patch break (sau else) and continue jumps
+	c.endLoop(loop, updatePos, len(c.Code))
+
+	// 11. Patch ENTER_SCOPE with final local variable count
+	c.patchOperand(enterScopePos, len(c.CurrentScope))
+
+	// 12. Clean up scope
+	c.leaveScope()
+	c.emit(bytecode.OP_LEAVE_SCOPE)
+}
+
+func (c *Compiler) compileForIn(s *ast.ForInStatement) {
+	// 1. Create new scope for the iterator and the loop variable
+	c.enterScope()
+	enterScopePos := c.emitWithPatch(bytecode.OP_ENTER_SCOPE)
+	loop := c.beginLoop(s.Label, s.Line)
+
+	// 2. Iterator lưu trong local ẩn (tên có dấu #) để break/continue không phải dọn stack
+	iterSlot := len(c.CurrentScope)
+	c.CurrentScope["#iter"] = iterSlot
+	c.compileExpression(s.Iterable)
+	c.emit(bytecode.OP_ITER)
+	c.emit(bytecode.OP_STORE_LOCAL, 1<<8|iterSlot)
+
+	// 3. Each iteration (continue jumps here): take the next element or leave the loop
+	startPos := len(c.Code)
+	c.loadLocal("#iter")
+	exitJumpPos := c.emitWithPatch(bytecode.OP_ITER_NEXT)
+
+	// 4. Biến của vòng lặp là local mới, che biến cùng tên ở ngoài
+	if c.isValidVariableName(s.Variable.Value) {
+		slot := len(c.CurrentScope)
+		c.CurrentScope[s.Variable.Value] = slot
+		c.emit(bytecode.OP_STORE_LOCAL, 1<<8|slot)
 	}
 
-	// Restore previous break positions
-	c.breakPositions = oldBreakPositions
+	// 5. Compile loop body and jump back
+	c.compileBlock(s.Body)
+	c.emit(bytecode.OP_JUMP, startPos)
 
-	// Patch all continue jumps to point to start of condition check
-	for _, pos := range c.continuePositions {
-		c.patchOperand(pos, startPos)
+	// 6. No elements left => loop finished without break => run else block
+	c.patchOperand(exitJumpPos, len(c.Code))
+	if s.ElseBlock != nil {
+		c.compileIfBlock(s.ElseBlock.Body)
 	}
 
-	// Restore previous continue positions
-	c.continuePositions = oldContinuePositions
-	// 10. Patch ENTER_SCOPE with final local variable count
-	c.patchOperand(enterScopePos, len(c.CurrentScope))
+	// 7. Patch break (sau else) and continue jumps
+	c.endLoop(loop, startPos, len(c.Code))
 
-	// 11. Clean up scope
+	// 8. Patch ENTER_SCOPE with final local variable count, then clean up scope
+	c.patchOperand(enterScopePos, len(c.CurrentScope))
 	c.leaveScope()
 	c.emit(bytecode.OP_LEAVE_SCOPE)
 }
 
 func (c *Compiler) compileWhile(s *ast.WhileStatement) {
-	// Save current break positions stack
-	oldBreakPositions := c.breakPositions
-	c.breakPositions = make([]int, 0)
-	// Save current continue positions stack
-	oldContinuePositions := c.continuePositions
-	c.continuePositions = make([]int, 0)
+	c.compileConditionLoop(s.Label, s.Condition, false, s.Body, s.ElseBlock, s.Line)
+}
+
+func (c *Compiler) compileUntil(s *ast.UntilStatement) {
+	c.compileConditionLoop(s.Label, s.Condition, true, s.Body, s.ElseBlock, s.Line)
+}
+
+// compileConditionLoop compiles while loops, and until loops (negate = true)
+func (c *Compiler) compileConditionLoop(label string, condition ast.Expression, negate bool, body *ast.BlockStatement, elseBlock *ast.ElseStatement, line int) {
 	// 1. Create new scope for loop variables
 	c.enterScope()
 	// Save position for ENTER_SCOPE instruction - will patch with final local var count
 	enterScopePos := c.emitWithPatch(bytecode.OP_ENTER_SCOPE)
+	loop := c.beginLoop(label, line)
 
 	// 2. Mark start of loop for continue statements
 	startPos := len(c.Code)
 
-	// 3. Compile condition
-	c.compileExpression(s.Condition)
+	// 3. Compile condition (until lặp cho tới khi điều kiện đúng)
+	c.compileExpression(condition)
+	if negate {
+		c.emit(bytecode.OP_NOT)
+	}
 
 	// 4. Emit conditional jump to end with temporary operand
-	endJumpPos := c.emitWithPatch(bytecode.OP_JUMP_IF_FALSE)
+	exitJumpPos := c.emitWithPatch(bytecode.OP_JUMP_IF_FALSE)
 
 	// 5. Compile loop body
-	c.compileBlock(s.Body)
+	c.compileBlock(body)
 
 	// 6. Jump back to condition check
 	c.emit(bytecode.OP_JUMP, startPos)
 
-	// 7. Record end position and patch the conditional jump
-	endPos := len(c.Code)
-	c.patchOperand(endJumpPos, endPos)
-
-	// Patch all break jumps to end position
-	for _, pos := range c.breakPositions {
-		c.patchOperand(pos, endPos)
+	// 7. Loop finished without break => run else block
+	c.patchOperand(exitJumpPos, len(c.Code))
+	if elseBlock != nil {
+		c.compileIfBlock(elseBlock.Body)
 	}
 
-	// Restore previous break positions
-	c.breakPositions = oldBreakPositions
-
-	// Patch all continue jumps to point to start of condition check
-	for _, pos := range c.continuePositions {
-		c.patchOperand(pos, startPos)
-	}
+	// 8. Patch break (sau else) and continue jumps
+	c.endLoop(loop, startPos, len(c.Code))
 
-	// Restore previous continue positions
-	c.continuePositions = oldContinuePositions
-	// 8. Patch ENTER_SCOPE with final local variable count
+	// 9. Patch ENTER_SCOPE with final local variable count
 	c.patchOperand(enterScopePos, len(c.CurrentScope))
 
-	// 9. Clean up scope
+	// 10. Clean up scope
 	c.leaveScope()
 	c.emit(bytecode.OP_LEAVE_SCOPE)
 }
 
-func (c *Compiler) compileBreak() {
+// beginLoop pushes a loop context, must be called right after entering the loop scope
+func (c *Compiler) beginLoop(label string, line int) *loopContext {
+	if label != "" && c.findLoop(label) != nil {
+		c.addError(customError.DuplicateLoopLabel, c.span, label, label)
+	}
+	loop := &loopContext{label: label, scopeDepth: len(c.Scopes), valueDepth: c.valueDepth}
+	c.loops = append(c.loops, loop)
+	return loop
+}
+
+// endLoop patches all break/continue jumps of the loop and pops it
+func (c *Compiler) endLoop(loop *loopContext, continuePos, breakPos int) {
+	for _, pos := range loop.breakPositions {
+		c.patchOperand(pos, breakPos)
+	}
+	for _, pos := range loop.continuePositions {
+		c.patchOperand(pos, continuePos)
+	}
+	c.loops = c.loops[:len(c.loops)-1]
+}
+
+// findLoop returns the innermost loop, or the loop with the given label
+func (c *Compiler) findLoop(label string) *loopContext {
+	for i := len(c.loops) - 1; i >= 0; i-- {
+		if label == "" || c.loops[i].label == label {
+			return c.loops[i]
+		}
+	}
+	return nil
+}
+
+// targetLoop resolves the loop of a break/continue and reports an error if there is none
+func (c *Compiler) targetLoop(keyword, label string, line int) *loopContext {
+	loop := c.findLoop(label)
+	if loop == nil {
+		if label != "" {
+			err := c.addError(customError.UnknownLoopLabel, c.span, keyword, label)
+			var labels []string
+			for _, loop := range c.loops {
+				labels = append(labels, loop.label)
+			}
+			err.Help = customError.Suggest(label, labels)
+		} else {
+			c.addError(customError.OutsideLoop, c.span, keyword, keyword)
+		}
+		return nil
+	}
+	if loop.valueDepth != c.valueDepth {
+		// Giá trị tạm của biểu thức bao quanh vẫn nằm trên stack, không nhảy ra được
+		c.addError(customError.LeaveValueExpression, c.span, keyword, keyword)
+		return nil
+	}
+
+	// Thoát các scope (if, vòng lặp trong) nằm giữa lệnh nhảy và thân vòng lặp đích
+	for i := len(c.Scopes); i > loop.scopeDepth; i-- {
+		c.emit(bytecode.OP_LEAVE_SCOPE)
+	}
+	return loop
+}
+
+func (c *Compiler) compileBreak(s *ast.BreakStatement) {
+	loop := c.targetLoop("break", s.Label, s.Line)
+	if loop == nil {
+		return
+	}
+
 	// Save position of the break jump instruction to patch later
 	breakPos := c.emitWithPatch(bytecode.OP_JUMP)
 
 	// Track this break position to patch when we know the end of the loop
-	c.breakPositions = append(c.breakPositions, breakPos)
+	loop.breakPositions = append(loop.breakPositions, breakPos)
 }
 
-func (c *Compiler) compileContinue() {
+func (c *Compiler) compileContinue(s *ast.ContinueStatement) {
+	loop := c.targetLoop("continue", s.Label, s.Line)
+	if loop == nil {
+		return
+	}
+
 	// Save position of the continue jump instruction to patch later
 	continuePos := c.emitWithPatch(bytecode.OP_JUMP)
 
 	// Track this continue position to patch when we know the update/start position
-	c.continuePositions = append(c.continuePositions, continuePos)
+	loop.continuePositions = append(loop.continuePositions, continuePos)
 }
 
 func (c *Compiler) compileReturn(s *ast.ReturnStatement) {
 	if !c.IsInsideFunction {
-		c.addError("return statement outside of a function", 0, 0, "return")
+		c.addError(customError.ReturnOutsideFunction, c.span, "return")
 	}
+	// return f(...): gọi f trong frame hiện tại thay vì tạo frame mới.
+	// OP_RETURN phía sau chỉ chạy khi VM không thể dùng lại frame (builtin, còn defer)
+	if call, ok := s.Value.(*ast.FunctionCallExpression); ok && c.TailCalls && c.IsInsideFunction {
+		for _, arg := range call.Arguments {
+			c.compileExpression(arg)
+		}
+		c.compileExpression(call.Function)
+		c.emit(bytecode.OP_TAIL_CALL, len(call.Arguments))
+		c.emit(bytecode.OP_RETURN)
+		return
+	}
+
 	//Có giá trị thì compile giá trị, không thì compile nothing
 	if s.Value != nil {
 		c.compileExpression(s.Value)
 	} else {
-		c.compileExpression(ast.NothingExpression{})
+		c.compileExpression(&ast.NothingExpression{})
 	}
 
 	//emit lệnh return
@@ -308,7 +548,7 @@ func (c *Compiler) compileReturn(s *ast.ReturnStatement) {
 func (c *Compiler) compileFuncDef(s *ast.FunctionDefinitionStatement) {
 	// 1. Kiểm tra global scope
 	if len(c.Scopes) > 0 {
-		c.addError("Function definitions are only allowed at the top-level (global scope)", 0, 0, "function definition")
+		c.addError(customError.NestedFunction, c.span, "function definition")
 		return
 	}
 
@@ -335,44 +575,188 @@ func (c *Compiler) compileFuncDef(s *ast.FunctionDefinitionStatement) {
 	c.GlobalSymbols[s.Name.Value] = idx
 	c.emit(bytecode.OP_STORE_GLOBAL, idx)
 
-	// 6. Jump qua thân hàm
+	params := make([]string, len(s.Parameters))
+	for i, param := range s.Parameters {
+		params[i] = param.Value
+	}
+	c.compileFunctionBody(fn, params, s.Body)
+}
+
+// compileFunctionBody emits the body of fn behind a jump, so it only runs when fn is called
+func (c *Compiler) compileFunctionBody(fn *bytecode.Function, params []string, body *ast.BlockStatement) {
+	// 1. Jump qua thân hàm
 	jumpPos := c.emitWithPatch(bytecode.OP_JUMP)
 
-	// 7. Cập nhật StartPC (vị trí bắt đầu thân hàm)
+	// 2. Cập nhật StartPC (vị trí bắt đầu thân hàm)
 	fn.StartPC = len(c.Code)
 
-	// 8. Vào scope hàm
+	// 3. Vào scope hàm (VM tạo scope này khi gọi hàm nên không emit OP_ENTER_SCOPE)
 	c.enterScope()
-	c.emit(bytecode.OP_ENTER_SCOPE, len(s.Parameters))
 
-	// 9. Đăng ký params vào scope
-	for i, param := range s.Parameters {
-		c.CurrentScope[param.Value] = i // Slot = index của param
-		c.LocalInitDepth[param.Value] = 1
+	// 4. Đăng ký params vào scope
+	for i, param := range params {
+		c.CurrentScope[param] = i // Slot = index của param
 	}
 
-	// 10. Compile thân hàm với flag đang trong hàm
+	// 5. Compile thân hàm với flag đang trong hàm
 	prevInFunction := c.IsInsideFunction
 	c.IsInsideFunction = true
-	c.compileBlock(s.Body)
+	c.compileBlock(body)
 	c.IsInsideFunction = prevInFunction
 
-	// 11. Tự động thêm return nếu thân hàm không kết thúc bằng return
-	if !endsWithReturn(s.Body) {
+	// 6. Tự động thêm return nếu thân hàm không kết thúc bằng return
+	if !endsWithReturn(body) {
 		c.emit(bytecode.OP_LOAD_NOTHING)
 		c.emit(bytecode.OP_RETURN)
 	}
 
-	// 12. Cập nhật LocalSize (params + local vars)
+	// 7. Cập nhật LocalSize (params + local vars)
 	fn.LocalSize = len(c.CurrentScope)
 
-	// 13. Thoát scope
+	// 8. Thoát scope (OP_RETURN đã bỏ scope của hàm ở runtime)
 	c.leaveScope()
-	c.emit(bytecode.OP_LEAVE_SCOPE)
 
-	// 14. Sửa jump offset (trừ 1 vì IP sẽ tự tăng sau khi đọc jump)
-	c.patchOperand(jumpPos, len(c.Code)-jumpPos-1)
+	// 9. Sửa jump để nhảy tới ngay sau thân hàm
+	c.patchOperand(jumpPos, len(c.Code))
+}
 
+// Các method đặc biệt mà VM gọi cho toán tử, và số argument của chúng (không tính self)
+var specialMethodArity = map[string]int{
+	"__add__": 1, "__sub__": 1, "__mul__": 1, "__div__": 1, "__mod__": 1, "__pow__": 1,
+	"__radd__": 1, "__rsub__": 1, "__rmul__": 1, "__rdiv__": 1, "__rmod__": 1, "__rpow__": 1,
+	"__eq__": 1, "__lt__": 1, "__index__": 1, "__neg__": 0, "__str__": 0,
+}
+
+// compileRecord creates the record type as a constant and stores it in a global variable
+func (c *Compiler) compileRecord(s *ast.RecordStatement) {
+	if len(c.Scopes) > 0 {
+		c.addError(customError.NestedRecord, s.Span, "record")
+		return
+	}
+	if !c.isValidVariableName(s.Name.Value) {
+		return
+	}
+	if c.isDeclaredType(s.Name.Value) {
+		c.addError(customError.DuplicateType, s.Name.Span, "record", s.Name.Value)
+		return
+	}
+
+	record := &bytecode.RecordType{Name: s.Name.Value, Methods: make(map[string]*bytecode.Function)}
+	for _, field := range s.Fields {
+		for _, existing := range record.Fields {
+			if existing == field.Value {
+				c.addError(customError.DuplicateField, field.Span, "record", field.Value, record.Name)
+				return
+			}
+		}
+		record.Fields = append(record.Fields, field.Value)
+	}
+	c.records[record.Name] = record
+
+	c.emit(bytecode.OP_LOAD_CONST, c.addConstant(record))
+	c.storeGlobal(record.Name)
+}
+
+// storeGlobal stores the value on top of the stack in the global variable name, creating it if needed
+func (c *Compiler) storeGlobal(name string) {
+	idx, exists := c.GlobalSymbols[name]
+	if !exists {
+		idx = len(c.GlobalSymbols)
+		c.GlobalSymbols[name] = idx
+	}
+	c.emit(bytecode.OP_STORE_GLOBAL, idx)
+}
+
+func (c *Compiler) isDeclaredType(name string) bool {
+	_, isRecord := c.records[name]
+	_, isEnum := c.enums[name]
+	return isRecord || isEnum
+}
+
+// compileMethodDef compiles func Record.name(params) { ... } into a function whose first parameter is self
+func (c *Compiler) compileMethodDef(s *ast.MethodDefinitionStatement) {
+	if len(c.Scopes) > 0 {
+		c.addError(customError.NestedMethod, s.Span, "method definition")
+		return
+	}
+
+	record, ok := c.records[s.Receiver.Value]
+	if !ok {
+		c.addError(customError.MethodOnNonRecord, s.Receiver.Span, "method definition", s.Receiver.Value)
+		return
+	}
+
+	name := s.Name.Value
+	if _, exists := record.Methods[name]; exists {
+		c.addError(customError.DuplicateMethod, s.Name.Span, "method definition", record.Name, name)
+		return
+	}
+	for _, field := range record.Fields {
+		if field == name {
+			c.addError(customError.MethodShadowsField, s.Name.Span, "method definition", record.Name, name)
+			return
+		}
+	}
+	if strings.HasPrefix(name, "__") && strings.HasSuffix(name, "__") {
+		arity, known := specialMethodArity[name]
+		if !known {
+			c.addError(customError.UnknownSpecialMethod, s.Name.Span, "method definition", name)
+			return
+		}
+		if len(s.Parameters) != arity {
+			c.addError(customError.SpecialMethodArity, s.Name.Span, "method definition", name, arity, len(s.Parameters))
+			return
+		}
+	}
+
+	params := []string{"self"}
+	for _, param := range s.Parameters {
+		if param.Value == "self" {
+			c.addError(customError.SelfParameter, param.Span, "method definition")
+			return
+		}
+		params = append(params, param.Value)
+	}
+
+	fn := &bytecode.Function{
+		Name:  record.Name + "." + name,
+		Arity: len(params),
+	}
+	record.Methods[name] = fn
+	c.compileFunctionBody(fn, params, s.Body)
+}
+
+// compileDefer evaluates the callee and its arguments now and registers the call
+// to run when the enclosing function returns
+func (c *Compiler) compileDefer(s *ast.DeferStatement) {
+	if !c.IsInsideFunction {
+		c.addError(customError.DeferOutsideFunction, s.Span, "defer")
+		return
+	}
+
+	switch call := s.Call.(type) {
+	case *ast.FunctionCallExpression:
+		for _, arg := range call.Arguments {
+			c.compileExpression(arg)
+		}
+		c.compileExpression(call.Function)
+		c.emit(bytecode.OP_DEFER, len(call.Arguments))
+
+	case *ast.MethodCallExpression:
+		c.compileExpression(call.Caller)
+		for _, arg := range call.Arguments {
+			c.compileExpression(arg)
+		}
+		nameIndex := c.addConstant(call.Method)
+		if nameIndex > 255 || len(call.Arguments) > 255 {
+			c.addError(customError.TooManyMethodArguments, s.Span, call.Method)
+			return
+		}
+		c.emit(bytecode.OP_DEFER_METHOD, nameIndex<<8|len(call.Arguments))
+
+	default:
+		c.addError(customError.CannotDefer, s.Span, "defer", s.Call)
+	}
 }
 
 func endsWithReturn(body *ast.BlockStatement) bool {
@@ -382,3 +766,226 @@ func endsWithReturn(body *ast.BlockStatement) bool {
 	_, ok := body.Statements[len(body.Statements)-1].(*ast.ReturnStatement)
 	return ok
 }
+
+// compileEnum creates the enum type as a constant and stores it in a global variable
+func (c *Compiler) compileEnum(s *ast.EnumStatement) {
+	if len(c.Scopes) > 0 {
+		c.addError(customError.NestedEnum, s.Span, "enum")
+		return
+	}
+	if !c.isValidVariableName(s.Name.Value) {
+		return
+	}
+	if c.isDeclaredType(s.Name.Value) {
+		c.addError(customError.DuplicateType, s.Name.Span, "enum", s.Name.Value)
+		return
+	}
+
+	enum := &bytecode.EnumType{Name: s.Name.Value}
+	for _, v := range s.Variants {
+		if _, exists := enum.Variant(v.Name.Value); exists {
+			c.addError(customError.DuplicateVariant, v.Name.Span, "enum", v.Name.Value, enum.Name)
+			return
+		}
+		variant := &bytecode.EnumVariant{Enum: enum, Name: v.Name.Value}
+		for _, field := range v.Fields {
+			for _, existing := range variant.Fields {
+				if existing == field.Value {
+					c.addError(customError.DuplicateVariantValue, field.Span, "enum", field.Value, enum.Name, variant.Name)
+					return
+				}
+			}
+			variant.Fields = append(variant.Fields, field.Value)
+		}
+		enum.Variants = append(enum.Variants, variant)
+	}
+	c.enums[enum.Name] = enum
+
+	c.emit(bytecode.OP_LOAD_CONST, c.addConstant(enum))
+	c.storeGlobal(enum.Name)
+}
+
+// compileMatch tests the arms in order. The subject is evaluated once into a hidden local,
+// and a match over the variants of an enum without else must cover every variant.
+// As a value (asValue = true) the matching arm leaves its value on the stack.
+func (c *Compiler) compileMatch(s *ast.MatchStatement, asValue bool) {
+	c.enterScope()
+	enterScopePos := c.emitWithPatch(bytecode.OP_ENTER_SCOPE)
+
+	// Tên có dấu # nên không trùng với biến của người dùng
+	subjectSlot := len(c.CurrentScope)
+	c.CurrentScope["#subject"] = subjectSlot
+	c.compileExpression(s.Subject)
+	c.emit(bytecode.OP_STORE_LOCAL, 1<<8|subjectSlot)
+
+	var enum *bytecode.EnumType // Enum của các pattern (nil nếu có pattern không phải variant)
+	onlyVariants := true
+	covered := make(map[*bytecode.EnumVariant]bool)
+	var endJumps []int
+
+	for _, arm := range s.Arms {
+		var bodyJumps []int
+		var failJump int
+		var bindings []*ast.Identifier
+		var bound *bytecode.EnumVariant
+
+		for i, pattern := range arm.Patterns {
+			variant, names, isVariant := c.variantPattern(pattern)
+			c.loadLocal("#subject")
+			if isVariant {
+				if variant == nil {
+					c.leaveScope() // Đã báo lỗi
+					return
+				}
+				if enum != nil && variant.Enum != enum {
+					c.addError(customError.MixedMatchEnums, arm.Span, "match", enum.Name, variant.Enum.Name)
+					c.leaveScope()
+					return
+				}
+				enum = variant.Enum
+				if covered[variant] {
+					c.addError(customError.UnreachableArm, arm.Span, "match", enum.Name, variant.Name)
+					c.leaveScope()
+					return
+				}
+				covered[variant] = true
+				c.emit(bytecode.OP_IS_VARIANT, c.addConstant(variant))
+			} else {
+				onlyVariants = false
+				c.compileExpression(pattern)
+				c.emit(bytecode.OP_EQ)
+			}
+
+			if len(names) > 0 {
+				if len(arm.Patterns) > 1 {
+					c.addError(customError.BindingInMultiPattern, arm.Span, "match")
+					c.leaveScope()
+					return
+				}
+				bindings, bound = names, variant
+			}
+
+			// Khớp một pattern là đủ: nhảy vào thân, không thì thử pattern tiếp theo
+			failJump = c.emitWithPatch(bytecode.OP_JUMP_IF_FALSE)
+			if i < len(arm.Patterns)-1 {
+				bodyJumps = append(bodyJumps, c.emitWithPatch(bytecode.OP_JUMP))
+				c.patchOperand(failJump, len(c.Code))
+			}
+		}
+		for _, pos := range bodyJumps {
+			c.patchOperand(pos, len(c.Code))
+		}
+
+		// Thân arm có scope riêng chứa các giá trị được bind
+		c.enterScope()
+		armScopePos := c.emitWithPatch(bytecode.OP_ENTER_SCOPE)
+		for i, name := range bindings {
+			if name.Value == "_" {
+				continue
+			}
+			c.loadLocal("#subject")
+			c.emit(bytecode.OP_GET_PROPERTY, c.addConstant(bound.Fields[i]))
+			slot := len(c.CurrentScope)
+			c.CurrentScope[name.Value] = slot
+			c.emit(bytecode.OP_STORE_LOCAL, 1<<8|slot)
+		}
+		if asValue {
+			c.compileBlockValue(arm.Body)
+		} else {
+			c.compileBlock(arm.Body)
+		}
+		c.patchOperand(armScopePos, len(c.CurrentScope))
+		c.leaveScope()
+		c.emit(bytecode.OP_LEAVE_SCOPE)
+
+		endJumps = append(endJumps, c.emitWithPatch(bytecode.OP_JUMP))
+		c.patchOperand(failJump, len(c.Code))
+	}
+
+	if s.ElseBlock != nil {
+		if asValue {
+			c.compileValueBlock(s.ElseBlock.Body)
+		} else {
+			c.compileIfBlock(s.ElseBlock.Body)
+		}
+	} else if asValue {
+		c.emit(bytecode.OP_LOAD_NOTHING) // Không arm nào khớp
+	}
+	if s.ElseBlock == nil && enum != nil && onlyVariants {
+		var missing []string
+		for _, variant := range enum.Variants {
+			if !covered[variant] {
+				missing = append(missing, enum.Name+"."+variant.Name)
+			}
+		}
+		if len(missing) > 0 {
+			c.addError(customError.NonExhaustiveMatch, s.Span, "match", enum.Name, strings.Join(missing, ", "))
+		}
+	}
+
+	for _, pos := range endJumps {
+		c.patchOperand(pos, len(c.Code))
+	}
+
+	c.patchOperand(enterScopePos, len(c.CurrentScope))
+	c.leaveScope()
+	c.emit(bytecode.OP_LEAVE_SCOPE)
+}
+
+// variantPattern recognizes the patterns Enum.Variant and Enum.Variant(name, ...).
+// isVariant is false for other patterns; variant is nil if the pattern is invalid (the error is reported).
+func (c *Compiler) variantPattern(pattern ast.Expression) (variant *bytecode.EnumVariant, bindings []*ast.Identifier, isVariant bool) {
+	var enumName, variantName string
+	var args []ast.Expression
+	hasArgs := false
+
+	switch p := pattern.(type) {
+	case *ast.PropertyExpression:
+		ident, ok := p.Object.(*ast.Identifier)
+		if !ok || p.Optional {
+			return nil, nil, false
+		}
+		enumName, variantName = ident.Value, p.Property
+	case *ast.MethodCallExpression:
+		ident, ok := p.Caller.(*ast.Identifier)
+		if !ok || p.Optional {
+			return nil, nil, false
+		}
+		enumName, variantName, args, hasArgs = ident.Value, p.Method, p.Arguments, true
+	default:
+		return nil, nil, false
+	}
+
+	enum, ok := c.enums[enumName]
+	if !ok {
+		return nil, nil, false
+	}
+	variant, ok = enum.Variant(variantName)
+	if !ok {
+		c.addError(customError.UnknownVariant, pattern.SourceSpan(), "match", enumName, variantName)
+		return nil, nil, true
+	}
+	if !hasArgs {
+		return variant, nil, true // Enum.Variant khớp variant bất kể giá trị đi kèm
+	}
+
+	if len(args) != len(variant.Fields) {
+		c.addError(customError.PatternArity, pattern.SourceSpan(), "match", enumName, variantName, len(variant.Fields), len(args))
+		return nil, nil, true
+	}
+	for _, arg := range args {
+		name, ok := arg.(*ast.Identifier)
+		if !ok {
+			c.addError(customError.PatternBindsNonName, pattern.SourceSpan(), "match", enumName, variantName)
+			return nil, nil, true
+		}
+		bindings = append(bindings, name)
+	}
+	return variant, bindings, true
+}
+
+// loadLocal loads a variable that is known to be local
+func (c *Compiler) loadLocal(name string) {
+	slot, depth, _, _ := c.resolveVariable(name)
+	c.emit(bytecode.OP_LOAD_LOCAL, depth<<8|slot)
+}
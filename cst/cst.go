@@ -0,0 +1,151 @@
+// Package cst builds a concrete syntax tree: the AST of a program together with every token,
+// comment and piece of whitespace of its source, so that the tree prints back byte for byte.
+// It is meant for tools that rewrite source code (formatter, refactoring) without losing comments.
+package cst
+
+import (
+	"io"
+	"pun/ast"
+	"pun/error"
+	"pun/lexer"
+	"pun/parser"
+	"sort"
+	"strings"
+)
+
+// Element is a child of a node: a *Node or a *Token
+type Element interface {
+	writeTo(b *strings.Builder)
+}
+
+// Node is an AST node with its tokens and child nodes in source order
+type Node struct {
+	AST      ast.Node
+	Children []Element
+}
+
+// Token is a leaf of the tree. Raw holds its exact text and the trivia around it.
+type Token struct {
+	lexer.Token
+}
+
+// Parse reads a program in lossless mode and returns its concrete syntax tree with the
+// syntax errors found. The tree is complete even when there are errors: the broken parts
+// become BadStatement and BadExpression nodes that keep their tokens.
+func Parse(file string, r io.Reader) (*Node, []customError.Diagnostic) {
+	l := lexer.NewReaderLexer(file, r)
+	l.Lossless = true
+	p := parser.NewParser(l)
+	program := p.ParseProgram()
+	return Build(program, p.Tokens()), p.Diagnostics()
+}
+
+// Build groups the tokens of a lossless parse (Parser.Tokens) under the nodes of program.
+// A token belongs to the innermost node whose span covers it; tokens outside every child
+// (dấu ngoặc quanh biểu thức, dấu phẩy giữa các phần tử, ...) belong to the parent.
+// The root keeps the EOF token, which holds the trivia at the end of the file.
+func Build(program *ast.Program, tokens []lexer.Token) *Node {
+	b := &builder{tokens: tokens}
+	return b.node(program, len(tokens))
+}
+
+type builder struct {
+	tokens []lexer.Token
+	next   int // Token đầu tiên chưa thuộc node nào
+}
+
+// node builds the tree of n from the tokens b.tokens[b.next:end], which all lie inside n
+func (b *builder) node(n ast.Node, end int) *Node {
+	result := &Node{AST: n}
+
+	children := ast.Children(n)
+	sort.SliceStable(children, func(i, j int) bool {
+		return children[i].SourceSpan().Start.Offset < children[j].SourceSpan().Start.Offset
+	})
+
+	for _, child := range children {
+		span := child.SourceSpan()
+		if span.IsZero() {
+			continue
+		}
+		for b.next < end && b.tokens[b.next].Offset < span.Start.Offset {
+			result.addToken(b.take())
+		}
+		childEnd := b.next
+		for childEnd < end && b.tokens[childEnd].Kind != lexer.KIND_EOF && b.tokens[childEnd].End.Offset <= span.End.Offset {
+			childEnd++
+		}
+		if childEnd > b.next {
+			result.Children = append(result.Children, b.node(child, childEnd))
+		}
+	}
+
+	for b.next < end {
+		result.addToken(b.take())
+	}
+	return result
+}
+
+func (n *Node) addToken(tok lexer.Token) {
+	n.Children = append(n.Children, &Token{tok})
+}
+
+func (b *builder) take() lexer.Token {
+	tok := b.tokens[b.next]
+	b.next++
+	return tok
+}
+
+// Tokens returns the tokens of the node in source order
+func (n *Node) Tokens() []*Token {
+	var tokens []*Token
+	for _, child := range n.Children {
+		switch c := child.(type) {
+		case *Token:
+			tokens = append(tokens, c)
+		case *Node:
+			tokens = append(tokens, c.Tokens()...)
+		}
+	}
+	return tokens
+}
+
+// String returns the source of the node exactly as it was read, trivia included
+func (n *Node) String() string {
+	var b strings.Builder
+	n.writeTo(&b)
+	return b.String()
+}
+
+// WriteTo writes the source of the node to w
+func (n *Node) WriteTo(w io.Writer) (int64, error) {
+	written, err := io.WriteString(w, n.String())
+	return int64(written), err
+}
+
+func (n *Node) writeTo(b *strings.Builder) {
+	for _, child := range n.Children {
+		child.writeTo(b)
+	}
+}
+
+// String returns the token with the trivia around it
+func (t *Token) String() string {
+	var b strings.Builder
+	t.writeTo(&b)
+	return b.String()
+}
+
+func (t *Token) writeTo(b *strings.Builder) {
+	if t.Raw == nil {
+		b.WriteString(t.Value) // Token không đọc ở chế độ lossless
+		return
+	}
+	for _, trivia := range t.Raw.Leading {
+		b.WriteString(trivia.Text)
+	}
+	b.WriteString(t.Raw.Text)
+	for _, trivia := range t.Raw.Trailing {
+		b.WriteString(trivia.Text)
+	}
+}
@@ -0,0 +1,126 @@
+package cst
+
+import (
+	"pun/lexer"
+	"strings"
+	"testing"
+)
+
+// programs covers every kind of statement, with comments and whitespace in unusual places
+var programs = []struct {
+	name   string
+	source string
+	errors int // Số lỗi cú pháp: chương trình lỗi vẫn in lại được nguyên văn
+}{
+	{"empty", "", 0},
+	{"only comments", "// một\n\n// hai\n", 0},
+	{"no final newline", "x = 1", 0},
+	{"blank lines and tabs", "\n\nx   =\t1\n\n\n\ty = x  +  2   // cộng\n", 0},
+	{"windows line endings", "x = 1\r\nif x > 0 {\r\n    print(x)\r\n}\r\n", 0},
+	{"byte order mark", "\uFEFFprint(1)\n", 0},
+	{"comments inside expressions", "x = (1 + // một\n    2) * [3, /* ba */ 4]\n", 0},
+	{"functions", `// tính diện tích
+func area(w, h) {
+    defer print("done") // dọn dẹp
+    return w * h
+}
+print(area(2, 3))
+`, 0},
+	{"loops", `outer: for i = 0; i < 3; i = i + 1 {
+    for x in #{1, 2} {
+        if x == 2 { continue outer }
+    }
+    while false {} else { break }
+} else {
+    until true { }
+}
+`, 0},
+	{"records and enums", `record Point(x, y)
+func Point.__add__(other) {
+    return Point(self.x + other.x, self.y + other.y)
+}
+enum Shape {
+    Circle(r),   // hình tròn
+    Square(side)
+}
+s = Shape.Circle(2)
+match s {
+    Shape.Circle(r) { print(r) }
+    else { print("?") }
+}
+`, 0},
+	{"values", `a = #[1, 2]
+t = (1, "a", nothing)
+b = 2n ** 64 + 1.5d
+c = a?.size ?? 0
+x = if c > 0 { "some" } elif c < 0 { "neg" } else { "none" }
+print("${x:>8} and ${c ? 1 : 2} ${"nested ${b}"}")
+`, 0},
+	{"syntax errors", "x = 1 + * 2\nprint(1 2)\nfunc f( {\n", 3},
+	{"invalid UTF-8", "x = 1 \xff + 2\n\xfe\n", 2},
+	{"unterminated string", "x = \"abc\nprint(x)\n", 1},
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, tt := range programs {
+		tree, diagnostics := Parse("test.pun", strings.NewReader(tt.source))
+		if len(diagnostics) != tt.errors {
+			t.Errorf("%s: got %d syntax errors, want %d: %v", tt.name, len(diagnostics), tt.errors, diagnostics)
+		}
+		if got := tree.String(); got != tt.source {
+			t.Errorf("%s: printed back as\n%q\nwant\n%q", tt.name, got, tt.source)
+		}
+		var out strings.Builder
+		if n, err := tree.WriteTo(&out); err != nil || out.String() != tt.source || int(n) != len(tt.source) {
+			t.Errorf("%s: WriteTo wrote %d bytes %q, error %v", tt.name, n, out.String(), err)
+		}
+	}
+}
+
+func TestTokensInsideNodes(t *testing.T) {
+	for _, tt := range programs {
+		tree, _ := Parse("test.pun", strings.NewReader(tt.source))
+
+		tokens := tree.Tokens()
+		if len(tokens) == 0 || tokens[len(tokens)-1].Kind != lexer.KIND_EOF {
+			t.Errorf("%s: the tree does not end with the EOF token", tt.name)
+		}
+		for i := 1; i < len(tokens); i++ {
+			if tokens[i].Offset < tokens[i-1].Offset {
+				t.Errorf("%s: token %q comes before %q", tt.name, tokens[i].Value, tokens[i-1].Value)
+			}
+		}
+
+		// Token của một node nằm trong span của node đó
+		var check func(n *Node)
+		check = func(n *Node) {
+			span := n.AST.SourceSpan()
+			for _, tok := range n.Tokens() {
+				if n != tree && (tok.Offset < span.Start.Offset || tok.End.Offset > span.End.Offset) {
+					t.Errorf("%s: token %q at %d:%d is outside its %T", tt.name, tok.Value, tok.Line, tok.Col, n.AST)
+				}
+			}
+			for _, child := range n.Children {
+				if child, ok := child.(*Node); ok {
+					check(child)
+				}
+			}
+		}
+		check(tree)
+	}
+}
+
+func TestComments(t *testing.T) {
+	tree, _ := Parse("test.pun", strings.NewReader("x = 1 // một\n// hai\ny = 2\n"))
+	var comments []string
+	for _, tok := range tree.Tokens() {
+		for _, trivia := range append(append([]lexer.Trivia{}, tok.Raw.Leading...), tok.Raw.Trailing...) {
+			if trivia.Kind == lexer.TRIVIA_LINE_COMMENT {
+				comments = append(comments, trivia.Text)
+			}
+		}
+	}
+	if strings.Join(comments, "|") != "// một|// hai" {
+		t.Errorf("got comments %q", comments)
+	}
+}
@@ -0,0 +1,318 @@
+// Package decimal implements the exact base-10 numbers of Pun (12.30d).
+// A decimal is an unscaled integer and a scale: 12.30d = 1230 × 10^-2,
+// so the number of digits after the point is kept through arithmetic and printing.
+package decimal
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// DivisionScale is the minimum number of digits after the point of a division result
+const DivisionScale = 16
+
+// Giới hạn số mũ của ** và của 1e5 để tránh tạo số khổng lồ
+const maxExponent = 1 << 16
+
+var (
+	ErrDivisionByZero   = errors.New("division by zero")
+	ErrExponentTooLarge = errors.New("exponent too large")
+	ten                 = big.NewInt(10)
+)
+
+// Decimal is an immutable exact decimal number
+type Decimal struct {
+	value *big.Int // Giá trị không có dấu chấm (1230 cho 12.30)
+	scale int      // Số chữ số sau dấu chấm (2 cho 12.30)
+}
+
+// RoundingMode decides how digits that do not fit the scale are dropped
+type RoundingMode int
+
+const (
+	HalfEven RoundingMode = iota // 2.5 -> 2, 3.5 -> 4 (mặc định, làm tròn ngân hàng)
+	HalfUp                       // 2.5 -> 3, -2.5 -> -3
+	HalfDown                     // 2.5 -> 2, -2.5 -> -2
+	Up                           // Ra xa số 0
+	Down                         // Về phía số 0 (cắt bỏ)
+	Ceiling                      // Về phía +vô cùng
+	Floor                        // Về phía -vô cùng
+)
+
+var roundingNames = map[RoundingMode]string{
+	HalfEven: "half_even",
+	HalfUp:   "half_up",
+	HalfDown: "half_down",
+	Up:       "up",
+	Down:     "down",
+	Ceiling:  "ceiling",
+	Floor:    "floor",
+}
+
+func (m RoundingMode) String() string {
+	return roundingNames[m]
+}
+
+// ParseRoundingMode returns the mode named "half_even", "half_up", "half_down", "up", "down", "ceiling" or "floor"
+func ParseRoundingMode(name string) (RoundingMode, bool) {
+	for mode, modeName := range roundingNames {
+		if modeName == name {
+			return mode, true
+		}
+	}
+	return HalfEven, false
+}
+
+// New returns unscaled × 10^-scale
+func New(unscaled *big.Int, scale int) *Decimal {
+	if scale < 0 {
+		return &Decimal{value: new(big.Int).Mul(unscaled, pow10(-scale)), scale: 0}
+	}
+	return &Decimal{value: new(big.Int).Set(unscaled), scale: scale}
+}
+
+// FromInt converts an integer to a decimal with scale 0
+func FromInt(n *big.Int) *Decimal {
+	return New(n, 0)
+}
+
+// Parse reads a decimal such as "12.30", "-0.5", "1_000.25" or "1.5e3".
+// An exponent beyond ±65536 returns ErrExponentTooLarge.
+func Parse(text string) (*Decimal, error) {
+	s := strings.ReplaceAll(strings.TrimSpace(text), "_", "")
+
+	exponent := 0
+	if idx := strings.IndexAny(s, "eE"); idx >= 0 {
+		exp, err := strconv.Atoi(s[idx+1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid decimal %q", text)
+		}
+		if exp > maxExponent || exp < -maxExponent {
+			return nil, ErrExponentTooLarge
+		}
+		exponent = exp
+		s = s[:idx]
+	}
+
+	scale := 0
+	if idx := strings.IndexByte(s, '.'); idx >= 0 {
+		scale = len(s) - idx - 1
+		s = s[:idx] + s[idx+1:]
+	}
+
+	value, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid decimal %q", text)
+	}
+	return New(value, scale-exponent), nil
+}
+
+// FromFloat converts a float using its shortest representation, so 0.1 becomes 0.1d
+func FromFloat(f float64) (*Decimal, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, fmt.Errorf("cannot convert %v to decimal", f)
+	}
+	return Parse(strconv.FormatFloat(f, 'f', -1, 64))
+}
+
+// Float64 returns the nearest float64
+func (d *Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// Scale returns the number of digits after the point
+func (d *Decimal) Scale() int {
+	return d.scale
+}
+
+// Sign returns -1, 0 or +1
+func (d *Decimal) Sign() int {
+	return d.value.Sign()
+}
+
+// IsInteger reports whether the value has no fractional part
+func (d *Decimal) IsInteger() bool {
+	if d.scale == 0 {
+		return true
+	}
+	return new(big.Int).Rem(d.value, pow10(d.scale)).Sign() == 0
+}
+
+// Int returns the integer part (truncated toward zero)
+func (d *Decimal) Int() *big.Int {
+	return new(big.Int).Quo(d.value, pow10(d.scale))
+}
+
+// String prints every digit of the scale: 12.30d prints "12.30"
+func (d *Decimal) String() string {
+	digits := new(big.Int).Abs(d.value).String()
+	if d.scale > 0 {
+		if len(digits) <= d.scale {
+			digits = strings.Repeat("0", d.scale-len(digits)+1) + digits
+		}
+		digits = digits[:len(digits)-d.scale] + "." + digits[len(digits)-d.scale:]
+	}
+	if d.value.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+// Reduce returns d without trailing zeros after the point (1.50 -> 1.5), the canonical form of its value
+func (d *Decimal) Reduce() *Decimal {
+	return d.trim(0)
+}
+
+// Neg returns -d
+func (d *Decimal) Neg() *Decimal {
+	return &Decimal{value: new(big.Int).Neg(d.value), scale: d.scale}
+}
+
+// Add returns a + b with the larger of the two scales
+func Add(a, b *Decimal) *Decimal {
+	scale := max(a.scale, b.scale)
+	return &Decimal{value: new(big.Int).Add(a.rescale(scale), b.rescale(scale)), scale: scale}
+}
+
+// Sub returns a - b with the larger of the two scales
+func Sub(a, b *Decimal) *Decimal {
+	scale := max(a.scale, b.scale)
+	return &Decimal{value: new(big.Int).Sub(a.rescale(scale), b.rescale(scale)), scale: scale}
+}
+
+// Mul returns a × b exactly (the scales add up)
+func Mul(a, b *Decimal) *Decimal {
+	return &Decimal{value: new(big.Int).Mul(a.value, b.value), scale: a.scale + b.scale}
+}
+
+// Quo returns a / b rounded to at least DivisionScale digits, then drops trailing zeros
+// that are not needed to keep the larger scale of a and b (10.00d / 4 = 2.50)
+func Quo(a, b *Decimal, mode RoundingMode) (*Decimal, error) {
+	if b.value.Sign() == 0 {
+		return nil, ErrDivisionByZero
+	}
+	keep := max(a.scale, b.scale)
+	scale := max(keep, DivisionScale)
+
+	// q = a.value × 10^(scale - a.scale + b.scale) / b.value
+	num := new(big.Int).Set(a.value)
+	den := new(big.Int).Set(b.value)
+	if shift := scale - a.scale + b.scale; shift >= 0 {
+		num.Mul(num, pow10(shift))
+	} else {
+		den.Mul(den, pow10(-shift))
+	}
+
+	result := &Decimal{value: divRound(num, den, mode), scale: scale}
+	return result.trim(keep), nil
+}
+
+// Rem returns the remainder of a / b truncated toward zero (same sign as a)
+func Rem(a, b *Decimal) (*Decimal, error) {
+	if b.value.Sign() == 0 {
+		return nil, ErrDivisionByZero
+	}
+	scale := max(a.scale, b.scale)
+	return &Decimal{value: new(big.Int).Rem(a.rescale(scale), b.rescale(scale)), scale: scale}, nil
+}
+
+// Pow returns d^n for an integer n; negative powers are divisions
+func Pow(d *Decimal, n int64, mode RoundingMode) (*Decimal, error) {
+	if n > maxExponent || n < -maxExponent {
+		return nil, ErrExponentTooLarge
+	}
+	abs := n
+	if abs < 0 {
+		abs = -abs
+	}
+	result := &Decimal{
+		value: new(big.Int).Exp(d.value, big.NewInt(abs), nil),
+		scale: d.scale * int(abs),
+	}
+	if n < 0 {
+		return Quo(FromInt(big.NewInt(1)), result, mode)
+	}
+	return result, nil
+}
+
+// Cmp compares a and b, returning -1, 0 or +1
+func Cmp(a, b *Decimal) int {
+	scale := max(a.scale, b.scale)
+	return a.rescale(scale).Cmp(b.rescale(scale))
+}
+
+// Round returns d with exactly `places` digits after the point
+func (d *Decimal) Round(places int, mode RoundingMode) *Decimal {
+	if places >= d.scale {
+		return &Decimal{value: d.rescale(places), scale: places}
+	}
+	return &Decimal{value: divRound(d.value, pow10(d.scale-places), mode), scale: places}
+}
+
+// rescale returns the unscaled value for a larger scale
+func (d *Decimal) rescale(scale int) *big.Int {
+	if scale == d.scale {
+		return d.value
+	}
+	return new(big.Int).Mul(d.value, pow10(scale-d.scale))
+}
+
+// trim drops trailing zeros after the point while the scale stays above `keep`
+func (d *Decimal) trim(keep int) *Decimal {
+	value, scale := new(big.Int).Set(d.value), d.scale
+	remainder := new(big.Int)
+	for scale > keep {
+		quotient, _ := new(big.Int).QuoRem(value, ten, remainder)
+		if remainder.Sign() != 0 {
+			break
+		}
+		value, scale = quotient, scale-1
+	}
+	return &Decimal{value: value, scale: scale}
+}
+
+// divRound divides num by den and rounds the result with the given mode
+func divRound(num, den *big.Int, mode RoundingMode) *big.Int {
+	quotient, remainder := new(big.Int).QuoRem(num, den, new(big.Int))
+	if remainder.Sign() == 0 {
+		return quotient
+	}
+
+	// Dấu của kết quả thật và so sánh phần dư với một nửa số chia
+	sign := int64(num.Sign() * den.Sign())
+	half := new(big.Int).Abs(remainder)
+	half.Mul(half, big.NewInt(2))
+	cmp := half.Cmp(new(big.Int).Abs(den))
+
+	roundAway := false
+	switch mode {
+	case Up:
+		roundAway = true
+	case Down:
+		roundAway = false
+	case Ceiling:
+		roundAway = sign > 0
+	case Floor:
+		roundAway = sign < 0
+	case HalfUp:
+		roundAway = cmp >= 0
+	case HalfDown:
+		roundAway = cmp > 0
+	default: // HalfEven
+		roundAway = cmp > 0 || (cmp == 0 && quotient.Bit(0) == 1)
+	}
+
+	if roundAway {
+		quotient.Add(quotient, big.NewInt(sign))
+	}
+	return quotient
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(ten, big.NewInt(int64(n)), nil)
+}
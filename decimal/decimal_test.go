@@ -0,0 +1,206 @@
+package decimal
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func mustParse(t *testing.T, text string) *Decimal {
+	t.Helper()
+	d, err := Parse(text)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", text, err)
+	}
+	return d
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"12.30", "12.30"},
+		{"-0.5", "-0.5"},
+		{"1_000.25", "1000.25"},
+		{"1.5e3", "1500"},
+		{"1.5E3", "1500"},
+		{"25e-1", "2.5"},
+		{"1e-3", "0.001"},
+		{"0.000", "0.000"},
+		{"1e65536", "1" + strings.Repeat("0", 65536)},
+	}
+	for _, tt := range tests {
+		if got := mustParse(t, tt.input).String(); got != tt.expected {
+			t.Errorf("Parse(%q) = %s, want %s", tt.input, shorten(got), shorten(tt.expected))
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		err   error // nil: chỉ cần có lỗi
+	}{
+		{"", nil},
+		{"abc", nil},
+		{"1.2.3", nil},
+		{"1e", nil},
+		{"1e99999999999999999999", nil},
+		{"1e65537", ErrExponentTooLarge},
+		{"1e-65537", ErrExponentTooLarge},
+		{"1e999999999", ErrExponentTooLarge},
+		{"1e-999999999", ErrExponentTooLarge},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.input)
+		if err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", tt.input)
+			continue
+		}
+		if tt.err != nil && !errors.Is(err, tt.err) {
+			t.Errorf("Parse(%q) error = %v, want %v", tt.input, err, tt.err)
+		}
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	tests := []struct {
+		a, op, b string
+		expected string
+	}{
+		{"12.30", "+", "0.7", "13.00"},
+		{"0.1", "+", "0.2", "0.3"},
+		{"1", "-", "0.01", "0.99"},
+		{"-1.5", "-", "-1.5", "0.0"},
+		{"1.5", "*", "2.25", "3.375"},
+		{"-0.10", "*", "3", "-0.30"},
+		{"10.00", "/", "4", "2.50"},
+		{"1", "/", "3", "0.3333333333333333"},
+		{"2", "/", "3", "0.6666666666666667"},
+		{"1", "/", "8", "0.125"},
+		{"7.5", "%", "2", "1.5"},
+		{"-7.5", "%", "2", "-1.5"},
+	}
+	for _, tt := range tests {
+		a, b := mustParse(t, tt.a), mustParse(t, tt.b)
+		var got *Decimal
+		var err error
+		switch tt.op {
+		case "+":
+			got = Add(a, b)
+		case "-":
+			got = Sub(a, b)
+		case "*":
+			got = Mul(a, b)
+		case "/":
+			got, err = Quo(a, b, HalfEven)
+		case "%":
+			got, err = Rem(a, b)
+		}
+		if err != nil {
+			t.Errorf("%s %s %s: %v", tt.a, tt.op, tt.b, err)
+			continue
+		}
+		if got.String() != tt.expected {
+			t.Errorf("%s %s %s = %s, want %s", tt.a, tt.op, tt.b, got, tt.expected)
+		}
+	}
+}
+
+func TestDivisionByZero(t *testing.T) {
+	zero := mustParse(t, "0.00")
+	if _, err := Quo(mustParse(t, "1"), zero, HalfEven); !errors.Is(err, ErrDivisionByZero) {
+		t.Errorf("Quo by zero error = %v", err)
+	}
+	if _, err := Rem(mustParse(t, "1"), zero); !errors.Is(err, ErrDivisionByZero) {
+		t.Errorf("Rem by zero error = %v", err)
+	}
+}
+
+func TestRound(t *testing.T) {
+	modes := []RoundingMode{HalfEven, HalfUp, HalfDown, Up, Down, Ceiling, Floor}
+	tests := []struct {
+		input    string
+		expected [7]string // Theo thứ tự của modes
+	}{
+		{"2.5", [7]string{"2", "3", "2", "3", "2", "3", "2"}},
+		{"3.5", [7]string{"4", "4", "3", "4", "3", "4", "3"}},
+		{"-2.5", [7]string{"-2", "-3", "-2", "-3", "-2", "-2", "-3"}},
+		{"2.51", [7]string{"3", "3", "3", "3", "2", "3", "2"}},
+		{"-2.49", [7]string{"-2", "-2", "-2", "-3", "-2", "-2", "-3"}},
+		{"7", [7]string{"7", "7", "7", "7", "7", "7", "7"}},
+	}
+	for _, tt := range tests {
+		d := mustParse(t, tt.input)
+		for i, mode := range modes {
+			if got := d.Round(0, mode).String(); got != tt.expected[i] {
+				t.Errorf("round(%s, 0, %s) = %s, want %s", tt.input, mode, got, tt.expected[i])
+			}
+		}
+	}
+
+	if got := mustParse(t, "1.005").Round(2, HalfUp).String(); got != "1.01" {
+		t.Errorf("round(1.005, 2, half_up) = %s, want 1.01", got)
+	}
+	if got := mustParse(t, "1.5").Round(3, HalfEven).String(); got != "1.500" {
+		t.Errorf("round(1.5, 3) = %s, want 1.500", got)
+	}
+}
+
+func TestPow(t *testing.T) {
+	tests := []struct {
+		base     string
+		exponent int64
+		expected string
+	}{
+		{"1.1", 2, "1.21"},
+		{"2", 10, "1024"},
+		{"0.5", 0, "1"},
+		{"2", -2, "0.25"},
+		{"3", -1, "0.3333333333333333"},
+	}
+	for _, tt := range tests {
+		got, err := Pow(mustParse(t, tt.base), tt.exponent, HalfEven)
+		if err != nil {
+			t.Errorf("%s ** %d: %v", tt.base, tt.exponent, err)
+			continue
+		}
+		if got.String() != tt.expected {
+			t.Errorf("%s ** %d = %s, want %s", tt.base, tt.exponent, got, tt.expected)
+		}
+	}
+	if _, err := Pow(mustParse(t, "2"), maxExponent+1, HalfEven); !errors.Is(err, ErrExponentTooLarge) {
+		t.Errorf("2 ** %d error = %v, want ErrExponentTooLarge", maxExponent+1, err)
+	}
+}
+
+func TestCmpAndReduce(t *testing.T) {
+	if Cmp(mustParse(t, "1.50"), mustParse(t, "1.5")) != 0 {
+		t.Error("1.50 and 1.5 should compare equal")
+	}
+	if Cmp(mustParse(t, "-0.1"), mustParse(t, "0.01")) != -1 {
+		t.Error("-0.1 should be less than 0.01")
+	}
+	if got := mustParse(t, "1.500").Reduce().String(); got != "1.5" {
+		t.Errorf("Reduce(1.500) = %s, want 1.5", got)
+	}
+	if got := mustParse(t, "100").Reduce().String(); got != "100" {
+		t.Errorf("Reduce(100) = %s, want 100", got)
+	}
+}
+
+func TestFromFloat(t *testing.T) {
+	d, err := FromFloat(0.1)
+	if err != nil || d.String() != "0.1" {
+		t.Errorf("FromFloat(0.1) = %v, %v", d, err)
+	}
+}
+
+// shorten keeps error messages readable for very long numbers
+func shorten(s string) string {
+	if len(s) > 40 {
+		return s[:20] + "..." + s[len(s)-20:]
+	}
+	return s
+}
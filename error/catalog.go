@@ -0,0 +1,138 @@
+package customError
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Code identifies an error independently of the language it is shown in: P00xx lexer,
+// P01xx parser, P02xx compiler, P03xx runtime. A code never changes meaning once released,
+// so tools can filter on it and `pun explain` can describe it.
+type Code string
+
+// Các ngôn ngữ có bản dịch thông báo lỗi
+const (
+	English    = "en"
+	Vietnamese = "vi"
+)
+
+// language là ngôn ngữ đang dùng cho thông báo lỗi
+var language = DetectLanguage()
+
+// Languages lists the supported languages
+func Languages() []string {
+	return []string{English, Vietnamese}
+}
+
+// DetectLanguage picks the language of the messages from PUN_LANG, then from the usual
+// locale variables (LC_ALL, LC_MESSAGES, LANG); anything not translated falls back to English
+func DetectLanguage() string {
+	for _, name := range []string{"PUN_LANG", "LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			return normalizeLanguage(value)
+		}
+	}
+	return English
+}
+
+// normalizeLanguage maps a locale such as vi_VN.UTF-8 to its language
+func normalizeLanguage(locale string) string {
+	lang := strings.ToLower(locale)
+	if i := strings.IndexAny(lang, "_-.@"); i >= 0 {
+		lang = lang[:i]
+	}
+	if _, ok := messages[lang]; ok {
+		return lang
+	}
+	return English
+}
+
+// SetLanguage changes the language of the messages created from now on; it reports false
+// (and keeps the current language) if lang is not supported
+func SetLanguage(lang string) bool {
+	if _, ok := messages[lang]; !ok {
+		return false
+	}
+	language = lang
+	return true
+}
+
+// Language returns the language of the messages
+func Language() string {
+	return language
+}
+
+// Message formats the message of code in the current language
+func Message(code Code, args ...interface{}) string {
+	return fmt.Sprintf(template(code), args...)
+}
+
+func template(code Code) string {
+	if t, ok := messages[language][code]; ok {
+		return t
+	}
+	return messages[English][code] // Chưa dịch: dùng bản tiếng Anh
+}
+
+// Text translates a fixed English phrase of the diagnostics (labels, hints, headings) and
+// formats it with args; phrases without a translation are used as they are
+func Text(english string, args ...interface{}) string {
+	text := english
+	if translated, ok := phrases[language][english]; ok {
+		text = translated
+	}
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+// Codes returns every code of the catalog in order
+func Codes() []Code {
+	codes := make([]Code, 0, len(messages[English]))
+	for code := range messages[English] {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i] < codes[j] })
+	return codes
+}
+
+// Summary returns the message of code in the current language with "…" for its arguments
+func Summary(code Code) string {
+	return verb.ReplaceAllString(template(code), "…")
+}
+
+// Explain returns the long explanation of code in the current language,
+// and false if there is no such code
+func Explain(code Code) (string, bool) {
+	if _, ok := messages[English][code]; !ok {
+		return "", false
+	}
+	text, ok := explanations[language][code]
+	if !ok {
+		text = explanations[English][code]
+	}
+	return fmt.Sprintf("%s: %s\n\n%s\n", code, Summary(code), strings.TrimRight(text, "\n")), true
+}
+
+// verb matches a formatting verb of a message template (%s, %d, %[1]s...)
+var verb = regexp.MustCompile(`%(\[\d+\])?[-+# 0]*\d*(\.\d+)?[a-zA-Z]`)
+
+// CodedError is an error of a helper that the caller reports with its code,
+// so that the message is translated like every other diagnostic
+type CodedError struct {
+	Code Code
+	Args []interface{}
+}
+
+// Errorf creates a CodedError for code
+func Errorf(code Code, args ...interface{}) error {
+	return &CodedError{Code: code, Args: args}
+}
+
+func (e *CodedError) Error() string {
+	return Message(e.Code, e.Args...)
+}
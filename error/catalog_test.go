@@ -0,0 +1,158 @@
+package customError
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// declaredCodes reads the code constants of codes.go, in the order they are declared
+func declaredCodes(t *testing.T) map[string]Code {
+	t.Helper()
+	file, err := parser.ParseFile(token.NewFileSet(), "codes.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	codes := make(map[string]Code)
+	ast.Inspect(file, func(n ast.Node) bool {
+		spec, ok := n.(*ast.ValueSpec)
+		if !ok {
+			return true
+		}
+		for i, name := range spec.Names {
+			value, _ := strconv.Unquote(spec.Values[i].(*ast.BasicLit).Value)
+			codes[name.Name] = Code(value)
+		}
+		return false
+	})
+	return codes
+}
+
+// verbsOf returns the formatting verbs of a template, sorted, without the argument indexes
+func verbsOf(template string) []string {
+	verbs := verb.FindAllString(template, -1)
+	for i, v := range verbs {
+		verbs[i] = regexp.MustCompile(`\[\d+\]`).ReplaceAllString(v, "")
+	}
+	slices.Sort(verbs)
+	return verbs
+}
+
+func TestCodes(t *testing.T) {
+	format := regexp.MustCompile(`^P0[0-3]\d\d$`)
+	names := make(map[Code]string)
+	for name, code := range declaredCodes(t) {
+		if !format.MatchString(string(code)) {
+			t.Errorf("%s: code %q is not of the form P0xxx", name, code)
+		}
+		if other, ok := names[code]; ok {
+			t.Errorf("%s and %s have the same code %s", name, other, code)
+		}
+		names[code] = name
+	}
+
+	// Mỗi mã trong catalog đều được khai báo, và ngược lại
+	if len(names) != len(Codes()) {
+		t.Errorf("codes.go declares %d codes, the catalog has %d", len(names), len(Codes()))
+	}
+	for _, code := range Codes() {
+		if _, ok := names[code]; !ok {
+			t.Errorf("%s has a message but no constant", code)
+		}
+	}
+}
+
+func TestMessages(t *testing.T) {
+	for _, code := range Codes() {
+		english := messages[English][code]
+		for _, lang := range Languages() {
+			translated, ok := messages[lang][code]
+			if !ok {
+				t.Errorf("%s: no %s message", code, lang)
+				continue
+			}
+			if !slices.Equal(verbsOf(translated), verbsOf(english)) {
+				t.Errorf("%s: %s message %q does not take the arguments of %q", code, lang, translated, english)
+			}
+			if _, ok := explanations[lang][code]; !ok {
+				t.Errorf("%s: no %s explanation", code, lang)
+			}
+		}
+	}
+	for _, lang := range Languages() {
+		for code := range explanations[lang] {
+			if _, ok := messages[English][code]; !ok {
+				t.Errorf("%s explanation of unknown code %s", lang, code)
+			}
+		}
+		for code := range messages[lang] {
+			if _, ok := messages[English][code]; !ok {
+				t.Errorf("%s message of unknown code %s", lang, code)
+			}
+		}
+	}
+	for english, translated := range phrases[Vietnamese] {
+		if !slices.Equal(verbsOf(translated), verbsOf(english)) {
+			t.Errorf("phrase %q is translated as %q, with other arguments", english, translated)
+		}
+	}
+}
+
+func TestLanguage(t *testing.T) {
+	defer SetLanguage(Language())
+
+	tests := []struct {
+		locale   string
+		expected string
+	}{
+		{"vi", Vietnamese},
+		{"vi_VN.UTF-8", Vietnamese},
+		{"VI-vn", Vietnamese},
+		{"en_US.UTF-8", English},
+		{"C", English},
+		{"fr_FR", English},
+	}
+	for _, tt := range tests {
+		if got := normalizeLanguage(tt.locale); got != tt.expected {
+			t.Errorf("normalizeLanguage(%q) = %q, want %q", tt.locale, got, tt.expected)
+		}
+	}
+
+	t.Setenv("PUN_LANG", "vi")
+	t.Setenv("LANG", "en_US.UTF-8")
+	if got := DetectLanguage(); got != Vietnamese {
+		t.Errorf("PUN_LANG=vi: got language %q", got)
+	}
+
+	if SetLanguage("fr") || !SetLanguage(Vietnamese) {
+		t.Errorf("SetLanguage accepts fr or refuses vi")
+	}
+	if got := Message(ExpectedSet, "number"); got != "cần một set, nhưng có number" {
+		t.Errorf("Vietnamese message: got %q", got)
+	}
+	if got := Text("Stack trace:"); got != "Các lời gọi đang chạy:" {
+		t.Errorf("Vietnamese phrase: got %q", got)
+	}
+	SetLanguage(English)
+	if got := Text("no translation %d", 1); got != "no translation 1" {
+		t.Errorf("untranslated phrase: got %q", got)
+	}
+}
+
+func TestExplain(t *testing.T) {
+	defer SetLanguage(Language())
+	SetLanguage(English)
+
+	text, ok := Explain(ExpectedSet)
+	if !ok || !strings.HasPrefix(text, "P0364: expected a set, got …\n\n") {
+		t.Errorf("Explain(%s) = %q, %v", ExpectedSet, text, ok)
+	}
+	if _, ok := Explain("P9999"); ok {
+		t.Errorf("Explain accepts an unknown code")
+	}
+}
@@ -0,0 +1,176 @@
+package customError
+
+// Mã lỗi. Chỉ thêm mã mới ở cuối mỗi nhóm, không đổi nghĩa hay dùng lại mã cũ.
+
+// Lỗi của lexer
+const (
+	InvalidUTF8                Code = "P0001"
+	UnterminatedString         Code = "P0002"
+	UnterminatedLineString     Code = "P0003"
+	InvalidHexEscape           Code = "P0004"
+	InvalidUnicodeEscape       Code = "P0005"
+	InvalidUnicodeEscapeDigits Code = "P0006"
+	InvalidCodePoint           Code = "P0007"
+	UnknownEscape              Code = "P0008"
+	UnexpectedDotInNumber      Code = "P0009"
+	MissingFractionDigits      Code = "P0010"
+	MissingExponentDigits      Code = "P0011"
+	InvalidNumberCharacter     Code = "P0012"
+	FractionalBigInt           Code = "P0013"
+	InvalidDigit               Code = "P0014"
+	NoDigits                   Code = "P0015"
+	MisplacedUnderscore        Code = "P0016"
+	ReadError                  Code = "P0017"
+	UnterminatedComment        Code = "P0018"
+)
+
+// Lỗi cú pháp của parser
+const (
+	UnexpectedToken              Code = "P0100"
+	InvalidNumber                Code = "P0101"
+	UnexpectedKeyword            Code = "P0102"
+	InvalidWalrusValue           Code = "P0103"
+	MissingExpressionBeforeBrace Code = "P0104"
+	TrailingComma                Code = "P0105"
+	MissingCloseParen            Code = "P0106"
+	EmptyInterpolation           Code = "P0107"
+	UnclosedInterpolation        Code = "P0108"
+	ExpectedToken                Code = "P0109"
+	InvalidStatement             Code = "P0110"
+	UnexpectedStatement          Code = "P0111"
+	InvalidAssignTarget          Code = "P0112"
+	InvalidAssignValue           Code = "P0113"
+	InvalidCondition             Code = "P0114"
+	InvalidForInit               Code = "P0115"
+	InvalidForUpdate             Code = "P0116"
+	LabelWithoutLoop             Code = "P0117"
+	OptionalDefer                Code = "P0118"
+	DeferNotCall                 Code = "P0119"
+	EmptyEnum                    Code = "P0120"
+	InvalidMatchSubject          Code = "P0121"
+	DuplicateElseArm             Code = "P0122"
+	ElseArmNotLast               Code = "P0123"
+	InvalidMatchPattern          Code = "P0124"
+)
+
+// Lỗi của compiler
+const (
+	OperandTooLarge         Code = "P0200"
+	BuiltinRedeclared       Code = "P0201"
+	UndefinedVariable       Code = "P0202"
+	InvalidDecimal          Code = "P0203"
+	TooManyTemplateParts    Code = "P0204"
+	TooManyElements         Code = "P0205"
+	TooManyMethodArguments  Code = "P0206"
+	UnsupportedStatement    Code = "P0207"
+	UnsupportedAssignTarget Code = "P0208"
+	DuplicateLoopLabel      Code = "P0209"
+	UnknownLoopLabel        Code = "P0210"
+	OutsideLoop             Code = "P0211"
+	LeaveValueExpression    Code = "P0212"
+	ReturnOutsideFunction   Code = "P0213"
+	NestedFunction          Code = "P0214"
+	NestedRecord            Code = "P0215"
+	NestedMethod            Code = "P0216"
+	NestedEnum              Code = "P0217"
+	DuplicateType           Code = "P0218"
+	DuplicateField          Code = "P0219"
+	MethodOnNonRecord       Code = "P0220"
+	DuplicateMethod         Code = "P0221"
+	MethodShadowsField      Code = "P0222"
+	UnknownSpecialMethod    Code = "P0223"
+	SpecialMethodArity      Code = "P0224"
+	SelfParameter           Code = "P0225"
+	DeferOutsideFunction    Code = "P0226"
+	CannotDefer             Code = "P0227"
+	DuplicateVariant        Code = "P0228"
+	DuplicateVariantValue   Code = "P0229"
+	MixedMatchEnums         Code = "P0230"
+	UnreachableArm          Code = "P0231"
+	BindingInMultiPattern   Code = "P0232"
+	NonExhaustiveMatch      Code = "P0233"
+	UnknownVariant          Code = "P0234"
+	PatternArity            Code = "P0235"
+	PatternBindsNonName     Code = "P0236"
+	NonExhaustiveIf         Code = "P0237"
+)
+
+// Lỗi khi chạy (vm)
+const (
+	StackUnderflow            Code = "P0300"
+	GlobalSlotOutOfBounds     Code = "P0301"
+	LocalSlotOutOfBounds      Code = "P0302"
+	ScopeDepthOutOfBounds     Code = "P0303"
+	UnknownOpcode             Code = "P0304"
+	WrongArraySize            Code = "P0305"
+	NotAFunctionObject        Code = "P0306"
+	NotAFormatSpec            Code = "P0307"
+	DivisionByZero            Code = "P0308"
+	UnsupportedOperator       Code = "P0309"
+	NonNumericOperands        Code = "P0310"
+	ExponentTooLarge          Code = "P0311"
+	DecimalExponent           Code = "P0312"
+	InexactDecimal            Code = "P0313"
+	UnsupportedComparison     Code = "P0314"
+	CannotCompare             Code = "P0315"
+	UnsupportedComparisonType Code = "P0316"
+	StringEquality            Code = "P0317"
+	EqualityOnly              Code = "P0318"
+	RecordCompare             Code = "P0319"
+	RecordOperator            Code = "P0320"
+	TupleOperator             Code = "P0321"
+	LogicalOperands           Code = "P0322"
+	UnsupportedLogical        Code = "P0323"
+	NotNonBoolean             Code = "P0324"
+	NegateNonNumber           Code = "P0325"
+	NegateRecord              Code = "P0326"
+	BitwiseIntegers           Code = "P0327"
+	BitwiseInteger            Code = "P0328"
+	InvalidShift              Code = "P0329"
+	UnsupportedBitwise        Code = "P0330"
+	IndexNotNumber            Code = "P0331"
+	NotIndexable              Code = "P0332"
+	IndexOutOfBounds          Code = "P0333"
+	RecordNotIndexable        Code = "P0334"
+	ImmutableElement          Code = "P0335"
+	FrozenArray               Code = "P0336"
+	Unhashable                Code = "P0337"
+	UnsupportedSetOperator    Code = "P0338"
+	SetOperands               Code = "P0339"
+	SetFrom                   Code = "P0340"
+	NotCallable               Code = "P0341"
+	ArgumentCount             Code = "P0342"
+	UndefinedBuiltin          Code = "P0343"
+	BuiltinArgumentCount      Code = "P0344"
+	SetArgumentCount          Code = "P0345"
+	RoundArgumentCount        Code = "P0346"
+	RecordArgumentCount       Code = "P0347"
+	MethodArgumentCount       Code = "P0348"
+	NoProperty                Code = "P0349"
+	NoMethod                  Code = "P0350"
+	VariantNeedsValues        Code = "P0351"
+	VariantHasNoValues        Code = "P0352"
+	VariantArity              Code = "P0353"
+	StrResult                 Code = "P0354"
+	BoolResult                Code = "P0355"
+	ConvertType               Code = "P0356"
+	ConvertString             Code = "P0357"
+	ConvertValue              Code = "P0358"
+	UnknownRounding           Code = "P0359"
+	RoundPlaces               Code = "P0360"
+	RoundType                 Code = "P0361"
+	SortType                  Code = "P0362"
+	ContainsType              Code = "P0363"
+	ExpectedSet               Code = "P0364"
+	InvalidFormatSpec         Code = "P0365"
+	FormatMissingPrecision    Code = "P0366"
+	UnknownFormatType         Code = "P0367"
+	FormatNeedsNumber         Code = "P0368"
+	FormatTypeNeedsNumber     Code = "P0369"
+	FormatNeedsWhole          Code = "P0370"
+	UnsupportedFormatType     Code = "P0371"
+	NotIterable               Code = "P0372"
+	ConditionNotBoolean       Code = "P0373"
+	CustomEqualityUnhashable  Code = "P0374"
+	InternalError             Code = "P0399"
+)
@@ -1,12 +1,52 @@
 package customError
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // Base struct cho mọi lỗi trong Pun
 type PunError struct {
-	Message string
-	Line    int
-	Column  int
+	Code      Code // Mã lỗi ổn định cho công cụ và `pun explain` (xem codes.go)
+	Message   string
+	Line      int
+	Column    int
+	EndLine   int      // Cuối đoạn bị gạch chân (0: chỉ đánh dấu ký tự ở Line:Column)
+	EndColumn int      // Cột ngay sau ký tự cuối
+	Labels    []Label  // Các vị trí liên quan khác (ví dụ dấu ngoặc mở chưa được đóng)
+	Notes     []string // Giải thích thêm
+	Help      string   // Gợi ý cách sửa
+	Fixes     []Fix    // Các thay đổi có thể áp dụng tự động
+}
+
+// Fix replaces the text from Line:Column to EndLine:EndColumn with Replacement
+type Fix struct {
+	Message     string
+	Line        int
+	Column      int
+	EndLine     int
+	EndColumn   int
+	Replacement string
+}
+
+// Label points at another place in the source that explains the error
+type Label struct {
+	Line      int
+	Column    int
+	EndColumn int
+	Message   string
+}
+
+// Diagnostic is an error that the Renderer can show with its source
+type Diagnostic interface {
+	error
+	Kind() string    // SyntaxError, CompilationError hoặc RuntimeError
+	Base() *PunError // Vị trí, nhãn, ghi chú
+	Detail() string  // Nhãn dưới dấu ^
+}
+
+func (e *PunError) Base() *PunError {
+	return e
 }
 
 // Implement error interface
@@ -26,6 +66,9 @@ func (e *SyntaxError) Error() string {
 		e.Line, e.Column, e.Message, e.Context)
 }
 
+func (e *SyntaxError) Kind() string   { return "SyntaxError" }
+func (e *SyntaxError) Detail() string { return e.Context }
+
 type CompilationError struct {
 	PunError
 	Context string
@@ -36,12 +79,59 @@ func (e *CompilationError) Error() string {
 		e.Line, e.Column, e.Message, e.Context)
 }
 
+func (e *CompilationError) Kind() string   { return "CompilationError" }
+func (e *CompilationError) Detail() string { return e.Context }
+
 type RuntimeError struct {
 	PunError
-	Context string // Thông tin bổ sung
+	Context string       // Thông tin bổ sung
+	Stack   []StackFrame // Các lời gọi đang chạy khi lỗi xảy ra, trong cùng ở đầu
 }
 
 func (e *RuntimeError) Error() string {
-	return fmt.Sprintf("RuntimeError at line (%d:%d): %s\nContext: %s",
+	message := fmt.Sprintf("RuntimeError at line (%d:%d): %s\nContext: %s",
 		e.Line, e.Column, e.Message, e.Context)
+	if len(e.Stack) == 0 {
+		return message
+	}
+	return message + "\n" + e.Trace()
+}
+
+// Trace formats the call stack, innermost call first
+func (e *RuntimeError) Trace() string {
+	var trace strings.Builder
+	trace.WriteString(Text("Stack trace:"))
+	for i, frame := range e.Stack {
+		// Đệ quy sâu: chỉ in các frame ở hai đầu
+		if len(e.Stack) > 2*traceEnds && i == traceEnds {
+			trace.WriteString("\n  " + Text("... %d more calls ...", len(e.Stack)-2*traceEnds))
+		}
+		if len(e.Stack) > 2*traceEnds && i >= traceEnds && i < len(e.Stack)-traceEnds {
+			continue
+		}
+		trace.WriteString("\n  at " + frame.String())
+	}
+	return trace.String()
+}
+
+func (e *RuntimeError) Kind() string   { return "RuntimeError" }
+func (e *RuntimeError) Detail() string { return e.Context }
+
+// traceEnds is how many frames a long stack trace keeps at each end
+const traceEnds = 10
+
+// StackFrame is one call of a runtime stack trace: the function and the line it was running
+type StackFrame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+}
+
+func (f StackFrame) String() string {
+	file := f.File
+	if file == "" {
+		file = "<input>" // Code nhập từ REPL
+	}
+	return fmt.Sprintf("%s (%s:%d:%d)", f.Function, file, f.Line, f.Column)
 }
@@ -0,0 +1,499 @@
+package customError
+
+// explanations holds the long form of every code, shown by `pun explain`
+var explanations = map[string]map[Code]string{
+	English: {
+		InvalidUTF8: `The source file contains bytes that are not valid UTF-8. Pun reads every file as UTF-8 text.
+Save the file as UTF-8 in your editor (most editors offer this in "Save with encoding").`,
+		UnterminatedString: `A string was opened but the file ended before its closing quote:
+
+    name = "Pun
+
+Add the missing quote at the end of the string: name = "Pun".`,
+		UnterminatedLineString: `A string in double quotes must end on the line where it starts.
+For text that spans several lines use triple quotes:
+
+    poem = """
+    line one
+    line two
+    """`,
+		InvalidHexEscape:           `The escape \x writes one byte given by exactly two hexadecimal digits, for example \x41 for "A".`,
+		InvalidUnicodeEscape:       `A Unicode escape is written \u followed by the code point in braces, for example \u{1F600}.`,
+		InvalidUnicodeEscapeDigits: `The braces of a \u{...} escape must contain between 1 and 6 hexadecimal digits.`,
+		InvalidCodePoint: `Unicode code points go up to U+10FFFF, and U+D800 to U+DFFF are reserved (surrogates).
+The escape names a number outside the valid range.`,
+		UnknownEscape: `Only these escapes are understood inside strings: \n \t \r \\ \" \' \$ \0 \xHH and \u{...}.
+To write a backslash itself, double it: "C:\\pun".`,
+		UnexpectedDotInNumber: `A number has at most one decimal point, and bigint or prefixed numbers (0x, 0o, 0b) have none.`,
+		MissingFractionDigits: `A decimal point must be followed by at least one digit: write 1.0 instead of 1.`,
+		MissingExponentDigits: `After e or E a number needs the exponent, optionally signed: 1e9, 2.5e-3.`,
+		InvalidNumberCharacter: `A letter or symbol is stuck to a number. Names cannot start with a digit, and only the
+suffixes n (bigint) and d (decimal) may follow a number: 10n, 1.50d.`,
+		FractionalBigInt: `The suffix n makes a bigint, which holds whole numbers only. Use the suffix d for an exact
+decimal such as 1.25d.`,
+		InvalidDigit: `Each base accepts its own digits: 0b uses 0-1, 0o uses 0-7 and 0x uses 0-9 and a-f.`,
+		NoDigits:     `A prefix such as 0x must be followed by at least one digit: 0xFF.`,
+		MisplacedUnderscore: `Underscores make long numbers easier to read (1_000_000), but each one must sit between two
+digits: not at the start or end, not doubled, and not next to the decimal point.`,
+		ReadError: `The source file stopped being readable part way through, for example because the disk or the
+network share it lives on failed. Everything before that point was parsed; check the file and run it again.`,
+		UnterminatedComment: `A block comment was opened with /* but the file ended before the closing */. Add */ where
+the comment should end.`,
+		UnexpectedToken: `The parser found a symbol where it cannot start or continue an expression, for example an
+operator with a missing operand:
+
+    x = 1 + * 2`,
+		InvalidNumber: `The number literal could not be converted to a value (for example it is too large).`,
+		UnexpectedKeyword: `Keywords such as return, for or func start statements and cannot be used as values.
+Only if, match, true, false and nothing can appear inside an expression.`,
+		InvalidWalrusValue: `The right side of name := value must be an expression: (n := count + 1) > 10.`,
+		MissingExpressionBeforeBrace: `In a condition, { starts the body of the statement, so the condition itself is missing:
+
+    if { ... }          // missing condition
+    if ready { ... }    // correct`,
+		TrailingComma: `An array literal cannot end with a comma: write [1, 2, 3] instead of [1, 2, 3,].`,
+		MissingCloseParen: `A parenthesis was opened but not closed. The error points at the first token that cannot
+belong inside the parentheses; the opening parenthesis is usually on the same or a previous line.`,
+		EmptyInterpolation: `Every ${...} inside a string must contain an expression: "total: ${price * count}".
+To write the characters ${ literally, escape the dollar sign: "\${".`,
+		UnclosedInterpolation: `An interpolation ${...} was opened but the expression inside is followed by something other than }.`,
+		ExpectedToken: `A specific symbol is required at this point of the grammar, for example the ( after a function
+name or the { that opens a block. When a closing bracket is missing, the bracket it should
+close is marked as well.`,
+		InvalidStatement: `The line could not be read as any statement. Pun skips to the next line and keeps checking the
+rest of the file, so fix the first error first: later ones may disappear with it.`,
+		UnexpectedStatement: `This keyword cannot start a statement here, for example elif or else without an if before them.`,
+		InvalidAssignTarget: `Only a variable, an element (a[i]) or a property (p.x) can be assigned to.`,
+		InvalidAssignValue:  `The right side of = is missing or is not an expression.`,
+		InvalidCondition:    `The condition between the keyword and { is missing or is not an expression.`,
+		InvalidForInit: `A counting loop is written for init; condition; update { ... }, and init must be an assignment:
+
+    for i = 0; i < 10; i = i + 1 { ... }`,
+		InvalidForUpdate: `The third part of for init; condition; update must be a statement such as i = i + 1.`,
+		LabelWithoutLoop: `A label names a loop so that break and continue can target it: outer: for ... { ... }.
+It cannot stand before other statements.`,
+		OptionalDefer: `defer needs to know the call it will run later; obj?.close() may not call anything.
+Check for nothing first, then defer obj.close().`,
+		DeferNotCall: `defer schedules a call to run when the function returns, so it must be followed by a call:
+defer print("done").`,
+		EmptyEnum:           `An enum lists its variants between braces: enum Color { Red, Green, Blue }.`,
+		InvalidMatchSubject: `match must be followed by the value to match: match result { ... }.`,
+		DuplicateElseArm:    `The else arm catches every value no other arm matched, so a match can have only one.`,
+		ElseArmNotLast:      `Arms are tried from top to bottom and else matches everything, so arms after it could never run.`,
+		InvalidMatchPattern: `Each arm starts with one or more patterns separated by commas: a value (1, "a") or an enum
+variant (Color.Red, Result.Ok(value)), followed by a block.`,
+		OperandTooLarge: internalEnglish,
+		BuiltinRedeclared: `Names such as print, sort or PI are built into Pun and cannot be used for variables,
+parameters or functions. Choose another name.`,
+		UndefinedVariable: `The name is read before anything was assigned to it in a visible scope. Check the spelling
+(Pun suggests a close name when there is one) and assign the variable before using it:
+
+    print(total)    // error: total does not exist yet
+    total = 0
+    print(total)    // correct`,
+		InvalidDecimal:       `A decimal literal ends with d and holds digits with at most one decimal point: 19.99d.`,
+		TooManyTemplateParts: `A string can contain at most 255 text and ${...} parts. Split it into several strings.`,
+		TooManyElements:      `Tuple and set literals can list at most 255 elements. Build larger collections step by step.`,
+		TooManyMethodArguments: `A method call can pass at most 255 arguments, and a program can use at most 256 distinct
+constants before its method names.`,
+		UnsupportedStatement:    internalEnglish,
+		UnsupportedAssignTarget: `Only a variable, an element (a[i]) or a property (p.x) can be assigned to.`,
+		DuplicateLoopLabel:      `A loop nested inside another loop cannot reuse its label, or break and continue would be ambiguous.`,
+		UnknownLoopLabel: `break label and continue label must name a loop that encloses them:
+
+    outer: for i = 0; i < 3; i = i + 1 {
+        for j = 0; j < 3; j = j + 1 {
+            break outer
+        }
+    }`,
+		OutsideLoop: `break and continue only make sense inside for, while or until. To leave a function early, use return.`,
+		LeaveValueExpression: `An if, match or block used as a value must produce that value, so break, continue and return
+cannot jump out of it. Use an if statement instead of an if expression.`,
+		ReturnOutsideFunction: `return ends the function it is in; at the top level of a file there is no function to end.`,
+		NestedFunction:        `Functions are declared at the top level of a file, not inside other functions, loops or blocks.`,
+		NestedRecord:          `Record types are declared at the top level of a file so that every function can use them.`,
+		NestedMethod:          `Methods (func Type.name(...)) are declared at the top level of a file, after their record.`,
+		NestedEnum:            `Enums are declared at the top level of a file so that every function can use them.`,
+		DuplicateType:         `Records and enums share one namespace: each type name can be declared only once.`,
+		DuplicateField:        `Every field of a record needs its own name: record Point(x, y), not record Point(x, x).`,
+		MethodOnNonRecord: `func Type.name(...) adds a method to a record. Declare the record before its methods:
+
+    record Point(x, y)
+    func Point.length() { return (self.x ** 2 + self.y ** 2) ** 0.5 }`,
+		DuplicateMethod:    `A record can have only one method with a given name.`,
+		MethodShadowsField: `p.name would be ambiguous between the field and the method. Rename one of them.`,
+		UnknownSpecialMethod: `Names between double underscores are reserved for operators: __add__ __sub__ __mul__ __div__
+__mod__ __pow__ (and their reflected forms __radd__ ... __rpow__), __eq__ __lt__ __index__
+__neg__ and __str__.`,
+		SpecialMethodArity: `Operator methods have a fixed shape: binary operators such as __add__(other) take one parameter,
+__neg__() and __str__() take none. self is always available without being listed.`,
+		SelfParameter:         `Inside func Point.move(dx) the record is already available as self; do not list it.`,
+		DeferOutsideFunction:  `defer runs a call when the enclosing function returns, so it can only be used inside a function.`,
+		CannotDefer:           `Only function and method calls can be deferred.`,
+		DuplicateVariant:      `Every variant of an enum needs its own name.`,
+		DuplicateVariantValue: `The values carried by a variant need distinct names: Ok(value), Pair(left, right).`,
+		MixedMatchEnums:       `A value belongs to a single enum, so the variant patterns of one match must all come from it.`,
+		UnreachableArm:        `Arms are tried from top to bottom, so a later arm for the same variant could never run.`,
+		BindingInMultiPattern: `In Result.Ok(v), Result.Err(v) { ... } the name v would come from different variants.
+Give each variant its own arm when you need its values.`,
+		NonExhaustiveMatch: `When a match tests the variants of an enum, every variant must be handled, so adding a variant
+later shows every match that needs updating. Add the missing arms or an else arm.`,
+		UnknownVariant: `The name after the dot must be one of the variants listed in the enum declaration.`,
+		PatternArity: `A pattern such as Result.Ok(value) binds one name per value carried by the variant.
+Write the variant without parentheses to match it regardless of its values.`,
+		PatternBindsNonName: `Inside a variant pattern, each value is given a name: Result.Ok(value), not Result.Ok(1).
+Compare the value inside the arm instead.`,
+		NonExhaustiveIf: `An if used as a value that compares one variable with the variants of an enum
+(x = if c == Color.Red { 1 } elif c == Color.Green { 2 }) would give nothing for the variants it
+leaves out, so like a match it must handle all of them. Add the missing branches or an else branch.`,
+		StackUnderflow:        internalEnglish,
+		GlobalSlotOutOfBounds: internalEnglish,
+		LocalSlotOutOfBounds:  internalEnglish,
+		ScopeDepthOutOfBounds: internalEnglish,
+		UnknownOpcode:         internalEnglish,
+		WrongArraySize:        internalEnglish,
+		NotAFunctionObject:    internalEnglish,
+		NotAFormatSpec:        internalEnglish,
+		DivisionByZero: `The right side of /, % or a decimal division is zero. Check the divisor before dividing:
+
+    if count != 0 { average = total / count }`,
+		UnsupportedOperator: `This operator cannot be applied to these values.`,
+		NonNumericOperands: `Arithmetic works on numbers (and + also joins strings and collections). One of the operands has
+another type, often nothing from a missing value. Convert text with number(x) first.`,
+		ExponentTooLarge: `The result of ** on decimals, or a decimal written with an exponent such as 1e99999, would be too
+large to compute exactly. Exponents go up to 65536 either way.`,
+		DecimalExponent: `A decimal can only be raised to a whole power, because other powers are not exact.
+Convert with number(x) to use fractional exponents.`,
+		InexactDecimal: `Decimals are exact; an ordinary number such as 0.1 is stored in binary and is slightly off.
+Pun refuses to mix them silently. Write the literal as 0.1d, or convert one side explicitly.`,
+		UnsupportedComparison: internalEnglish,
+		CannotCompare: `Ordering (<, >, <=, >=) works between two numbers, two strings or two values of the same
+collection type. Convert one side first, for example number(text).`,
+		UnsupportedComparisonType: `Values of this type cannot be ordered with <, >, <= or >=.`,
+		StringEquality:            `Strings can be compared with == and != here.`,
+		EqualityOnly:              `These values can be tested for equality but have no order.`,
+		RecordCompare: `Records are ordered by their __lt__ method. Define it to use <, >, <= and >=:
+
+    func Money.__lt__(other) { return self.cents < other.cents }`,
+		RecordOperator: `Operators on records call special methods: + calls __add__, - calls __sub__ and so on.
+Define the method named in the message:
+
+    func Vec.__add__(other) { return Vec(self.x + other.x, self.y + other.y) }`,
+		TupleOperator:      `Tuples support + (joining) and comparisons, but not this operator.`,
+		LogicalOperands:    `&& and || combine true and false. Compare values first: count > 0 && ready.`,
+		UnsupportedLogical: internalEnglish,
+		NotNonBoolean:      `! negates true or false. To test for a missing value compare with nothing: x == nothing.`,
+		NegateNonNumber:    `Unary minus works on numbers, bigints and decimals.`,
+		NegateRecord:       `-r on a record calls its __neg__ method. Define func Type.__neg__() to support it.`,
+		BitwiseIntegers:    `&, |, ^, << and >> work on whole numbers. Round the values first with round(x, 0).`,
+		BitwiseInteger:     `~ works on whole numbers.`,
+		InvalidShift:       `The right side of << and >> must be a whole number from 0 upwards.`,
+		UnsupportedBitwise: internalEnglish,
+		IndexNotNumber:     `Arrays, strings and tuples are indexed by whole numbers starting at 0: items[0].`,
+		NotIndexable:       `Only arrays, strings, tuples and records with __index__ can be indexed with [ ].`,
+		IndexOutOfBounds:   `Valid indexes go from 0 to length - 1. Check the index against items.length first.`,
+		RecordNotIndexable: `r[i] on a record calls its __index__ method. Define func Type.__index__(i) to support it.`,
+		ImmutableElement: `Strings and tuples cannot be changed after they are created. Build a new value instead,
+or use an array.`,
+		FrozenArray: `freeze(a) and #[...] make an array read-only. Use copy(a) to get a modifiable copy.`,
+		Unhashable: `Set elements must not change, so a modifiable array (or NaN) cannot be put in a set. Use a tuple
+or a frozen array instead: #{ (1, 2), #[3, 4] }.`,
+		UnsupportedSetOperator: `Sets support | (union), & (intersection), - (difference) and ^ (symmetric difference).`,
+		SetOperands:            `Set operators combine two sets. Convert the other side with set(x) first.`,
+		SetFrom:                `set(x) takes an array, a tuple, a string or another set.`,
+		NotCallable:            `Only functions can be called with ( ). The name probably holds another value.`,
+		ArgumentCount:          `A function must be called with exactly as many arguments as it has parameters.`,
+		UndefinedBuiltin:       internalEnglish,
+		BuiltinArgumentCount:   `This built-in function takes exactly one argument.`,
+		SetArgumentCount:       `set() makes an empty set and set(x) makes a set from the elements of x.`,
+		RoundArgumentCount: `round(x, places) rounds to a number of decimal places; a third argument picks the rounding
+mode: round(x, 2, "half_up").`,
+		RecordArgumentCount: `Creating a record takes one argument per field, in the order of the declaration.`,
+		MethodArgumentCount: `Built-in methods take a fixed number of arguments.`,
+		NoProperty:          `The value has no field or property with this name. Check the spelling and the type of the value.`,
+		NoMethod:            `The value has no method with this name. Check the spelling and the type of the value.`,
+		VariantNeedsValues:  `A variant declared with values, such as Ok(value), is created by calling it: Result.Ok(42).`,
+		VariantHasNoValues:  `A variant declared without values is already a value: write Color.Red, not Color.Red().`,
+		VariantArity:        `A variant is created with one argument per value in its declaration.`,
+		StrResult:           `print and ${...} use __str__ to turn a record into text, so it must return a string.`,
+		BoolResult:          `__eq__ and __lt__ answer a yes/no question and must return true or false.`,
+		ConvertType:         `number(x), decimal(x) and bigint(x) accept numbers and strings that contain a number.`,
+		ConvertString:       `The text does not contain a valid number. Trim spaces and check the input first.`,
+		ConvertValue: `This value has no exact equivalent in the target type, for example a number with a fraction
+to bigint, or infinity to decimal.`,
+		UnknownRounding: `Rounding modes are given by name: half_even (the default, banker's rounding), half_up,
+half_down, up, down, ceiling and floor.`,
+		RoundPlaces:            `The second argument of round is the number of decimal places to keep.`,
+		RoundType:              `round works on numbers and decimals.`,
+		SortType:               `sort takes an array whose elements can be compared with <.`,
+		ContainsType:           `s.contains(part) looks for text inside a string.`,
+		ExpectedSet:            `Set methods such as union, intersection and difference take another set. Convert with set(x) first.`,
+		InvalidFormatSpec:      `A format spec follows : inside ${...}: ${price:.2f}, ${n:,}, ${ratio:.1%}.`,
+		FormatMissingPrecision: `After the dot comes the number of digits: ${x:.2f}.`,
+		UnknownFormatType: `Format types are f (fixed), e and g (scientific), % (percent), d (integer), x, o and b (hex,
+octal and binary) and s (text).`,
+		FormatNeedsNumber:     `Numeric format specs cannot be applied to text or other values.`,
+		FormatTypeNeedsNumber: `This format type only works on numbers.`,
+		FormatNeedsWhole:      `d, x, o and b print integers. Round the value first, or use f for fractions.`,
+		UnsupportedFormatType: internalEnglish,
+		NotIterable: `for x in xs runs the body once per element of an array, tuple or set. To loop over a range of
+numbers use for i = 0; i < n; i = i + 1.`,
+		ConditionNotBoolean: `if, elif, while, until, ?: and loop conditions must be true or false. Pun does not treat 0, "" or
+nothing as false: compare explicitly, for example if count > 0 or if x != nothing.`,
+		CustomEqualityUnhashable: `A set finds equal elements by their fields. A record type with its own __eq__ decides equality in
+code the set cannot use, so the set could keep two values that == says are equal. Put a field that
+identifies the value in the set instead: #{p.id}.`,
+		InternalError: internalEnglish,
+	},
+	Vietnamese: {
+		InvalidUTF8: `File nguồn chứa các byte không phải UTF-8 hợp lệ. Pun luôn đọc file dưới dạng văn bản UTF-8.
+Hãy lưu lại file với mã hoá UTF-8 trong trình soạn thảo (thường ở mục "Save with encoding").`,
+		UnterminatedString: `Một chuỗi được mở nhưng file kết thúc trước khi gặp dấu nháy đóng:
+
+    name = "Pun
+
+Hãy thêm dấu nháy còn thiếu ở cuối chuỗi: name = "Pun".`,
+		UnterminatedLineString: `Chuỗi trong dấu nháy kép phải kết thúc trên cùng dòng với chỗ bắt đầu.
+Với văn bản nhiều dòng, hãy dùng ba dấu nháy:
+
+    poem = """
+    dòng một
+    dòng hai
+    """`,
+		InvalidHexEscape:           `Escape \x biểu diễn một byte bằng đúng hai chữ số hex, ví dụ \x41 là "A".`,
+		InvalidUnicodeEscape:       `Escape Unicode được viết là \u theo sau là mã trong dấu ngoặc nhọn, ví dụ \u{1F600}.`,
+		InvalidUnicodeEscapeDigits: `Trong dấu ngoặc của escape \u{...} phải có từ 1 đến 6 chữ số hex.`,
+		InvalidCodePoint: `Mã Unicode chỉ đến U+10FFFF, và các mã từ U+D800 đến U+DFFF được dành riêng (surrogate).
+Escape này chứa một số nằm ngoài khoảng hợp lệ.`,
+		UnknownEscape: `Trong chuỗi chỉ dùng được các escape: \n \t \r \\ \" \' \$ \0 \xHH và \u{...}.
+Muốn viết dấu \ thì gõ hai lần: "C:\\pun".`,
+		UnexpectedDotInNumber: `Một số chỉ có tối đa một dấu thập phân, còn số bigint hoặc số có tiền tố (0x, 0o, 0b) thì không có.`,
+		MissingFractionDigits: `Sau dấu thập phân phải có ít nhất một chữ số: viết 1.0 thay vì 1.`,
+		MissingExponentDigits: `Sau e hoặc E phải có số mũ, có thể kèm dấu: 1e9, 2.5e-3.`,
+		InvalidNumberCharacter: `Có chữ cái hoặc ký hiệu dính liền với một số. Tên không được bắt đầu bằng chữ số, và sau một số
+chỉ được có hậu tố n (bigint) hoặc d (decimal): 10n, 1.50d.`,
+		FractionalBigInt: `Hậu tố n tạo số bigint, chỉ chứa số nguyên. Hãy dùng hậu tố d cho số thập phân chính xác
+như 1.25d.`,
+		InvalidDigit: `Mỗi hệ cơ số có bộ chữ số riêng: 0b dùng 0-1, 0o dùng 0-7 và 0x dùng 0-9 và a-f.`,
+		NoDigits:     `Sau tiền tố như 0x phải có ít nhất một chữ số: 0xFF.`,
+		MisplacedUnderscore: `Dấu gạch dưới giúp số dài dễ đọc hơn (1_000_000), nhưng mỗi dấu phải nằm giữa hai chữ số:
+không ở đầu hay cuối, không đứng liền nhau và không cạnh dấu thập phân.`,
+		ReadError: `Không đọc tiếp được file nguồn giữa chừng, ví dụ vì ổ đĩa hay thư mục mạng chứa nó bị lỗi. Phần
+trước chỗ đó đã được parse; hãy kiểm tra file rồi chạy lại.`,
+		UnterminatedComment: `Một block comment được mở bằng /* nhưng file kết thúc trước khi gặp */. Hãy thêm */ ở chỗ
+comment kết thúc.`,
+		UnexpectedToken: `Parser gặp một ký hiệu ở chỗ không thể bắt đầu hay tiếp tục biểu thức, ví dụ một toán tử
+thiếu toán hạng:
+
+    x = 1 + * 2`,
+		InvalidNumber: `Không thể chuyển số này thành giá trị (ví dụ vì nó quá lớn).`,
+		UnexpectedKeyword: `Các từ khoá như return, for hay func mở đầu câu lệnh và không thể dùng như giá trị.
+Chỉ if, match, true, false và nothing được xuất hiện trong biểu thức.`,
+		InvalidWalrusValue: `Vế phải của name := value phải là một biểu thức: (n := count + 1) > 10.`,
+		MissingExpressionBeforeBrace: `Trong điều kiện, dấu { mở thân câu lệnh, nên điều kiện đang bị thiếu:
+
+    if { ... }          // thiếu điều kiện
+    if ready { ... }    // đúng`,
+		TrailingComma: `Mảng không được kết thúc bằng dấu phẩy: viết [1, 2, 3] thay vì [1, 2, 3,].`,
+		MissingCloseParen: `Một dấu ngoặc được mở nhưng chưa đóng. Lỗi chỉ vào token đầu tiên không thể nằm trong ngoặc;
+dấu ngoặc mở thường ở cùng dòng hoặc dòng trước đó.`,
+		EmptyInterpolation: `Mỗi ${...} trong chuỗi phải chứa một biểu thức: "tổng: ${price * count}".
+Muốn viết ký tự ${ nguyên văn, hãy escape dấu đô la: "\${".`,
+		UnclosedInterpolation: `Một ${...} được mở nhưng sau biểu thức bên trong không phải là dấu }.`,
+		ExpectedToken: `Tại vị trí này ngữ pháp cần một ký hiệu cụ thể, ví dụ dấu ( sau tên hàm hoặc dấu { mở một block.
+Khi thiếu dấu ngoặc đóng, dấu ngoặc mở tương ứng cũng được đánh dấu.`,
+		InvalidStatement: `Không thể đọc dòng này thành câu lệnh nào. Pun bỏ qua đến dòng tiếp theo và tiếp tục kiểm tra
+phần còn lại của file, nên hãy sửa lỗi đầu tiên trước: các lỗi sau có thể biến mất theo.`,
+		UnexpectedStatement: `Từ khoá này không thể mở đầu câu lệnh ở đây, ví dụ elif hoặc else mà không có if phía trước.`,
+		InvalidAssignTarget: `Chỉ có thể gán cho biến, phần tử (a[i]) hoặc thuộc tính (p.x).`,
+		InvalidAssignValue:  `Vế phải của dấu = bị thiếu hoặc không phải là biểu thức.`,
+		InvalidCondition:    `Điều kiện giữa từ khoá và dấu { bị thiếu hoặc không phải là biểu thức.`,
+		InvalidForInit: `Vòng lặp đếm được viết là for khởi_tạo; điều_kiện; cập_nhật { ... }, và phần khởi tạo phải là phép gán:
+
+    for i = 0; i < 10; i = i + 1 { ... }`,
+		InvalidForUpdate: `Phần thứ ba của for khởi_tạo; điều_kiện; cập_nhật phải là câu lệnh như i = i + 1.`,
+		LabelWithoutLoop: `Nhãn dùng để đặt tên cho vòng lặp, để break và continue có thể nhắm tới: outer: for ... { ... }.
+Nhãn không thể đứng trước các câu lệnh khác.`,
+		OptionalDefer: `defer cần biết chắc lời gọi sẽ chạy sau này; obj?.close() có thể không gọi gì cả.
+Hãy kiểm tra nothing trước rồi mới defer obj.close().`,
+		DeferNotCall: `defer hẹn một lời gọi để chạy khi hàm kết thúc, nên sau nó phải là một lời gọi:
+defer print("xong").`,
+		EmptyEnum:           `Enum liệt kê các variant trong dấu ngoặc nhọn: enum Color { Red, Green, Blue }.`,
+		InvalidMatchSubject: `Sau match phải là giá trị cần so khớp: match result { ... }.`,
+		DuplicateElseArm:    `Nhánh else bắt mọi giá trị mà các nhánh khác không khớp, nên mỗi match chỉ có một nhánh else.`,
+		ElseArmNotLast:      `Các nhánh được thử từ trên xuống và else khớp với mọi giá trị, nên các nhánh sau nó không bao giờ chạy.`,
+		InvalidMatchPattern: `Mỗi nhánh bắt đầu bằng một hoặc nhiều mẫu cách nhau bởi dấu phẩy: một giá trị (1, "a") hoặc một
+variant của enum (Color.Red, Result.Ok(value)), theo sau là một block.`,
+		OperandTooLarge: internalVietnamese,
+		BuiltinRedeclared: `Các tên như print, sort hay PI có sẵn trong Pun và không thể dùng làm tên biến, tham số hay hàm.
+Hãy chọn tên khác.`,
+		UndefinedVariable: `Tên này được đọc trước khi được gán giá trị trong một scope nhìn thấy được. Hãy kiểm tra chính tả
+(Pun gợi ý tên gần giống nếu có) và gán giá trị cho biến trước khi dùng:
+
+    print(total)    // lỗi: total chưa có
+    total = 0
+    print(total)    // đúng`,
+		InvalidDecimal:       `Số decimal kết thúc bằng d và chỉ gồm chữ số với tối đa một dấu thập phân: 19.99d.`,
+		TooManyTemplateParts: `Một chuỗi chỉ chứa được tối đa 255 phần văn bản và ${...}. Hãy tách thành nhiều chuỗi.`,
+		TooManyElements:      `Literal tuple và set chỉ liệt kê được tối đa 255 phần tử. Hãy tạo tập lớn hơn theo từng bước.`,
+		TooManyMethodArguments: `Một lời gọi method chỉ truyền được tối đa 255 đối số, và chương trình chỉ dùng được tối đa 256
+hằng số khác nhau trước tên các method.`,
+		UnsupportedStatement:    internalVietnamese,
+		UnsupportedAssignTarget: `Chỉ có thể gán cho biến, phần tử (a[i]) hoặc thuộc tính (p.x).`,
+		DuplicateLoopLabel:      `Vòng lặp lồng bên trong không được dùng lại nhãn của vòng ngoài, nếu không break và continue sẽ mơ hồ.`,
+		UnknownLoopLabel: `break nhãn và continue nhãn phải chỉ tới một vòng lặp đang bao quanh chúng:
+
+    outer: for i = 0; i < 3; i = i + 1 {
+        for j = 0; j < 3; j = j + 1 {
+            break outer
+        }
+    }`,
+		OutsideLoop: `break và continue chỉ có nghĩa bên trong for, while hoặc until. Muốn thoát khỏi hàm sớm, hãy dùng return.`,
+		LeaveValueExpression: `Một if, match hoặc block dùng như giá trị phải tạo ra giá trị đó, nên break, continue và return
+không thể nhảy ra khỏi nó. Hãy dùng câu lệnh if thay cho biểu thức if.`,
+		ReturnOutsideFunction: `return kết thúc hàm chứa nó; ở cấp ngoài cùng của file không có hàm nào để kết thúc.`,
+		NestedFunction:        `Hàm được khai báo ở cấp ngoài cùng của file, không nằm trong hàm, vòng lặp hay block khác.`,
+		NestedRecord:          `Kiểu record được khai báo ở cấp ngoài cùng của file để mọi hàm đều dùng được.`,
+		NestedMethod:          `Method (func Type.name(...)) được khai báo ở cấp ngoài cùng của file, sau record của nó.`,
+		NestedEnum:            `Enum được khai báo ở cấp ngoài cùng của file để mọi hàm đều dùng được.`,
+		DuplicateType:         `Record và enum dùng chung một không gian tên: mỗi tên kiểu chỉ được khai báo một lần.`,
+		DuplicateField:        `Mỗi trường của record cần một tên riêng: record Point(x, y), không phải record Point(x, x).`,
+		MethodOnNonRecord: `func Type.name(...) thêm method cho một record. Hãy khai báo record trước các method của nó:
+
+    record Point(x, y)
+    func Point.length() { return (self.x ** 2 + self.y ** 2) ** 0.5 }`,
+		DuplicateMethod:    `Mỗi record chỉ có một method với cùng một tên.`,
+		MethodShadowsField: `p.name sẽ không rõ là trường hay method. Hãy đổi tên một trong hai.`,
+		UnknownSpecialMethod: `Các tên nằm giữa hai dấu gạch dưới kép được dành cho toán tử: __add__ __sub__ __mul__ __div__
+__mod__ __pow__ (và các dạng đảo __radd__ ... __rpow__), __eq__ __lt__ __index__
+__neg__ và __str__.`,
+		SpecialMethodArity: `Method toán tử có dạng cố định: toán tử hai ngôi như __add__(other) nhận một tham số,
+__neg__() và __str__() không nhận tham số nào. self luôn có sẵn mà không cần liệt kê.`,
+		SelfParameter:         `Bên trong func Point.move(dx), record đã có sẵn dưới tên self; không cần liệt kê nó.`,
+		DeferOutsideFunction:  `defer chạy một lời gọi khi hàm chứa nó kết thúc, nên chỉ dùng được bên trong hàm.`,
+		CannotDefer:           `Chỉ có thể defer lời gọi hàm hoặc method.`,
+		DuplicateVariant:      `Mỗi variant của enum cần một tên riêng.`,
+		DuplicateVariantValue: `Các giá trị đi kèm một variant cần tên khác nhau: Ok(value), Pair(left, right).`,
+		MixedMatchEnums:       `Một giá trị chỉ thuộc một enum, nên các mẫu variant trong cùng một match phải thuộc enum đó.`,
+		UnreachableArm:        `Các nhánh được thử từ trên xuống, nên nhánh sau cho cùng variant sẽ không bao giờ chạy.`,
+		BindingInMultiPattern: `Trong Result.Ok(v), Result.Err(v) { ... } tên v sẽ đến từ các variant khác nhau.
+Hãy cho mỗi variant một nhánh riêng khi cần dùng giá trị của nó.`,
+		NonExhaustiveMatch: `Khi match kiểm tra các variant của một enum, mọi variant đều phải được xử lý, nhờ vậy khi thêm
+variant mới sẽ thấy ngay các match cần sửa. Hãy thêm các nhánh còn thiếu hoặc một nhánh else.`,
+		UnknownVariant: `Tên sau dấu chấm phải là một trong các variant được liệt kê khi khai báo enum.`,
+		PatternArity: `Mẫu như Result.Ok(value) gán một tên cho mỗi giá trị mà variant mang theo.
+Viết variant không có ngoặc để khớp nó bất kể giá trị.`,
+		PatternBindsNonName: `Trong mẫu variant, mỗi giá trị được đặt một tên: Result.Ok(value), không phải Result.Ok(1).
+Hãy so sánh giá trị bên trong nhánh.`,
+		NonExhaustiveIf: `Một if dùng làm giá trị so sánh một biến với các variant của enum
+(x = if c == Color.Red { 1 } elif c == Color.Green { 2 }) sẽ cho nothing với các variant bị bỏ sót,
+nên cũng như match, nó phải xét hết các variant. Hãy thêm các nhánh còn thiếu hoặc nhánh else.`,
+		StackUnderflow:        internalVietnamese,
+		GlobalSlotOutOfBounds: internalVietnamese,
+		LocalSlotOutOfBounds:  internalVietnamese,
+		ScopeDepthOutOfBounds: internalVietnamese,
+		UnknownOpcode:         internalVietnamese,
+		WrongArraySize:        internalVietnamese,
+		NotAFunctionObject:    internalVietnamese,
+		NotAFormatSpec:        internalVietnamese,
+		DivisionByZero: `Vế phải của /, % hoặc phép chia decimal bằng không. Hãy kiểm tra số chia trước khi chia:
+
+    if count != 0 { average = total / count }`,
+		UnsupportedOperator: `Không thể áp dụng toán tử này cho các giá trị này.`,
+		NonNumericOperands: `Phép toán số học chỉ dùng cho số (dấu + còn nối chuỗi và tập hợp). Một toán hạng có kiểu khác,
+thường là nothing do thiếu giá trị. Hãy chuyển văn bản bằng number(x) trước.`,
+		ExponentTooLarge: `Kết quả của ** trên số decimal, hay một số decimal viết với số mũ như 1e99999, quá lớn để tính
+chính xác. Số mũ chỉ được từ -65536 đến 65536.`,
+		DecimalExponent: `Số decimal chỉ nâng được lên lũy thừa nguyên, vì các lũy thừa khác không chính xác.
+Hãy chuyển bằng number(x) để dùng số mũ thập phân.`,
+		InexactDecimal: `Số decimal là chính xác; số thường như 0.1 được lưu ở dạng nhị phân nên bị lệch một chút.
+Pun không âm thầm trộn hai loại này. Hãy viết 0.1d, hoặc chuyển đổi rõ ràng một bên.`,
+		UnsupportedComparison: internalVietnamese,
+		CannotCompare: `So sánh thứ tự (<, >, <=, >=) dùng được giữa hai số, hai chuỗi hoặc hai giá trị cùng loại tập hợp.
+Hãy chuyển đổi một bên trước, ví dụ number(text).`,
+		UnsupportedComparisonType: `Không thể so sánh thứ tự các giá trị kiểu này bằng <, >, <= hoặc >=.`,
+		StringEquality:            `Ở đây chuỗi chỉ so sánh được bằng == và !=.`,
+		EqualityOnly:              `Các giá trị này chỉ kiểm tra bằng nhau được, không có thứ tự.`,
+		RecordCompare: `Record được so sánh thứ tự bằng method __lt__. Hãy định nghĩa nó để dùng <, >, <= và >=:
+
+    func Money.__lt__(other) { return self.cents < other.cents }`,
+		RecordOperator: `Toán tử trên record gọi các method đặc biệt: + gọi __add__, - gọi __sub__, v.v.
+Hãy định nghĩa method được nêu trong thông báo:
+
+    func Vec.__add__(other) { return Vec(self.x + other.x, self.y + other.y) }`,
+		TupleOperator:      `Tuple hỗ trợ + (nối) và so sánh, nhưng không hỗ trợ toán tử này.`,
+		LogicalOperands:    `&& và || kết hợp true và false. Hãy so sánh giá trị trước: count > 0 && ready.`,
+		UnsupportedLogical: internalVietnamese,
+		NotNonBoolean:      `! đảo true hoặc false. Để kiểm tra giá trị bị thiếu, hãy so sánh với nothing: x == nothing.`,
+		NegateNonNumber:    `Dấu trừ một ngôi chỉ dùng cho số, bigint và decimal.`,
+		NegateRecord:       `-r trên record gọi method __neg__. Hãy định nghĩa func Type.__neg__() để hỗ trợ.`,
+		BitwiseIntegers:    `&, |, ^, << và >> chỉ dùng cho số nguyên. Hãy làm tròn trước bằng round(x, 0).`,
+		BitwiseInteger:     `~ chỉ dùng cho số nguyên.`,
+		InvalidShift:       `Vế phải của << và >> phải là số nguyên không âm.`,
+		UnsupportedBitwise: internalVietnamese,
+		IndexNotNumber:     `Mảng, chuỗi và tuple được truy cập bằng số nguyên bắt đầu từ 0: items[0].`,
+		NotIndexable:       `Chỉ có mảng, chuỗi, tuple và record có __index__ mới truy cập được bằng [ ].`,
+		IndexOutOfBounds:   `Chỉ số hợp lệ đi từ 0 đến length - 1. Hãy kiểm tra chỉ số với items.length trước.`,
+		RecordNotIndexable: `r[i] trên record gọi method __index__. Hãy định nghĩa func Type.__index__(i) để hỗ trợ.`,
+		ImmutableElement:   `Chuỗi và tuple không thể thay đổi sau khi được tạo. Hãy tạo giá trị mới, hoặc dùng mảng.`,
+		FrozenArray:        `freeze(a) và #[...] làm mảng chỉ đọc. Hãy dùng copy(a) để có bản sao sửa được.`,
+		Unhashable: `Phần tử của set không được thay đổi, nên không thể đưa mảng còn sửa được (hoặc NaN) vào set.
+Hãy dùng tuple hoặc mảng đã đóng băng: #{ (1, 2), #[3, 4] }.`,
+		UnsupportedSetOperator: `Set hỗ trợ | (hợp), & (giao), - (hiệu) và ^ (hiệu đối xứng).`,
+		SetOperands:            `Toán tử set kết hợp hai set. Hãy chuyển vế còn lại bằng set(x) trước.`,
+		SetFrom:                `set(x) nhận mảng, tuple, chuỗi hoặc một set khác.`,
+		NotCallable:            `Chỉ có hàm mới gọi được bằng ( ). Tên này có lẽ đang chứa một giá trị khác.`,
+		ArgumentCount:          `Hàm phải được gọi với số đối số đúng bằng số tham số của nó.`,
+		UndefinedBuiltin:       internalVietnamese,
+		BuiltinArgumentCount:   `Hàm có sẵn này nhận đúng một đối số.`,
+		SetArgumentCount:       `set() tạo set rỗng và set(x) tạo set từ các phần tử của x.`,
+		RoundArgumentCount: `round(x, places) làm tròn đến số chữ số thập phân; đối số thứ ba chọn cách làm tròn:
+round(x, 2, "half_up").`,
+		RecordArgumentCount: `Tạo record cần một đối số cho mỗi trường, theo thứ tự khi khai báo.`,
+		MethodArgumentCount: `Các method có sẵn nhận số đối số cố định.`,
+		NoProperty:          `Giá trị không có trường hay thuộc tính nào với tên này. Hãy kiểm tra chính tả và kiểu của giá trị.`,
+		NoMethod:            `Giá trị không có method nào với tên này. Hãy kiểm tra chính tả và kiểu của giá trị.`,
+		VariantNeedsValues:  `Variant được khai báo có giá trị, như Ok(value), được tạo bằng cách gọi nó: Result.Ok(42).`,
+		VariantHasNoValues:  `Variant không có giá trị đi kèm đã là một giá trị: viết Color.Red, không phải Color.Red().`,
+		VariantArity:        `Variant được tạo với một đối số cho mỗi giá trị khi khai báo.`,
+		StrResult:           `print và ${...} dùng __str__ để chuyển record thành văn bản, nên nó phải trả về chuỗi.`,
+		BoolResult:          `__eq__ và __lt__ trả lời câu hỏi có/không nên phải trả về true hoặc false.`,
+		ConvertType:         `number(x), decimal(x) và bigint(x) nhận số và chuỗi chứa một số.`,
+		ConvertString:       `Văn bản không chứa số hợp lệ. Hãy bỏ khoảng trắng và kiểm tra dữ liệu nhập trước.`,
+		ConvertValue: `Giá trị này không có giá trị tương đương chính xác trong kiểu đích, ví dụ số có phần lẻ sang
+bigint, hoặc vô cực sang decimal.`,
+		UnknownRounding: `Cách làm tròn được chọn bằng tên: half_even (mặc định, làm tròn kiểu ngân hàng), half_up,
+half_down, up, down, ceiling và floor.`,
+		RoundPlaces:            `Đối số thứ hai của round là số chữ số thập phân cần giữ.`,
+		RoundType:              `round dùng cho số và decimal.`,
+		SortType:               `sort nhận một mảng có các phần tử so sánh được bằng <.`,
+		ContainsType:           `s.contains(part) tìm văn bản bên trong chuỗi.`,
+		ExpectedSet:            `Các method của set như union, intersection và difference nhận một set khác. Hãy chuyển bằng set(x) trước.`,
+		InvalidFormatSpec:      `Định dạng đứng sau dấu : trong ${...}: ${price:.2f}, ${n:,}, ${ratio:.1%}.`,
+		FormatMissingPrecision: `Sau dấu chấm là số chữ số: ${x:.2f}.`,
+		UnknownFormatType: `Các kiểu định dạng gồm f (số thập phân), e và g (khoa học), % (phần trăm), d (số nguyên), x, o và b
+(hex, bát phân và nhị phân) và s (văn bản).`,
+		FormatNeedsNumber:     `Không thể áp dụng định dạng số cho văn bản hay giá trị khác.`,
+		FormatTypeNeedsNumber: `Kiểu định dạng này chỉ dùng cho số.`,
+		FormatNeedsWhole:      `d, x, o và b in số nguyên. Hãy làm tròn giá trị trước, hoặc dùng f cho số lẻ.`,
+		UnsupportedFormatType: internalVietnamese,
+		NotIterable: `for x in xs chạy thân vòng lặp một lần cho mỗi phần tử của array, tuple hoặc set. Để lặp qua một
+dãy số hãy dùng for i = 0; i < n; i = i + 1.`,
+		ConditionNotBoolean: `Điều kiện của if, elif, while, until, ?: và vòng lặp phải là true hoặc false. Pun không coi 0, "" hay
+nothing là false: hãy so sánh rõ ràng, ví dụ if count > 0 hoặc if x != nothing.`,
+		CustomEqualityUnhashable: `Set tìm các phần tử bằng nhau qua các field của chúng. Kiểu record tự định nghĩa __eq__ quyết định
+phép so sánh bằng code mà set không dùng được, nên set có thể giữ hai giá trị mà == cho là bằng nhau.
+Hãy đưa vào set một field xác định giá trị đó: #{p.id}.`,
+		InternalError: internalVietnamese,
+	},
+}
+
+// Lỗi chỉ xảy ra khi bytecode không khớp với vm, tức là lỗi của chính Pun
+const (
+	internalEnglish = `This error should never happen in a correct program: it means the compiler produced
+bytecode the virtual machine cannot run. It is a bug in Pun. Please report it together with the
+smallest program that triggers it.`
+	internalVietnamese = `Lỗi này không bao giờ xảy ra với chương trình đúng: nó có nghĩa là compiler đã tạo ra
+bytecode mà máy ảo không chạy được. Đây là lỗi của Pun. Hãy báo lỗi kèm theo chương trình nhỏ
+nhất gây ra nó.`
+)
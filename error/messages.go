@@ -0,0 +1,391 @@
+package customError
+
+// messages holds the message template of every code in each language. The arguments are the
+// same in every language; a translation that needs them in another order uses %[n]s.
+var messages = map[string]map[Code]string{
+	English: {
+		// Lỗi của lexer
+		InvalidUTF8:                "invalid UTF-8 encoding",
+		UnterminatedString:         "unterminated string literal",
+		UnterminatedLineString:     "unterminated string literal (use \"\"\" for multi-line strings)",
+		InvalidHexEscape:           "invalid escape sequence: \\x must be followed by 2 hex digits",
+		InvalidUnicodeEscape:       "invalid escape sequence: expected \\u{XXXX}",
+		InvalidUnicodeEscapeDigits: "invalid escape sequence: expected \\u{XXXX} with 1 to 6 hex digits",
+		InvalidCodePoint:           "invalid escape sequence: U+%X is not a valid Unicode code point",
+		UnknownEscape:              "invalid escape sequence '\\%c'",
+		UnexpectedDotInNumber:      "unexpected '.' in number literal",
+		MissingFractionDigits:      "expected digit after decimal point",
+		MissingExponentDigits:      "exponent has no digits",
+		InvalidNumberCharacter:     "invalid character %q in number literal",
+		FractionalBigInt:           "bigint literal must be an integer",
+		InvalidDigit:               "invalid digit %q in %s literal",
+		NoDigits:                   "%s literal has no digits",
+		MisplacedUnderscore:        "'_' must separate successive digits",
+		ReadError:                  "cannot read the source: %s",
+		UnterminatedComment:        "unterminated block comment",
+
+		// Lỗi cú pháp của parser
+		UnexpectedToken:              "Unexpected token: %s",
+		InvalidNumber:                "Invalid number: %s",
+		UnexpectedKeyword:            "Unexpected keyword in expression: %s",
+		InvalidWalrusValue:           "Invalid value in ':=' assignment",
+		MissingExpressionBeforeBrace: "Expected an expression before '{'",
+		TrailingComma:                "Trailing comma in array is not allowed",
+		MissingCloseParen:            "Missing closing ')'",
+		EmptyInterpolation:           "Empty expression in string interpolation",
+		UnclosedInterpolation:        "Expected '}' to close string interpolation",
+		ExpectedToken:                "Expected %s, got %s instead",
+		InvalidStatement:             "Invalid statement",
+		UnexpectedStatement:          "Unexpected statement: %s",
+		InvalidAssignTarget:          "Invalid assignment target",
+		InvalidAssignValue:           "Invalid value in assignment",
+		InvalidCondition:             "Invalid condition in '%s' statement",
+		InvalidForInit:               "Invalid init statement in 'for'",
+		InvalidForUpdate:             "Invalid update statement in 'for'",
+		LabelWithoutLoop:             "label '%s' must be followed by a loop",
+		OptionalDefer:                "cannot defer an optional method call",
+		DeferNotCall:                 "expression in defer must be a function call",
+		EmptyEnum:                    "enum %s has no variants",
+		InvalidMatchSubject:          "Invalid subject in 'match' statement",
+		DuplicateElseArm:             "match has more than one else arm",
+		ElseArmNotLast:               "the else arm must be the last arm of match",
+		InvalidMatchPattern:          "Invalid pattern in 'match' statement",
+
+		// Lỗi của compiler
+		OperandTooLarge:         "operand %d too large for opcode %d",
+		BuiltinRedeclared:       "Cannot redeclare built-in name %q",
+		UndefinedVariable:       "undefined variable",
+		InvalidDecimal:          "invalid decimal %q",
+		TooManyTemplateParts:    "too many parts in interpolated string",
+		TooManyElements:         "too many elements in %s literal",
+		TooManyMethodArguments:  "too many constants or arguments for method call",
+		UnsupportedStatement:    "Unsupported statement type: %T",
+		UnsupportedAssignTarget: "Unsupported assignment target: %T",
+		DuplicateLoopLabel:      "duplicate loop label '%s'",
+		UnknownLoopLabel:        "unknown loop label '%s'",
+		OutsideLoop:             "%s statement outside of a loop",
+		LeaveValueExpression:    "%s cannot leave an if, match or block expression",
+		ReturnOutsideFunction:   "return statement outside of a function",
+		NestedFunction:          "Function definitions are only allowed at the top-level (global scope)",
+		NestedRecord:            "Record declarations are only allowed at the top-level (global scope)",
+		NestedMethod:            "Method definitions are only allowed at the top-level (global scope)",
+		NestedEnum:              "Enum declarations are only allowed at the top-level (global scope)",
+		DuplicateType:           "type %s is already declared",
+		DuplicateField:          "duplicate field %s in record %s",
+		MethodOnNonRecord:       "methods can only be defined on a declared record type, %s is not one",
+		DuplicateMethod:         "method %s.%s is already defined",
+		MethodShadowsField:      "method %s.%s has the same name as a field",
+		UnknownSpecialMethod:    "unknown special method %s",
+		SpecialMethodArity:      "special method %s takes %d parameters besides self, got %d",
+		SelfParameter:           "self is declared implicitly and cannot be a parameter",
+		DeferOutsideFunction:    "defer statement outside of a function",
+		CannotDefer:             "cannot defer %T",
+		DuplicateVariant:        "duplicate variant %s in enum %s",
+		DuplicateVariantValue:   "duplicate value %s in %s.%s",
+		MixedMatchEnums:         "match mixes variants of %s and %s",
+		UnreachableArm:          "%s.%s is already matched by an earlier arm",
+		BindingInMultiPattern:   "cannot bind values in a match arm with several patterns",
+		NonExhaustiveMatch:      "match on %s is not exhaustive: missing %s (add the arms or an else arm)",
+		UnknownVariant:          "enum %s has no variant %s",
+		PatternArity:            "%s.%s has %d values, the pattern binds %d",
+		PatternBindsNonName:     "the values of %s.%s can only be bound to names",
+		NonExhaustiveIf:         "if on the variants of %s is not exhaustive: missing %s (add elif branches or an else branch)",
+
+		// Lỗi khi chạy (vm)
+		StackUnderflow:            "stack underflow",
+		GlobalSlotOutOfBounds:     "global variable slot %d out of bounds",
+		LocalSlotOutOfBounds:      "local variable slot %d out of bounds",
+		ScopeDepthOutOfBounds:     "local scope depth %d out of bounds",
+		UnknownOpcode:             "unknown opcode: %d",
+		WrongArraySize:            "wrong array size",
+		NotAFunctionObject:        "expected function object, got %T",
+		NotAFormatSpec:            "expected format spec, got %T",
+		DivisionByZero:            "division by zero",
+		UnsupportedOperator:       "unsupported operator: %s",
+		NonNumericOperands:        "operations only supported between numbers, got %s and %s",
+		ExponentTooLarge:          "exponent too large",
+		DecimalExponent:           "decimal exponent must be an integer, got %s",
+		InexactDecimal:            "cannot mix decimal with inexact number %s; convert with decimal(x) or number(x)",
+		UnsupportedComparison:     "unsupported comparison operator: %s",
+		CannotCompare:             "cannot compare %s with %s",
+		UnsupportedComparisonType: "unsupported type for comparison: %s",
+		StringEquality:            "string only supports == and != operators",
+		EqualityOnly:              "%s only supports == and != with %s",
+		RecordCompare:             "cannot compare %s with %s (define __lt__)",
+		RecordOperator:            "unsupported operator %s between %s and %s (define %s)",
+		TupleOperator:             "unsupported operator %s between %s and %s",
+		LogicalOperands:           "logical operators require boolean operands",
+		UnsupportedLogical:        "unsupported logical operator: %s",
+		NotNonBoolean:             "cannot logical NOT non-boolean type: %s",
+		NegateNonNumber:           "cannot negate non-number type: %s",
+		NegateRecord:              "cannot negate %s (define __neg__)",
+		BitwiseIntegers:           "bitwise operators require integers, got %s and %s",
+		BitwiseInteger:            "bitwise operators require integers, got %s",
+		InvalidShift:              "invalid shift count %s",
+		UnsupportedBitwise:        "unsupported bitwise operator: %s",
+		IndexNotNumber:            "expected index to be a number, got %s instead",
+		NotIndexable:              "expected array type, got %s",
+		IndexOutOfBounds:          "index %d out of bounds (array size: %d)",
+		RecordNotIndexable:        "%s cannot be indexed (define __index__)",
+		ImmutableElement:          "cannot assign to an element of a %[1]s (%[1]s is immutable)",
+		FrozenArray:               "cannot modify a frozen array",
+		Unhashable:                "unhashable type: %s",
+		UnsupportedSetOperator:    "unsupported set operator: %s",
+		SetOperands:               "operator %s needs two sets, got %s and %s",
+		SetFrom:                   "cannot make a set from %s",
+		NotCallable:               "not callable: expected function, got %T (value: %v)",
+		ArgumentCount:             "expected %d arguments, got %d",
+		UndefinedBuiltin:          "undefined builtin function",
+		BuiltinArgumentCount:      "%s expects 1 argument, got %d",
+		SetArgumentCount:          "set expects 0 or 1 argument, got %d",
+		RoundArgumentCount:        "round expects 2 or 3 arguments, got %d",
+		RecordArgumentCount:       "%s expects %d arguments, got %d",
+		MethodArgumentCount:       "method '%s' expects %d arguments, got %d",
+		NoProperty:                "%s has no property '%s'",
+		NoMethod:                  "%s has no method '%s'",
+		VariantNeedsValues:        "%[1]s.%[2]s has associated values, create it with %[1]s.%[2]s(%[3]s)",
+		VariantHasNoValues:        "%s.%s has no values, use it without parentheses",
+		VariantArity:              "%s.%s expects %d values, got %d",
+		StrResult:                 "__str__ must return a string, got %s",
+		BoolResult:                "%s must return a boolean, got %s",
+		ConvertType:               "cannot convert %s to %s",
+		ConvertString:             "cannot convert %q to %s",
+		ConvertValue:              "cannot convert %s %s to %s",
+		UnknownRounding:           "unknown rounding mode %s (expected half_even, half_up, half_down, up, down, ceiling or floor)",
+		RoundPlaces:               "round: places must be a whole number from 0 to 1000, got %s",
+		RoundType:                 "round expects a number, got %s",
+		SortType:                  "cannot sort %s",
+		ContainsType:              "contains expects a string, got %s",
+		ExpectedSet:               "expected a set, got %s",
+		InvalidFormatSpec:         "invalid format spec %q",
+		FormatMissingPrecision:    "invalid format spec %q: missing precision after '.'",
+		UnknownFormatType:         "invalid format spec %q: unknown format type '%c'",
+		FormatNeedsNumber:         "format spec %q is only valid for numbers, got %s",
+		FormatTypeNeedsNumber:     "format type '%c' requires a number, got %s",
+		FormatNeedsWhole:          "format type '%c' requires a whole number, got %v",
+		UnsupportedFormatType:     "unsupported format type '%c'",
+		NotIterable:               "cannot iterate over %s: for-in needs an array, tuple or set",
+		ConditionNotBoolean:       "condition must be a boolean, got %s",
+		CustomEqualityUnhashable:  "%s defines __eq__, so it cannot be a set element",
+		InternalError:             "%s",
+	},
+	Vietnamese: {
+		// Lỗi của lexer
+		InvalidUTF8:                "mã hoá UTF-8 không hợp lệ",
+		UnterminatedString:         "chuỗi chưa được đóng",
+		UnterminatedLineString:     "chuỗi chưa được đóng trên cùng dòng (dùng \"\"\" cho chuỗi nhiều dòng)",
+		InvalidHexEscape:           "chuỗi escape không hợp lệ: sau \\x phải có 2 chữ số hex",
+		InvalidUnicodeEscape:       "chuỗi escape không hợp lệ: cần dạng \\u{XXXX}",
+		InvalidUnicodeEscapeDigits: "chuỗi escape không hợp lệ: cần \\u{XXXX} với 1 đến 6 chữ số hex",
+		InvalidCodePoint:           "chuỗi escape không hợp lệ: U+%X không phải là mã Unicode hợp lệ",
+		UnknownEscape:              "chuỗi escape '\\%c' không hợp lệ",
+		UnexpectedDotInNumber:      "dấu '.' không hợp lệ trong số",
+		MissingFractionDigits:      "cần chữ số sau dấu thập phân",
+		MissingExponentDigits:      "phần mũ không có chữ số nào",
+		InvalidNumberCharacter:     "ký tự %q không hợp lệ trong số",
+		FractionalBigInt:           "số bigint phải là số nguyên",
+		InvalidDigit:               "chữ số %q không hợp lệ trong số %s",
+		NoDigits:                   "số %s không có chữ số nào",
+		MisplacedUnderscore:        "'_' chỉ được đặt giữa hai chữ số",
+		ReadError:                  "không đọc được mã nguồn: %s",
+		UnterminatedComment:        "block comment chưa được đóng",
+
+		// Lỗi cú pháp của parser
+		UnexpectedToken:              "Token không mong đợi: %s",
+		InvalidNumber:                "Số không hợp lệ: %s",
+		UnexpectedKeyword:            "Từ khoá %s không được dùng trong biểu thức",
+		InvalidWalrusValue:           "Giá trị không hợp lệ trong phép gán ':='",
+		MissingExpressionBeforeBrace: "Cần một biểu thức trước '{'",
+		TrailingComma:                "Không được có dấu phẩy thừa ở cuối mảng",
+		MissingCloseParen:            "Thiếu dấu ')' đóng",
+		EmptyInterpolation:           "Biểu thức trong ${} bị rỗng",
+		UnclosedInterpolation:        "Cần '}' để đóng ${...} trong chuỗi",
+		ExpectedToken:                "Cần %s nhưng gặp %s",
+		InvalidStatement:             "Câu lệnh không hợp lệ",
+		UnexpectedStatement:          "Câu lệnh không mong đợi: %s",
+		InvalidAssignTarget:          "Vế trái của phép gán không hợp lệ",
+		InvalidAssignValue:           "Giá trị không hợp lệ trong phép gán",
+		InvalidCondition:             "Điều kiện của lệnh '%s' không hợp lệ",
+		InvalidForInit:               "Lệnh khởi tạo của 'for' không hợp lệ",
+		InvalidForUpdate:             "Lệnh cập nhật của 'for' không hợp lệ",
+		LabelWithoutLoop:             "sau nhãn '%s' phải là một vòng lặp",
+		OptionalDefer:                "không thể defer một lời gọi method dạng ?.",
+		DeferNotCall:                 "biểu thức sau defer phải là một lời gọi hàm",
+		EmptyEnum:                    "enum %s không có variant nào",
+		InvalidMatchSubject:          "Giá trị cần so khớp của 'match' không hợp lệ",
+		DuplicateElseArm:             "match có nhiều hơn một nhánh else",
+		ElseArmNotLast:               "nhánh else phải là nhánh cuối cùng của match",
+		InvalidMatchPattern:          "Mẫu trong 'match' không hợp lệ",
+
+		// Lỗi của compiler
+		OperandTooLarge:         "toán hạng %d quá lớn cho opcode %d",
+		BuiltinRedeclared:       "Không thể khai báo lại tên có sẵn %q",
+		UndefinedVariable:       "biến chưa được định nghĩa",
+		InvalidDecimal:          "số decimal %q không hợp lệ",
+		TooManyTemplateParts:    "chuỗi nội suy có quá nhiều phần",
+		TooManyElements:         "literal %s có quá nhiều phần tử",
+		TooManyMethodArguments:  "lời gọi method có quá nhiều hằng số hoặc đối số",
+		UnsupportedStatement:    "Loại câu lệnh không được hỗ trợ: %T",
+		UnsupportedAssignTarget: "Không thể gán cho %T",
+		DuplicateLoopLabel:      "nhãn vòng lặp '%s' bị trùng",
+		UnknownLoopLabel:        "không có vòng lặp nào mang nhãn '%s'",
+		OutsideLoop:             "lệnh %s nằm ngoài vòng lặp",
+		LeaveValueExpression:    "%s không thể thoát ra khỏi biểu thức if, match hoặc block",
+		ReturnOutsideFunction:   "lệnh return nằm ngoài hàm",
+		NestedFunction:          "Chỉ được định nghĩa hàm ở cấp ngoài cùng (global scope)",
+		NestedRecord:            "Chỉ được khai báo record ở cấp ngoài cùng (global scope)",
+		NestedMethod:            "Chỉ được định nghĩa method ở cấp ngoài cùng (global scope)",
+		NestedEnum:              "Chỉ được khai báo enum ở cấp ngoài cùng (global scope)",
+		DuplicateType:           "kiểu %s đã được khai báo",
+		DuplicateField:          "trường %s bị trùng trong record %s",
+		MethodOnNonRecord:       "chỉ có thể định nghĩa method cho record đã khai báo, %s không phải là record",
+		DuplicateMethod:         "method %s.%s đã được định nghĩa",
+		MethodShadowsField:      "method %s.%s trùng tên với một trường",
+		UnknownSpecialMethod:    "không có method đặc biệt nào tên %s",
+		SpecialMethodArity:      "method đặc biệt %s nhận %d tham số ngoài self, nhưng có %d",
+		SelfParameter:           "self được khai báo ngầm, không thể là tham số",
+		DeferOutsideFunction:    "lệnh defer nằm ngoài hàm",
+		CannotDefer:             "không thể defer %T",
+		DuplicateVariant:        "variant %s bị trùng trong enum %s",
+		DuplicateVariantValue:   "giá trị %s bị trùng trong %s.%s",
+		MixedMatchEnums:         "match trộn lẫn variant của %s và %s",
+		UnreachableArm:          "%s.%s đã được so khớp ở một nhánh trước",
+		BindingInMultiPattern:   "không thể gán giá trị trong nhánh match có nhiều mẫu",
+		NonExhaustiveMatch:      "match trên %s chưa xét hết các trường hợp: thiếu %s (thêm các nhánh đó hoặc nhánh else)",
+		UnknownVariant:          "enum %s không có variant %s",
+		PatternArity:            "%s.%s có %d giá trị, nhưng mẫu gán %d",
+		PatternBindsNonName:     "giá trị của %s.%s chỉ có thể được gán cho tên biến",
+		NonExhaustiveIf:         "if trên các variant của %s chưa xét hết các trường hợp: thiếu %s (thêm các nhánh elif hoặc nhánh else)",
+
+		// Lỗi khi chạy (vm)
+		StackUnderflow:            "stack bị rỗng (stack underflow)",
+		GlobalSlotOutOfBounds:     "ô biến global %d nằm ngoài giới hạn",
+		LocalSlotOutOfBounds:      "ô biến local %d nằm ngoài giới hạn",
+		ScopeDepthOutOfBounds:     "độ sâu scope %d nằm ngoài giới hạn",
+		UnknownOpcode:             "opcode không xác định: %d",
+		WrongArraySize:            "kích thước mảng không đúng",
+		NotAFunctionObject:        "cần function object, nhưng có %T",
+		NotAFormatSpec:            "cần format spec, nhưng có %T",
+		DivisionByZero:            "chia cho không",
+		UnsupportedOperator:       "toán tử không được hỗ trợ: %s",
+		NonNumericOperands:        "phép toán chỉ dùng được giữa các số, nhưng có %s và %s",
+		ExponentTooLarge:          "số mũ quá lớn",
+		DecimalExponent:           "số mũ của decimal phải là số nguyên, nhưng có %s",
+		InexactDecimal:            "không thể trộn decimal với số không chính xác %s; hãy chuyển bằng decimal(x) hoặc number(x)",
+		UnsupportedComparison:     "toán tử so sánh không được hỗ trợ: %s",
+		CannotCompare:             "không thể so sánh %s với %s",
+		UnsupportedComparisonType: "kiểu %s không so sánh được",
+		StringEquality:            "string chỉ hỗ trợ == và !=",
+		EqualityOnly:              "%s chỉ hỗ trợ == và != với %s",
+		RecordCompare:             "không thể so sánh %s với %s (hãy định nghĩa __lt__)",
+		RecordOperator:            "không hỗ trợ toán tử %s giữa %s và %s (hãy định nghĩa %s)",
+		TupleOperator:             "không hỗ trợ toán tử %s giữa %s và %s",
+		LogicalOperands:           "toán tử logic cần toán hạng kiểu boolean",
+		UnsupportedLogical:        "toán tử logic không được hỗ trợ: %s",
+		NotNonBoolean:             "không thể dùng ! với giá trị không phải boolean: %s",
+		NegateNonNumber:           "không thể đổi dấu giá trị không phải số: %s",
+		NegateRecord:              "không thể đổi dấu %s (hãy định nghĩa __neg__)",
+		BitwiseIntegers:           "toán tử bit cần số nguyên, nhưng có %s và %s",
+		BitwiseInteger:            "toán tử bit cần số nguyên, nhưng có %s",
+		InvalidShift:              "số bit dịch %s không hợp lệ",
+		UnsupportedBitwise:        "toán tử bit không được hỗ trợ: %s",
+		IndexNotNumber:            "chỉ số phải là số, nhưng có %s",
+		NotIndexable:              "cần kiểu mảng, nhưng có %s",
+		IndexOutOfBounds:          "chỉ số %d nằm ngoài mảng (kích thước: %d)",
+		RecordNotIndexable:        "%s không truy cập bằng chỉ số được (hãy định nghĩa __index__)",
+		ImmutableElement:          "không thể gán cho phần tử của %[1]s (%[1]s là bất biến)",
+		FrozenArray:               "không thể sửa mảng đã đóng băng",
+		Unhashable:                "kiểu %s không dùng làm phần tử set được",
+		UnsupportedSetOperator:    "toán tử không dùng được cho set: %s",
+		SetOperands:               "toán tử %s cần hai set, nhưng có %s và %s",
+		SetFrom:                   "không thể tạo set từ %s",
+		NotCallable:               "không gọi được: cần hàm, nhưng có %T (giá trị: %v)",
+		ArgumentCount:             "cần %d đối số, nhưng có %d",
+		UndefinedBuiltin:          "hàm có sẵn không tồn tại",
+		BuiltinArgumentCount:      "%s cần 1 đối số, nhưng có %d",
+		SetArgumentCount:          "set cần 0 hoặc 1 đối số, nhưng có %d",
+		RoundArgumentCount:        "round cần 2 hoặc 3 đối số, nhưng có %d",
+		RecordArgumentCount:       "%s cần %d đối số, nhưng có %d",
+		MethodArgumentCount:       "method '%s' cần %d đối số, nhưng có %d",
+		NoProperty:                "%s không có thuộc tính '%s'",
+		NoMethod:                  "%s không có method '%s'",
+		VariantNeedsValues:        "%[1]s.%[2]s có giá trị đi kèm, hãy tạo nó bằng %[1]s.%[2]s(%[3]s)",
+		VariantHasNoValues:        "%s.%s không có giá trị đi kèm, hãy dùng nó không có ngoặc",
+		VariantArity:              "%s.%s cần %d giá trị, nhưng có %d",
+		StrResult:                 "__str__ phải trả về chuỗi, nhưng trả về %s",
+		BoolResult:                "%s phải trả về boolean, nhưng trả về %s",
+		ConvertType:               "không thể chuyển %s thành %s",
+		ConvertString:             "không thể chuyển %q thành %s",
+		ConvertValue:              "không thể chuyển %s %s thành %s",
+		UnknownRounding:           "cách làm tròn %s không tồn tại (dùng half_even, half_up, half_down, up, down, ceiling hoặc floor)",
+		RoundPlaces:               "round: số chữ số phải là số nguyên từ 0 đến 1000, nhưng có %s",
+		RoundType:                 "round cần một số, nhưng có %s",
+		SortType:                  "không thể sắp xếp %s",
+		ContainsType:              "contains cần một chuỗi, nhưng có %s",
+		ExpectedSet:               "cần một set, nhưng có %s",
+		InvalidFormatSpec:         "định dạng %q không hợp lệ",
+		FormatMissingPrecision:    "định dạng %q không hợp lệ: thiếu độ chính xác sau '.'",
+		UnknownFormatType:         "định dạng %q không hợp lệ: không có kiểu định dạng '%c'",
+		FormatNeedsNumber:         "định dạng %q chỉ dùng cho số, nhưng có %s",
+		FormatTypeNeedsNumber:     "kiểu định dạng '%c' cần một số, nhưng có %s",
+		FormatNeedsWhole:          "kiểu định dạng '%c' cần số nguyên, nhưng có %v",
+		UnsupportedFormatType:     "kiểu định dạng '%c' không được hỗ trợ",
+		NotIterable:               "không thể duyệt %s: for-in cần một array, tuple hoặc set",
+		ConditionNotBoolean:       "điều kiện phải là boolean, nhưng có %s",
+		CustomEqualityUnhashable:  "%s tự định nghĩa __eq__ nên không dùng làm phần tử set được",
+		InternalError:             "%s",
+	},
+}
+
+// phrases translates the fixed English text around the messages: labels, hints and headings.
+// English needs no entry; the key is used as it is.
+var phrases = map[string]map[string]string{
+	Vietnamese: {
+		"Near: %s":                  "Gần: %s",
+		"Near token: %q (Type: %s)": "Gần token: %q (loại: %s)",
+		"unclosed %q opened here":   "%q mở ở đây chưa được đóng",
+		"did you mean `%s`?":        "có phải ý bạn là `%s`?",
+		"replace `%s` with `%s`":    "thay `%s` bằng `%s`",
+		"note:":                     "ghi chú:",
+		"help:":                     "gợi ý:",
+		"Context: ":                 "Ngữ cảnh: ",
+		"Stack trace:":              "Các lời gọi đang chạy:",
+		"... %d more calls ...":     "... thêm %d lời gọi ...",
+		"PARSER ERRORS:":            "LỖI CÚ PHÁP:",
+		"COMPILATION ERRORS:":       "LỖI BIÊN DỊCH:",
+		"RUNTIME ERRORS:":           "LỖI KHI CHẠY:",
+		"`%s` is built into Pun; choose another name": "`%s` là tên có sẵn của Pun; hãy chọn tên khác",
+
+		// Ngữ cảnh in dưới dấu ^ (chỉ các cụm nhiều từ, để không dịch nhầm tên do người dùng đặt)
+		"arithmetic operation": "phép toán số học",
+		"comparison operation": "phép so sánh",
+		"logical operation":    "phép toán logic",
+		"condition check":      "kiểm tra điều kiện",
+		"bitwise operation":    "phép toán bit",
+		"unary operation":      "phép toán một ngôi",
+		"set operation":        "phép toán set",
+		"tuple operation":      "phép toán tuple",
+		"array get":            "đọc phần tử",
+		"array set":            "gán phần tử",
+		"get property":         "đọc thuộc tính",
+		"call method":          "gọi method",
+		"execute call":         "gọi hàm",
+		"make array":           "tạo mảng",
+		"make set":             "tạo set",
+		"make tuple":           "tạo tuple",
+		"make function":        "tạo hàm",
+		"build string":         "tạo chuỗi",
+		"compile statement":    "biên dịch câu lệnh",
+		"function definition":  "định nghĩa hàm",
+		"method definition":    "định nghĩa method",
+
+		// Tên các loại token trong "Expected %s, got %s instead" (dấu câu và từ khóa giữ nguyên)
+		"identifier":    "tên",
+		"number":        "số",
+		"string":        "chuỗi",
+		"format spec":   "định dạng",
+		"comment":       "chú thích",
+		"end of file":   "cuối file",
+		"unknown token": "token lạ",
+	},
+}
@@ -0,0 +1,192 @@
+package customError
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Mã màu ANSI, chỉ dùng khi in ra terminal
+const (
+	colorReset = "\033[0m"
+	colorBold  = "\033[1m"
+	colorRed   = "\033[1;31m"
+	colorBlue  = "\033[1;34m"
+	colorCyan  = "\033[1;36m"
+	colorGreen = "\033[1;32m"
+)
+
+// Renderer shows diagnostics with the source line they point at:
+//
+//	SyntaxError[P0100]: Unexpected token: *
+//	 --> main.pun:1:9
+//	  |
+//	1 | x = 1 + * 2
+//	  |         ^ Near token: "*" (Type: ARITHMETIC)
+type Renderer struct {
+	File  string
+	Color bool
+	lines []string
+}
+
+// NewRenderer creates a renderer for the source of file; colors are used only if out is a terminal
+func NewRenderer(file, source string, out *os.File) *Renderer {
+	return &Renderer{
+		File:  file,
+		Color: isTerminal(out) && os.Getenv("NO_COLOR") == "",
+		lines: strings.Split(strings.TrimPrefix(source, "\uFEFF"), "\n"),
+	}
+}
+
+func isTerminal(f *os.File) bool {
+	if f == nil {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func (r *Renderer) paint(color, text string) string {
+	if !r.Color || text == "" {
+		return text
+	}
+	return color + text + colorReset
+}
+
+// Render formats one diagnostic
+func (r *Renderer) Render(d Diagnostic) string {
+	e := d.Base()
+	var out strings.Builder
+	header := d.Kind()
+	if e.Code != "" {
+		header += "[" + string(e.Code) + "]"
+	}
+	out.WriteString(r.paint(colorRed, header) + r.paint(colorBold, ": "+e.Message))
+
+	// Nhãn chính ở vị trí lỗi, các nhãn phụ theo sau
+	labels := []Label{{Line: e.Line, Column: e.Column, EndColumn: r.endColumn(e), Message: d.Detail()}}
+	labels = append(labels, e.Labels...)
+	snippet := r.snippet(labels)
+
+	file := r.File
+	if file == "" {
+		file = "<input>"
+	}
+	gutter := strings.Repeat(" ", len(strconv.Itoa(maxLine(labels))))
+	if snippet != "" {
+		out.WriteString(fmt.Sprintf("\n%s%s %s:%d:%d", gutter, r.paint(colorBlue, "-->"), file, e.Line, e.Column))
+		out.WriteString(snippet)
+	} else if d.Detail() != "" {
+		out.WriteString("\n" + Text("Context: ") + d.Detail()) // Không có source (hoặc không có vị trí)
+	}
+
+	for _, note := range e.Notes {
+		out.WriteString(fmt.Sprintf("\n%s %s %s", gutter, r.paint(colorBlue, "="), r.paint(colorBold, Text("note:"))+" "+note))
+	}
+	if e.Help != "" {
+		out.WriteString(fmt.Sprintf("\n%s %s %s", gutter, r.paint(colorBlue, "="), r.paint(colorGreen, Text("help:"))+" "+e.Help))
+	}
+
+	if rt, ok := d.(*RuntimeError); ok && len(rt.Stack) > 0 {
+		out.WriteString("\n" + rt.Trace())
+	}
+	return out.String()
+}
+
+// endColumn returns where the underline of the error stops; a span over several lines
+// is underlined up to the end of its first line
+func (r *Renderer) endColumn(e *PunError) int {
+	switch {
+	case e.EndLine == e.Line:
+		return e.EndColumn
+	case e.EndLine > e.Line && e.Line <= len(r.lines):
+		return utf8.RuneCountInString(strings.TrimRight(r.lines[e.Line-1], "\r")) + 1
+	}
+	return 0
+}
+
+// snippet prints every source line that has a label, with the labels underlined below it.
+// It returns "" if none of the labels is inside the source.
+func (r *Renderer) snippet(labels []Label) string {
+	byLine := map[int][]int{} // Dòng => chỉ số các nhãn trên dòng đó
+	var lines []int
+	for i, label := range labels {
+		if label.Line < 1 || label.Line > len(r.lines) || label.Column < 1 {
+			continue
+		}
+		if _, ok := byLine[label.Line]; !ok {
+			lines = append(lines, label.Line)
+		}
+		byLine[label.Line] = append(byLine[label.Line], i)
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	sort.Ints(lines)
+
+	width := len(strconv.Itoa(lines[len(lines)-1]))
+	gutter := r.paint(colorBlue, strings.Repeat(" ", width+1)+"|")
+
+	var out strings.Builder
+	out.WriteString("\n" + gutter)
+	for n, line := range lines {
+		if n > 0 && line > lines[n-1]+1 {
+			out.WriteString("\n" + r.paint(colorBlue, strings.Repeat(".", width+1))) // Bỏ qua các dòng ở giữa
+		}
+		source := strings.TrimRight(r.lines[line-1], "\r")
+		out.WriteString(fmt.Sprintf("\n%s %s", r.paint(colorBlue, fmt.Sprintf("%*d |", width, line)), source))
+
+		for _, i := range byLine[line] {
+			label := labels[i]
+			mark, color := "-", colorCyan
+			if i == 0 {
+				mark, color = "^", colorRed
+			}
+			length := label.EndColumn - label.Column
+			if length < 1 {
+				length = 1
+			}
+			underline := r.paint(color, strings.Repeat(mark, length))
+			if label.Message != "" {
+				underline += " " + r.paint(color, label.Message)
+			}
+			out.WriteString(fmt.Sprintf("\n%s %s%s", gutter, indent(source, label.Column), underline))
+		}
+	}
+	return out.String()
+}
+
+// indent returns the blank space that puts the next character under column col of source.
+// Tab stays a tab so the caret lines up whatever the tab width of the terminal is.
+func indent(source string, col int) string {
+	var pad strings.Builder
+	n := 1
+	for _, ch := range source {
+		if n >= col {
+			break
+		}
+		if ch == '\t' {
+			pad.WriteRune('\t')
+		} else {
+			pad.WriteRune(' ')
+		}
+		n++
+	}
+	for ; n < col; n++ {
+		pad.WriteRune(' ') // Cột ngay sau cuối dòng (ví dụ thiếu dấu ngoặc ở cuối dòng)
+	}
+	return pad.String()
+}
+
+func maxLine(labels []Label) int {
+	max := 0
+	for _, label := range labels {
+		if label.Line > max {
+			max = label.Line
+		}
+	}
+	return max
+}
@@ -0,0 +1,115 @@
+package customError
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	defer SetLanguage(Language())
+	SetLanguage(English)
+
+	source := "func f() {\n    x = (1 + 2\n\n    print(x)\n}\n"
+	tests := []struct {
+		name       string
+		diagnostic Diagnostic
+		expected   string
+	}{
+		{"labels on two lines", &SyntaxError{
+			PunError: PunError{
+				Code: ExpectedToken, Message: "Expected ')'", Line: 4, Column: 5, EndLine: 4, EndColumn: 10,
+				Labels: []Label{{Line: 2, Column: 9, EndColumn: 10, Message: `unclosed "(" opened here`}},
+				Notes:  []string{"a note"},
+				Help:   "insert ')'",
+			},
+			Context: `Near token: "print"`,
+		}, `SyntaxError[P0109]: Expected ')'
+ --> test.pun:4:5
+  |
+2 |     x = (1 + 2
+  |         - unclosed "(" opened here
+..
+4 |     print(x)
+  |     ^^^^^ Near token: "print"
+  = note: a note
+  = help: insert ')'`},
+		{"span over several lines", &CompilationError{
+			PunError: PunError{Code: UndefinedVariable, Message: "undefined variable: y", Line: 1, Column: 6, EndLine: 2, EndColumn: 3},
+		}, `CompilationError[P0202]: undefined variable: y
+ --> test.pun:1:6
+  |
+1 | func f() {
+  |      ^^^^^`},
+		{"no position", &CompilationError{
+			PunError: PunError{Code: UndefinedVariable, Message: "undefined variable: y"},
+			Context:  "load variable",
+		}, `CompilationError[P0202]: undefined variable: y
+Context: load variable`},
+		{"runtime error", &RuntimeError{
+			PunError: PunError{Code: DivisionByZero, Message: "division by zero", Line: 2, Column: 10, EndLine: 2, EndColumn: 15},
+			Stack:    []StackFrame{{Function: "f", File: "test.pun", Line: 2, Column: 10}},
+		}, `RuntimeError[P0308]: division by zero
+ --> test.pun:2:10
+  |
+2 |     x = (1 + 2
+  |          ^^^^^
+Stack trace:
+  at f (test.pun:2:10)`},
+	}
+	r := NewRenderer("test.pun", source, nil)
+	for _, tt := range tests {
+		if got := r.Render(tt.diagnostic); got != tt.expected {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.name, got, tt.expected)
+		}
+	}
+
+	// Màu chỉ dùng khi được bật (NewRenderer chỉ bật cho terminal)
+	err := &SyntaxError{PunError: PunError{Code: UnexpectedToken, Message: "Unexpected token: *", Line: 1, Column: 1}}
+	if strings.Contains(r.Render(err), "\033[") {
+		t.Errorf("renderer without color prints escape codes")
+	}
+	r.Color = true
+	if !strings.Contains(r.Render(err), colorRed+"^") {
+		t.Errorf("renderer with color does not color the caret:\n%q", r.Render(err))
+	}
+}
+
+func TestRenderTabs(t *testing.T) {
+	r := NewRenderer("", "\tx = y\n", nil)
+	err := &CompilationError{PunError: PunError{Code: UndefinedVariable, Message: "undefined variable: y", Line: 1, Column: 6, EndLine: 1, EndColumn: 7}}
+	// Tab được giữ nguyên để dấu ^ thẳng hàng với mọi độ rộng tab; thiếu tên file thì in <input>
+	expected := "CompilationError[P0202]: undefined variable: y\n --> <input>:1:6\n  |\n1 | \tx = y\n  | \t    ^"
+	if got := r.Render(err); got != expected {
+		t.Errorf("got\n%q\nwant\n%q", got, expected)
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	defer SetLanguage(Language())
+	SetLanguage(English)
+
+	tests := []struct {
+		name       string
+		candidates []string
+		expected   string
+	}{
+		{"cuont", []string{"count", "total"}, "count"},     // Đổi chỗ hai ký tự là một lỗi
+		{"totl", []string{"count", "total"}, "total"},      // Thiếu một ký tự
+		{"x", []string{"y", "count"}, ""},                  // Tên một ký tự: sửa một ký tự là đổi cả tên
+		{"xy", []string{"count"}, ""},                      // Không đủ giống
+		{"count", []string{"count"}, ""},                   // Không gợi ý chính nó
+		{"cnt", []string{"cat", "cut"}, "cat"},             // Cùng khoảng cách: theo thứ tự chữ cái
+		{"độ_dài", []string{"do_dai", "độ_dai"}, "độ_dai"}, // Khoảng cách tính theo ký tự, không theo byte
+	}
+	for _, tt := range tests {
+		if got := Closest(tt.name, tt.candidates); got != tt.expected {
+			t.Errorf("Closest(%q, %q) = %q, want %q", tt.name, tt.candidates, got, tt.expected)
+		}
+	}
+
+	e := &PunError{Line: 3, Column: 7, EndLine: 3, EndColumn: 12}
+	e.SuggestName("cuont", []string{"count"})
+	if e.Help != "did you mean `count`?" || len(e.Fixes) != 1 || e.Fixes[0].Replacement != "count" || e.Fixes[0].Column != 7 {
+		t.Errorf("SuggestName: got help %q, fixes %+v", e.Help, e.Fixes)
+	}
+}
@@ -0,0 +1,292 @@
+package customError
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// Các định dạng lỗi cho công cụ (CI, editor) thay vì cho người đọc
+const (
+	FormatText  = "text"
+	FormatJSON  = "json"
+	FormatSARIF = "sarif"
+)
+
+// Record is one diagnostic in the JSON output
+type Record struct {
+	Severity string        `json:"severity"`
+	Code     string        `json:"code,omitempty"`
+	Kind     string        `json:"kind"`
+	Message  string        `json:"message"`
+	File     string        `json:"file"`
+	Span     RecordSpan    `json:"span"`
+	Context  string        `json:"context,omitempty"`
+	Related  []RecordLabel `json:"related,omitempty"`
+	Notes    []string      `json:"notes,omitempty"`
+	Help     string        `json:"help,omitempty"`
+	Fixes    []RecordFix   `json:"fixes,omitempty"`
+	Stack    []StackFrame  `json:"stack,omitempty"`
+}
+
+// RecordSpan is a range of the source; lines and columns start at 1 and the end column is exclusive
+type RecordSpan struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+	EndLine     int `json:"endLine"`
+	EndColumn   int `json:"endColumn"`
+}
+
+// RecordLabel is a related location with its message
+type RecordLabel struct {
+	Message string     `json:"message"`
+	Span    RecordSpan `json:"span"`
+}
+
+// RecordFix is a suggested edit: replace span with replacement
+type RecordFix struct {
+	Message     string     `json:"message"`
+	Span        RecordSpan `json:"span"`
+	Replacement string     `json:"replacement"`
+}
+
+// spanOf fills in the end of a span that marks a single character
+func spanOf(line, col, endLine, endCol int) RecordSpan {
+	if endLine < line || (endLine == line && endCol <= col) {
+		endLine, endCol = line, col+1
+	}
+	return RecordSpan{StartLine: line, StartColumn: col, EndLine: endLine, EndColumn: endCol}
+}
+
+// NewRecord converts a diagnostic of file to its JSON form
+func NewRecord(file string, d Diagnostic) Record {
+	e := d.Base()
+	record := Record{
+		Severity: "error",
+		Code:     string(e.Code),
+		Kind:     d.Kind(),
+		Message:  e.Message,
+		File:     file,
+		Span:     spanOf(e.Line, e.Column, e.EndLine, e.EndColumn),
+		Context:  d.Detail(),
+		Notes:    e.Notes,
+		Help:     e.Help,
+	}
+	for _, label := range e.Labels {
+		record.Related = append(record.Related, RecordLabel{
+			Message: label.Message,
+			Span:    spanOf(label.Line, label.Column, label.Line, label.EndColumn),
+		})
+	}
+	for _, fix := range e.Fixes {
+		record.Fixes = append(record.Fixes, RecordFix{
+			Message:     fix.Message,
+			Span:        spanOf(fix.Line, fix.Column, fix.EndLine, fix.EndColumn),
+			Replacement: fix.Replacement,
+		})
+	}
+	if rt, ok := d.(*RuntimeError); ok {
+		record.Stack = rt.Stack
+	}
+	return record
+}
+
+// WriteJSON writes the diagnostics of file as a JSON array (an empty array if there are none)
+func WriteJSON(w io.Writer, file string, diagnostics []Diagnostic) error {
+	records := make([]Record, 0, len(diagnostics))
+	for _, d := range diagnostics {
+		records = append(records, NewRecord(file, d))
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(records)
+}
+
+// Các kiểu của SARIF 2.1.0 (chỉ những trường Pun dùng)
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules,omitempty"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifResult struct {
+	RuleID           string          `json:"ruleId"`
+	Level            string          `json:"level"`
+	Message          sarifMessage    `json:"message"`
+	Locations        []sarifLocation `json:"locations"`
+	RelatedLocations []sarifLocation `json:"relatedLocations,omitempty"`
+	Fixes            []sarifFix      `json:"fixes,omitempty"`
+	Stacks           []sarifStack    `json:"stacks,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	ID               *int                   `json:"id,omitempty"`
+	PhysicalLocation sarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+	Message          *sarifMessage          `json:"message,omitempty"`
+}
+
+// sarifLogicalLocation names the function a location is in
+type sarifLogicalLocation struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifact `json:"artifactLocation"`
+	Region           *sarifRegion  `json:"region,omitempty"`
+}
+
+type sarifArtifact struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+	EndLine     int `json:"endLine,omitempty"` // Frame của stack chỉ có điểm bắt đầu
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+type sarifFix struct {
+	Description     sarifMessage          `json:"description"`
+	ArtifactChanges []sarifArtifactChange `json:"artifactChanges"`
+}
+
+type sarifArtifactChange struct {
+	ArtifactLocation sarifArtifact      `json:"artifactLocation"`
+	Replacements     []sarifReplacement `json:"replacements"`
+}
+
+type sarifReplacement struct {
+	DeletedRegion   sarifRegion  `json:"deletedRegion"`
+	InsertedContent sarifMessage `json:"insertedContent"`
+}
+
+// sarifStack is the call stack of a runtime error; the first frame is the innermost call
+type sarifStack struct {
+	Message sarifMessage      `json:"message"`
+	Frames  []sarifStackFrame `json:"frames"`
+}
+
+type sarifStackFrame struct {
+	Location sarifLocation `json:"location"`
+}
+
+// regionOf converts span to a SARIF region; errors without a position have none
+func regionOf(span RecordSpan) *sarifRegion {
+	if span.StartLine < 1 {
+		return nil
+	}
+	region := sarifRegion(span)
+	return &region
+}
+
+// stackOf converts the stack of a runtime error to SARIF
+func stackOf(frames []StackFrame, file string) sarifStack {
+	stack := sarifStack{Message: sarifMessage{Text: strings.TrimSuffix(Text("Stack trace:"), ":")}}
+	for _, frame := range frames {
+		uri := frame.File
+		if uri == "" {
+			uri = file
+		}
+		location := sarifLocation{
+			PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifact{URI: uri}},
+			LogicalLocations: []sarifLogicalLocation{{Name: frame.Function, Kind: "function"}},
+		}
+		if frame.Line > 0 {
+			location.PhysicalLocation.Region = &sarifRegion{StartLine: frame.Line, StartColumn: frame.Column}
+		}
+		stack.Frames = append(stack.Frames, sarifStackFrame{Location: location})
+	}
+	return stack
+}
+
+// WriteSARIF writes the diagnostics of file as a SARIF 2.1.0 log, the format read by code scanning tools
+func WriteSARIF(w io.Writer, file string, diagnostics []Diagnostic) error {
+	run := sarifRun{Tool: sarifTool{Driver: sarifDriver{Name: "pun"}}, Results: []sarifResult{}}
+	artifact := sarifArtifact{URI: file}
+	rules := map[string]bool{}
+
+	for _, d := range diagnostics {
+		record := NewRecord(file, d)
+		ruleID := record.Code
+		if ruleID == "" {
+			ruleID = record.Kind // Lỗi chưa có mã riêng: dùng loại lỗi
+		}
+		if !rules[ruleID] {
+			rules[ruleID] = true
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: ruleID})
+		}
+
+		message := record.Message
+		if record.Help != "" {
+			message += " (" + record.Help + ")"
+		}
+		result := sarifResult{
+			RuleID:  ruleID,
+			Level:   record.Severity,
+			Message: sarifMessage{Text: message},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: artifact, Region: regionOf(record.Span)},
+			}},
+		}
+		for i, related := range record.Related {
+			id := i + 1
+			result.RelatedLocations = append(result.RelatedLocations, sarifLocation{
+				ID:               &id,
+				PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: artifact, Region: regionOf(related.Span)},
+				Message:          &sarifMessage{Text: related.Message},
+			})
+		}
+		for _, fix := range record.Fixes {
+			result.Fixes = append(result.Fixes, sarifFix{
+				Description: sarifMessage{Text: fix.Message},
+				ArtifactChanges: []sarifArtifactChange{{
+					ArtifactLocation: artifact,
+					Replacements: []sarifReplacement{{
+						DeletedRegion:   sarifRegion(fix.Span),
+						InsertedContent: sarifMessage{Text: fix.Replacement},
+					}},
+				}},
+			})
+		}
+		if len(record.Stack) > 0 {
+			result.Stacks = []sarifStack{stackOf(record.Stack, file)}
+		}
+		run.Results = append(run.Results, result)
+	}
+
+	log := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(log)
+}
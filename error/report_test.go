@@ -0,0 +1,116 @@
+package customError
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func runtimeError() *RuntimeError {
+	return &RuntimeError{
+		PunError: PunError{Code: IndexOutOfBounds, Message: "index out of range", Line: 2, Column: 21, EndLine: 2, EndColumn: 26},
+		Context:  "array index",
+		Stack: []StackFrame{
+			{Function: "down", File: "a.pun", Line: 2, Column: 21},
+			{Function: "<main>", File: "a.pun", Line: 5, Column: 1},
+		},
+	}
+}
+
+func syntaxError() *SyntaxError {
+	return &SyntaxError{
+		PunError: PunError{
+			Code: ExpectedToken, Message: "Expected ')'", Line: 1, Column: 9,
+			Labels: []Label{{Line: 1, Column: 6, EndColumn: 7, Message: "unclosed \"(\" opened here"}},
+			Fixes:  []Fix{{Message: "insert ')'", Line: 1, Column: 9, EndLine: 1, EndColumn: 9, Replacement: ")"}},
+		},
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteJSON(&out, "a.pun", []Diagnostic{syntaxError(), runtimeError()}); err != nil {
+		t.Fatal(err)
+	}
+	var records []Record
+	if err := json.Unmarshal(out.Bytes(), &records); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out.String())
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2", len(records))
+	}
+
+	syntax, runtime := records[0], records[1]
+	if syntax.Code != string(ExpectedToken) || syntax.Kind != "SyntaxError" || syntax.File != "a.pun" {
+		t.Errorf("syntax record: %+v", syntax)
+	}
+	// Lỗi chỉ ở một ký tự: span dài một cột
+	if syntax.Span != (RecordSpan{StartLine: 1, StartColumn: 9, EndLine: 1, EndColumn: 10}) {
+		t.Errorf("syntax span: %+v", syntax.Span)
+	}
+	if len(syntax.Related) != 1 || len(syntax.Fixes) != 1 || syntax.Fixes[0].Replacement != ")" {
+		t.Errorf("syntax related/fixes: %+v %+v", syntax.Related, syntax.Fixes)
+	}
+	if len(runtime.Stack) != 2 || runtime.Stack[0].Function != "down" || runtime.Stack[1].Line != 5 {
+		t.Errorf("runtime stack: %+v", runtime.Stack)
+	}
+
+	// Không có lỗi: vẫn là một mảng JSON
+	out.Reset()
+	WriteJSON(&out, "a.pun", nil)
+	if out.String() != "[]\n" {
+		t.Errorf("no diagnostics: got %q, want %q", out.String(), "[]\n")
+	}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteSARIF(&out, "a.pun", []Diagnostic{syntaxError(), runtimeError()}); err != nil {
+		t.Fatal(err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(out.Bytes(), &log); err != nil {
+		t.Fatalf("invalid SARIF: %v\n%s", err, out.String())
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("log: version %q, %d runs", log.Version, len(log.Runs))
+	}
+	run := log.Runs[0]
+	if len(run.Tool.Driver.Rules) != 2 || len(run.Results) != 2 {
+		t.Fatalf("got %d rules and %d results, want 2 and 2", len(run.Tool.Driver.Rules), len(run.Results))
+	}
+
+	syntax := run.Results[0]
+	if syntax.RuleID != string(ExpectedToken) || len(syntax.RelatedLocations) != 1 || len(syntax.Fixes) != 1 {
+		t.Errorf("syntax result: %+v", syntax)
+	}
+	if len(syntax.Stacks) != 0 {
+		t.Errorf("syntax error has a stack: %+v", syntax.Stacks)
+	}
+
+	runtime := run.Results[1]
+	if len(runtime.Stacks) != 1 {
+		t.Fatalf("runtime result has %d stacks, want 1", len(runtime.Stacks))
+	}
+	frames := runtime.Stacks[0].Frames
+	expected := []struct {
+		function string
+		line     int
+	}{{"down", 2}, {"<main>", 5}}
+	if len(frames) != len(expected) {
+		t.Fatalf("got %d frames, want %d", len(frames), len(expected))
+	}
+	for i, frame := range frames {
+		location := frame.Location
+		if location.LogicalLocations[0].Name != expected[i].function ||
+			location.PhysicalLocation.Region.StartLine != expected[i].line ||
+			location.PhysicalLocation.ArtifactLocation.URI != "a.pun" {
+			t.Errorf("frame %d: %+v", i, location)
+		}
+	}
+
+	// Frame chỉ có điểm bắt đầu: không ghi endLine/endColumn bằng 0
+	if bytes.Contains(out.Bytes(), []byte(`"endLine": 0`)) {
+		t.Errorf("SARIF has an empty end line:\n%s", out.String())
+	}
+}
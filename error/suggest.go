@@ -0,0 +1,69 @@
+package customError
+
+// SuggestName adds a "did you mean" hint when one of the candidates looks like a typo of name,
+// with a fix that replaces the span of the error (which must cover exactly name)
+func (e *PunError) SuggestName(name string, candidates []string) {
+	best := Closest(name, candidates)
+	if best == "" {
+		return
+	}
+	e.Help = Text("did you mean `%s`?", best)
+	e.Fixes = append(e.Fixes, Fix{
+		Message:     Text("replace `%s` with `%s`", name, best),
+		Line:        e.Line,
+		Column:      e.Column,
+		EndLine:     e.EndLine,
+		EndColumn:   e.EndColumn,
+		Replacement: best,
+	})
+}
+
+// Suggest returns a "did you mean" hint with the candidate closest to name,
+// or "" if none is close enough to be a likely typo
+func Suggest(name string, candidates []string) string {
+	if best := Closest(name, candidates); best != "" {
+		return Text("did you mean `%s`?", best)
+	}
+	return ""
+}
+
+// Closest returns the candidate closest to name, or "" if none is close enough to be a likely typo
+func Closest(name string, candidates []string) string {
+	best, bestDistance := "", len([]rune(name))/3+1 // Cho phép khoảng 1 lỗi mỗi 3 ký tự
+	for _, candidate := range candidates {
+		if candidate == name {
+			continue
+		}
+		if d := editDistance(name, candidate); d < bestDistance || (d == bestDistance && best != "" && candidate < best) {
+			best, bestDistance = candidate, d
+		}
+	}
+	return best
+}
+
+// editDistance counts the characters to insert, delete or replace to turn a into b;
+// swapping two neighbours (cuont => count) counts as one edit
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
@@ -0,0 +1,102 @@
+// Package format prints Pun programs in one canonical layout (pun fmt): four spaces of indentation
+// per block, one space around binary operators and after commas, the opening brace on the line of
+// its statement, "} elif" and "} else" on the line of the closing brace, one statement per line and
+// at most one blank line between them. Comments stay where they are.
+//
+// Line breaks inside a statement are kept, because a new line can change the meaning of the code:
+// ( and [ at the start of a line do not call or index the line before, and return, break and
+// continue only take a value or a label on their own line.
+package format
+
+import (
+	"io"
+	"pun/ast"
+	"pun/cst"
+	"pun/error"
+)
+
+// Indent is the indentation of one level
+const Indent = "    "
+
+// Source formats the program read from r. A program with syntax errors is not formatted:
+// the result is empty and the errors are returned.
+func Source(file string, r io.Reader) (string, []customError.Diagnostic) {
+	tree, diagnostics := cst.Parse(file, r)
+	if len(diagnostics) > 0 {
+		return "", diagnostics
+	}
+	return Node(tree), nil
+}
+
+// Node prints a concrete syntax tree in canonical form. The tree must come from cst.Parse
+// (tokens with their trivia) and have no syntax errors.
+func Node(tree *cst.Node) string {
+	p := &printer{parents: map[*cst.Node]*cst.Node{}, lineStart: true}
+	p.collect(tree)
+	p.matchBrackets()
+	p.print()
+	return p.out.String()
+}
+
+// item is a token of the tree with the node it belongs to
+type item struct {
+	*cst.Token
+	parent    *cst.Node
+	stmtStart bool // Token đầu tiên của một statement, một nhánh của match hay một variant của enum: ở đầu dòng
+	pair      int  // Vị trí của dấu ngoặc tương ứng (với dấu mở và dấu đóng), -1 nếu không có
+	newlines  int  // Số dòng mới giữa token (hoặc comment) trước đó và token này trong source
+}
+
+// collect lists the tokens of n in source order
+func (p *printer) collect(n *cst.Node) {
+	for _, child := range n.Children {
+		switch c := child.(type) {
+		case *cst.Token:
+			p.items = append(p.items, &item{Token: c, parent: n, pair: -1})
+		case *cst.Node:
+			p.parents[c] = n
+			first := len(p.items)
+			p.collect(c)
+			if startsLine(n.AST, c.AST) && first < len(p.items) {
+				p.items[first].stmtStart = true
+			}
+		}
+	}
+}
+
+// startsLine reports whether child, directly inside parent, is a statement of a block, an arm of
+// a match or a variant of an enum
+func startsLine(parent, child ast.Node) bool {
+	switch parent.(type) {
+	case *ast.Program, *ast.BlockStatement:
+		_, ok := child.(ast.Statement)
+		return ok
+	case *ast.EnumStatement:
+		_, ok := child.(*ast.EnumVariant)
+		return ok
+	case *ast.MatchStatement:
+		switch child.(type) {
+		case *ast.MatchArm, *ast.ElseStatement:
+			return true
+		}
+	}
+	return false
+}
+
+// parentAST returns the AST of the node that contains n (nil for the root)
+func (p *printer) parentAST(n *cst.Node) ast.Node {
+	if parent := p.parents[n]; parent != nil {
+		return parent.AST
+	}
+	return nil
+}
+
+// inExpression reports whether n is part of an expression (x = if c { a } else { b })
+func (p *printer) inExpression(n *cst.Node) bool {
+	for ; n != nil; n = p.parents[n] {
+		if _, ok := n.AST.(ast.Expression); ok {
+			return true
+		}
+	}
+	return false
+}
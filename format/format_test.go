@@ -0,0 +1,172 @@
+package format
+
+import (
+	"pun/lexer"
+	"strings"
+	"testing"
+)
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"operators and commas", "x=1+2*3\ny=f(a,b)[0]\nw = x>1 ? \"a\":\"b\"\n",
+			"x = 1 + 2 * 3\ny = f(a, b)[0]\nw = x > 1 ? \"a\" : \"b\"\n"},
+		{"unary operators", "z = - x + ~ 1\nok = ! done\n", "z = -x + ~1\nok = !done\n"},
+		{"if, elif and else", "if x>3{print( \"big\" )}elif x<0 {print(\"neg\")}\nelse{\nprint(\"small\")\n}\n",
+			"if x > 3 {\n    print(\"big\")\n} elif x < 0 {\n    print(\"neg\")\n} else {\n    print(\"small\")\n}\n"},
+		{"indentation", "func f(a) {\nfor x in a {\n\t\tif x { return x }\n}\n}\n",
+			"func f(a) {\n    for x in a {\n        if x {\n            return x\n        }\n    }\n}\n"},
+		{"blank lines", "\n\nx = 1\n\n\n\ny = 2\n\n", "x = 1\n\ny = 2\n"},
+		{"comments", "// đầu\nfunc   add(a,b){\nreturn a+b   // tổng\n}\n",
+			"// đầu\nfunc add(a, b) {\n    return a + b // tổng\n}\n"},
+		{"line break inside a statement", "y = [1,2,\n  3]\n", "y = [1, 2,\n    3]\n"},
+		{"match and enum", "enum E{A,B(v)}\nmatch e {\nE.A {print(1)}\nelse {print(2)}\n}\n",
+			"enum E { A, B(v) }\nmatch e {\n    E.A {\n        print(1)\n    }\n    else {\n        print(2)\n    }\n}\n"},
+		{"collections and templates", "s=#{1,2}\nt=( 1 , 2 )\nprint(\"${x:>4} ${ a+b }\")\n",
+			"s = #{1, 2}\nt = (1, 2)\nprint(\"${x:>4} ${a + b}\")\n"},
+		{"labels and loop else", "outer:for i=0;i<3;i=i+1{break outer}else{print(0)}\n",
+			"outer: for i = 0; i < 3; i = i + 1 {\n    break outer\n} else {\n    print(0)\n}\n"},
+		{"missing final newline", "x = 1", "x = 1\n"},
+	}
+	for _, tt := range tests {
+		got, diagnostics := Source("test.pun", strings.NewReader(tt.input))
+		if len(diagnostics) > 0 {
+			t.Errorf("%s: unexpected errors %v", tt.name, diagnostics)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.name, got, tt.expected)
+		}
+	}
+}
+
+// programs are formatted twice and must not change the second time
+var programs = []string{
+	`x=1+2*3
+if x>3{print( "big" )}elif x<0 {print("neg")}
+else{
+print("small")
+}
+
+
+
+// a comment
+func   add(a,b){
+return a+b   // sum
+}
+y = [1,2,
+  3]
+`,
+	`record Point(x,y)
+func Point.__add__(other){return Point(self.x+other.x,self.y+other.y)}
+enum Shape{
+Circle(r), // hình tròn
+Square(side)
+}
+area = match s {
+Shape.Circle(r) { 3 * r * r }
+Shape.Square(side) {side*side}
+}
+`,
+	`outer: for i = 0; i < 3; i = i + 1 {
+for v in #{1,2} { if v==2 {continue outer} }
+while false {} else { break }
+} else {
+until true { }
+}
+func f() { defer print("done")
+return a?.b ?? 2n ** 64 }
+`,
+	`x = if c { "a" } elif d { "b" } else { "c" }
+v = { 1 + 2 }
+print("${x:>8} and ${c ? 1 : 2} ${"nested ${b}"}")
+x = (1 + /* một */ 2)
+    * 3
+`,
+}
+
+// kinds lists the tokens of source, which the formatter must not change
+func kinds(source string) []string {
+	l := lexer.NewLexer(source)
+	var tokens []string
+	for tok := l.NextToken(); tok.Kind != lexer.KIND_EOF; tok = l.NextToken() {
+		tokens = append(tokens, tok.Kind.String()+" "+tok.Value)
+	}
+	return tokens
+}
+
+func TestIdempotent(t *testing.T) {
+	for i, program := range programs {
+		once, diagnostics := Source("test.pun", strings.NewReader(program))
+		if len(diagnostics) > 0 {
+			t.Errorf("program %d: unexpected errors %v", i, diagnostics)
+			continue
+		}
+		twice, _ := Source("test.pun", strings.NewReader(once))
+		if twice != once {
+			t.Errorf("program %d: formatting again changes it:\n%s", i, Diff("test.pun", once, twice))
+		}
+		if before, after := kinds(program), kinds(once); strings.Join(before, "\n") != strings.Join(after, "\n") {
+			t.Errorf("program %d: formatting changes the tokens:\n%q\n%q", i, before, after)
+		}
+	}
+
+	// Comment ở cuối file: định dạng lần hai không thêm dòng trống, comment chưa đóng thì không định dạng
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x = 1 // cuối", "x = 1 // cuối\n"},
+		{"x = 1\n/* a */", "x = 1\n/* a */\n"},
+		{"x = 1\n/* a\n   b */\n\n", "x = 1\n/* a\n   b */\n"},
+		{"x = 1\n/* abc", ""},
+		{"x = 1\n/* abc\n", ""},
+	}
+	for _, tt := range tests {
+		once, diagnostics := Source("test.pun", strings.NewReader(tt.input))
+		if once != tt.expected || (tt.expected == "") != (len(diagnostics) > 0) {
+			t.Errorf("%q: got %q with errors %v, want %q", tt.input, once, diagnostics, tt.expected)
+			continue
+		}
+		if twice, _ := Source("test.pun", strings.NewReader(once)); once != "" && twice != once {
+			t.Errorf("%q: formatting again gives %q, want %q", tt.input, twice, once)
+		}
+	}
+}
+
+func TestSyntaxErrors(t *testing.T) {
+	got, diagnostics := Source("test.pun", strings.NewReader("x = (1 +\nprint(x)\n"))
+	if got != "" || len(diagnostics) == 0 {
+		t.Errorf("broken program: got %q with %d errors, want nothing and the errors", got, len(diagnostics))
+	}
+}
+
+func TestDiff(t *testing.T) {
+	if got := Diff("a.pun", "x = 1\n", "x = 1\n"); got != "" {
+		t.Errorf("equal texts: got diff %q", got)
+	}
+
+	before := "a\nb\nc\nd\ne\nf\ng\nh\n"
+	after := "a\nb\nc\nD\ne\nf\ng\nh"
+	expected := `--- a.pun.orig
++++ a.pun
+@@ -1,8 +1,8 @@
+ a
+ b
+ c
+-d
++D
+ e
+ f
+ g
+-h
++h
+\ No newline at end of file
+`
+	if got := Diff("a.pun", before, after); got != expected {
+		t.Errorf("got diff\n%s\nwant\n%s", got, expected)
+	}
+}
@@ -0,0 +1,352 @@
+package format
+
+import (
+	"pun/ast"
+	"pun/cst"
+	"pun/lexer"
+	"strings"
+)
+
+type printer struct {
+	items   []*item
+	parents map[*cst.Node]*cst.Node
+	out     strings.Builder
+	stack   []bracket // Các dấu ngoặc đang mở
+
+	indent           int    // Indent của dòng đang in
+	lineStart        bool   // Chưa in gì trên dòng hiện tại
+	started          bool   // Đã in token hoặc comment đầu tiên (BOM không tính)
+	last             *item  // Token vừa in
+	afterOpener      bool   // Thứ vừa in là một dấu mở ngoặc: không để dòng trống ngay sau nó
+	afterComment     bool   // Thứ vừa in là một comment
+	afterLineComment bool   // Thứ vừa in là comment //: phải xuống dòng
+	ownLineComment   bool   // Comment vừa in đứng đầu dòng: statement sau nó được ở cùng dòng
+	skipped          string // Phần input sau ký tự NUL, in lại nguyên văn ở cuối
+}
+
+// bracket is an open bracket, brace or ${ of a template
+type bracket struct {
+	indent  int  // Indent của dòng có dấu mở
+	block   bool // { của block, match hay enum: mỗi dòng bên trong là một statement, nhánh hay variant
+	oneLine bool // Viết trên một dòng và được giữ trên một dòng ({ a } trong biểu thức, enum E { A, B })
+}
+
+// matchBrackets pairs every opening bracket with its closing bracket
+func (p *printer) matchBrackets() {
+	var open []int
+	for i, it := range p.items {
+		if isCloser(it.Kind) && it.Kind != lexer.KIND_EOF && len(open) > 0 {
+			j := open[len(open)-1]
+			open = open[:len(open)-1]
+			p.items[j].pair, it.pair = i, j
+		}
+		if isOpener(it.Kind) {
+			open = append(open, i)
+		}
+	}
+}
+
+// isOpener and isCloser: TEMPLATE_MIDDLE (}...${) đóng một ${ và mở một ${ khác
+func isOpener(kind lexer.Kind) bool {
+	switch kind {
+	case lexer.KIND_LPAREN, lexer.KIND_LSQUARE, lexer.KIND_FROZEN_ARRAY_OPEN, lexer.KIND_SET_OPEN,
+		lexer.KIND_LCURLY, lexer.KIND_TEMPLATE_HEAD, lexer.KIND_TEMPLATE_MIDDLE:
+		return true
+	}
+	return false
+}
+
+func isCloser(kind lexer.Kind) bool {
+	switch kind {
+	case lexer.KIND_RPAREN, lexer.KIND_RSQUARE, lexer.KIND_RCURLY,
+		lexer.KIND_TEMPLATE_MIDDLE, lexer.KIND_TEMPLATE_TAIL, lexer.KIND_EOF:
+		return true
+	}
+	return false
+}
+
+// isBlockBrace reports whether it is a brace of a block, a match or an enum, which holds
+// statements, arms or variants
+func isBlockBrace(it *item) bool {
+	if it.Kind != lexer.KIND_LCURLY && it.Kind != lexer.KIND_RCURLY {
+		return false
+	}
+	switch it.parent.AST.(type) {
+	case *ast.BlockStatement, *ast.MatchStatement, *ast.EnumStatement:
+		return true
+	}
+	return false
+}
+
+// oneLine reports whether the braces opened at index i stay on one line: they were written on
+// one line and hold an enum or are part of an expression. Block của statement luôn được tách ra nhiều dòng.
+func (p *printer) oneLine(i int) bool {
+	if p.items[i].pair < 0 {
+		return false
+	}
+	if _, enum := p.items[i].parent.AST.(*ast.EnumStatement); !enum && !p.inExpression(p.items[i].parent) {
+		return false
+	}
+	for _, it := range p.items[i+1 : p.items[i].pair+1] {
+		if hasNewline(it.Raw) {
+			return false
+		}
+	}
+	return true
+}
+
+func hasNewline(raw *lexer.Raw) bool {
+	if raw == nil {
+		return false
+	}
+	for _, trivia := range raw.Leading {
+		if trivia.Kind == lexer.TRIVIA_NEWLINE {
+			return true
+		}
+	}
+	return false
+}
+
+// joinsStatement reports whether it always goes on the line of the token before it: the brace
+// that opens the body of a statement, and elif/else after the closing brace of if or a loop
+func (p *printer) joinsStatement(it *item) bool {
+	switch it.Kind {
+	case lexer.KIND_LCURLY:
+		switch it.parent.AST.(type) {
+		case *ast.MatchStatement, *ast.EnumStatement:
+			return true
+		case *ast.BlockStatement:
+			_, expr := p.parentAST(it.parent).(*ast.BlockExpression)
+			return !expr
+		}
+	case lexer.KIND_ELIF, lexer.KIND_ELSE:
+		_, arm := p.parentAST(it.parent).(*ast.MatchStatement) // else của match là một nhánh riêng
+		return !arm
+	}
+	return false
+}
+
+func (p *printer) print() {
+	for i, it := range p.items {
+		p.leading(it)
+		if it.Kind != lexer.KIND_EOF {
+			p.token(i, it)
+		}
+		p.trailing(it)
+	}
+	if p.started {
+		p.out.WriteByte('\n')
+	}
+	p.out.WriteString(p.skipped) // Phần sau ký tự NUL, giữ nguyên
+}
+
+// leading prints the comments before a token and counts the new lines after the last of them
+func (p *printer) leading(it *item) {
+	newlines := 0
+	for _, trivia := range it.Raw.Leading {
+		switch trivia.Kind {
+		case lexer.TRIVIA_BOM:
+			p.out.WriteString(trivia.Text)
+		case lexer.TRIVIA_NEWLINE:
+			newlines++
+		case lexer.TRIVIA_LINE_COMMENT, lexer.TRIVIA_BLOCK_COMMENT:
+			ownLine := newlines > 0 || p.afterLineComment || !p.started
+			if ownLine {
+				p.newline(newlines > 1 && !p.afterOpener)
+				p.indent = p.indentFor(it, true)
+			} else if !p.lineStart {
+				p.out.WriteByte(' ')
+			}
+			p.comment(trivia)
+			p.ownLineComment = ownLine || p.ownLineComment // /* a */ /* b */ x = 1
+			p.afterOpener = false
+			newlines = 0
+		}
+	}
+	it.newlines = newlines
+}
+
+// token prints a token on a new line or after the one before it, and keeps track of the brackets
+func (p *printer) token(i int, it *item) {
+	breakLine := false
+	switch {
+	case !p.started:
+	case p.afterLineComment:
+		breakLine = true
+	case it.stmtStart:
+		// /* comment */ x = 1: statement đứng sau comment ở đầu dòng thì giữ trên dòng đó
+		breakLine = !p.inOneLine() && !(p.afterComment && p.ownLineComment && it.newlines == 0)
+	case isBlockBrace(it) && it.Kind == lexer.KIND_RCURLY && !p.inOneLine():
+		// Block rỗng in thành {}
+		breakLine = p.afterComment || p.last == nil || p.last != p.items[it.pair]
+	case p.joinsStatement(it):
+	case it.newlines > 0:
+		breakLine = true
+	}
+
+	if breakLine {
+		p.newline(it.newlines > 1 && !p.afterOpener && !isCloser(it.Kind))
+		p.indent = p.indentFor(it, false)
+	} else if !p.lineStart && p.space(it) {
+		p.out.WriteByte(' ')
+	}
+	p.write(it.Raw.Text)
+
+	if isCloser(it.Kind) && len(p.stack) > 0 {
+		p.stack = p.stack[:len(p.stack)-1]
+	}
+	if isOpener(it.Kind) {
+		block := isBlockBrace(it)
+		p.stack = append(p.stack, bracket{indent: p.indent, block: block, oneLine: block && p.oneLine(i)})
+	}
+	p.last = it
+	p.afterOpener = isOpener(it.Kind)
+	p.afterComment, p.afterLineComment, p.ownLineComment = false, false, false
+}
+
+// trailing prints the comments after a token on its line
+func (p *printer) trailing(it *item) {
+	for _, trivia := range it.Raw.Trailing {
+		switch trivia.Kind {
+		case lexer.TRIVIA_LINE_COMMENT, lexer.TRIVIA_BLOCK_COMMENT:
+			p.out.WriteByte(' ')
+			p.comment(trivia)
+			p.ownLineComment = false
+		case lexer.TRIVIA_SKIPPED:
+			p.skipped = trivia.Text
+		}
+	}
+}
+
+func (p *printer) comment(trivia lexer.Trivia) {
+	if trivia.Kind == lexer.TRIVIA_LINE_COMMENT {
+		p.write(strings.TrimRight(trivia.Text, " \t\r"))
+	} else {
+		p.write(strings.ReplaceAll(trivia.Text, "\r\n", "\n"))
+	}
+	p.afterComment = true
+	p.afterLineComment = trivia.Kind == lexer.TRIVIA_LINE_COMMENT
+}
+
+// newline ends the current line, with a blank line after it if blank is set.
+// Ở đầu file không in dòng trống nào.
+func (p *printer) newline(blank bool) {
+	if !p.started {
+		return
+	}
+	p.out.WriteByte('\n')
+	if blank {
+		p.out.WriteByte('\n')
+	}
+	p.lineStart = true
+}
+
+func (p *printer) write(text string) {
+	if p.lineStart {
+		p.out.WriteString(strings.Repeat(Indent, p.indent))
+		p.lineStart = false
+	}
+	p.out.WriteString(text)
+	p.started = true
+}
+
+// inOneLine reports whether the innermost open bracket is a block kept on one line
+func (p *printer) inOneLine() bool {
+	return len(p.stack) > 0 && p.stack[len(p.stack)-1].oneLine
+}
+
+// indentFor returns the indentation of a line that starts with it, or with a comment before it.
+// A closing bracket lines up with the line of its opening bracket; inside a block, a line that
+// does not start a statement continues the statement above and gets one more level.
+func (p *printer) indentFor(it *item, comment bool) int {
+	indent, block := 0, true
+	if n := len(p.stack); n > 0 {
+		top := p.stack[n-1]
+		if isCloser(it.Kind) && !comment {
+			return top.indent
+		}
+		indent, block = top.indent+1, top.block
+	}
+	if block && !it.stmtStart && !isCloser(it.Kind) && !p.joinsStatement(it) {
+		indent++
+	}
+	return indent
+}
+
+// space reports whether a space goes between the last token and it, on the same line
+func (p *printer) space(it *item) bool {
+	prev := p.last
+	if p.afterComment {
+		return true
+	}
+	if prev == nil {
+		return false
+	}
+	if glues(prev, it) {
+		return true
+	}
+
+	switch it.Kind {
+	case lexer.KIND_COMMA, lexer.KIND_SEMICOLON, lexer.KIND_RPAREN, lexer.KIND_RSQUARE, lexer.KIND_DOT,
+		lexer.KIND_OPT_DOT, lexer.KIND_TEMPLATE_MIDDLE, lexer.KIND_TEMPLATE_TAIL, lexer.KIND_FORMAT_SPEC:
+		return false
+	case lexer.KIND_RCURLY:
+		if prev.Kind == lexer.KIND_LCURLY || prev.Kind == lexer.KIND_SET_OPEN {
+			return false // {} và #{}
+		}
+		_, set := it.parent.AST.(*ast.SetExpression)
+		return !set
+	case lexer.KIND_LPAREN:
+		// f(x), a.m(x), func f(x), record P(x), enum E { V(x) }; (x) sau dấu phẩy hay toán tử là ngoặc nhóm
+		switch it.parent.AST.(type) {
+		case *ast.FunctionCallExpression, *ast.MethodCallExpression, *ast.FunctionDefinitionStatement,
+			*ast.MethodDefinitionStatement, *ast.RecordStatement, *ast.EnumVariant:
+			if endsValue(prev.Kind) {
+				return false
+			}
+		}
+	case lexer.KIND_LSQUARE:
+		if _, index := it.parent.AST.(*ast.ArrayIndexExpression); index && endsValue(prev.Kind) {
+			return false
+		}
+	case lexer.KIND_COLON:
+		if _, conditional := it.parent.AST.(*ast.ConditionalExpression); !conditional {
+			return false // Nhãn của vòng lặp: outer: for
+		}
+	}
+
+	switch prev.Kind {
+	case lexer.KIND_LPAREN, lexer.KIND_LSQUARE, lexer.KIND_FROZEN_ARRAY_OPEN, lexer.KIND_SET_OPEN,
+		lexer.KIND_DOT, lexer.KIND_OPT_DOT, lexer.KIND_TEMPLATE_HEAD, lexer.KIND_TEMPLATE_MIDDLE:
+		return false
+	case lexer.KIND_MINUS, lexer.KIND_NOT, lexer.KIND_BIT_NOT:
+		_, unary := prev.parent.AST.(*ast.UnaryExpression)
+		return !unary
+	}
+	return true
+}
+
+// endsValue reports whether a token of this kind can end the expression before a call or index
+func endsValue(kind lexer.Kind) bool {
+	switch kind {
+	case lexer.KIND_IDENTIFIER, lexer.KIND_NUMBER, lexer.KIND_STRING, lexer.KIND_TEMPLATE_TAIL,
+		lexer.KIND_RPAREN, lexer.KIND_RSQUARE, lexer.KIND_RCURLY,
+		lexer.KIND_TRUE, lexer.KIND_FALSE, lexer.KIND_NOTHING:
+		return true
+	}
+	return false
+}
+
+// glues reports whether two tokens written without a space between them would be read
+// differently: - -x is not --x, ! !x is not !!x, and 1 .x is not 1.x
+func glues(a, b *item) bool {
+	if a.Raw.Text == "" || b.Raw.Text == "" {
+		return false
+	}
+	if a.Kind == lexer.KIND_NUMBER && b.Kind == lexer.KIND_DOT {
+		return true
+	}
+	const operators = "+-*/%=<>!&|^~?:.#"
+	return strings.IndexByte(operators, a.Raw.Text[len(a.Raw.Text)-1]) >= 0 &&
+		strings.IndexByte(operators, b.Raw.Text[0]) >= 0
+}
@@ -1,59 +1,155 @@
 package lexer
 
-import "unicode"
-
-// Lexer structure
+import (
+	"fmt"
+	"io"
+	"pun/error"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Lexer structure. Positions are byte offsets into input, columns count characters.
 type Lexer struct {
-	input        string
-	position     int
-	readPosition int
+	file         string // Tên file nguồn (rỗng nếu đọc từ REPL)
+	src          *source
+	position     int // Vị trí byte của ký tự hiện tại
+	readPosition int // Vị trí byte của ký tự tiếp theo
 	ch           rune
+	invalid      bool // ch là một byte không phải UTF-8 (đã báo lỗi)
 	line         int
 	col          int
+	templates    []template // Các ${...} đang mở (lồng nhau)
+	errors       []customError.SyntaxError
+
+	// Lossless makes every token carry its raw text and the whitespace and comments around it
+	// (Token.Raw), and turns comments into trivia instead of COMMENT tokens.
+	// Set it before reading the first token.
+	Lossless bool
+	bom      bool // File bắt đầu bằng BOM (đã bỏ qua), trả lại trong trivia của token đầu tiên
+	atEOF    bool // Đã trả về EOF trong chế độ lossless
 }
 
 // NewLexer creates a new lexer
 func NewLexer(input string) *Lexer {
-	l := &Lexer{input: input, line: 1, col: 0}
+	return newLexer(newStringSource(input))
+}
+
+// NewReaderLexer creates a lexer that reads the source of a file from r as it goes,
+// so a large script does not have to be loaded into memory first
+func NewReaderLexer(file string, r io.Reader) *Lexer {
+	l := newLexer(newReaderSource(r))
+	l.file = file
+	return l
+}
+
+func newLexer(src *source) *Lexer {
+	l := &Lexer{src: src, line: 1, col: 0}
 	l.nextChar() // Initialize first character
+	if l.ch == '\uFEFF' {
+		l.nextChar() // Bỏ qua BOM ở đầu file
+		l.col = 1    // BOM không tính là một cột
+		l.bom = true
+	}
+	return l
+}
+
+// NewFileLexer creates a lexer for the source of a file; the name is kept in the spans of the AST
+func NewFileLexer(file, input string) *Lexer {
+	l := NewLexer(input)
+	l.file = file
 	return l
 }
 
-// NextToken extracts the next token from the input
+// File returns the name of the source file (empty for REPL input)
+func (l *Lexer) File() string {
+	return l.file
+}
+
+// NextToken extracts the next token from the input, with its start and end positions
 func (l *Lexer) NextToken() Token {
+	if l.Lossless {
+		return l.nextLosslessToken()
+	}
+	return l.nextToken()
+}
+
+func (l *Lexer) nextToken() Token {
 	l.skipWhitespace()
+	l.src.discard(l.position) // Các token trước đã đọc xong
+	start := Position{Offset: l.position, Line: l.line, Col: l.col}
+
+	tok := l.readToken()
+	// Token nhiều dòng (chuỗi ``, comment /* */) cũng lấy vị trí bắt đầu
+	tok.Offset, tok.Line, tok.Col = start.Offset, start.Line, start.Col
+	tok.End = Position{Offset: l.position, Line: l.line, Col: l.col}
+	return tok
+}
+
+func (l *Lexer) readToken() Token {
 	startCol := l.col
 
 	switch l.ch {
 	case '.':
 		l.nextChar()
-		return Token{Type: TOKEN_DOT, Value: ".", Line: l.line, Col: startCol}
+		return Token{Kind: KIND_DOT, Value: ".", Line: l.line, Col: startCol}
 	case ',':
 		l.nextChar()
-		return Token{Type: TOKEN_COMMA, Value: ",", Line: l.line, Col: startCol}
+		return Token{Kind: KIND_COMMA, Value: ",", Line: l.line, Col: startCol}
 	case '(':
+		l.openBracket()
 		l.nextChar()
-		return Token{Type: TOKEN_LPAREN, Value: "(", Line: l.line, Col: startCol}
+		return Token{Kind: KIND_LPAREN, Value: "(", Line: l.line, Col: startCol}
 	case ')':
+		l.closeBracket()
 		l.nextChar()
-		return Token{Type: TOKEN_RPAREN, Value: ")", Line: l.line, Col: startCol}
+		return Token{Kind: KIND_RPAREN, Value: ")", Line: l.line, Col: startCol}
 	case '{':
+		l.openBracket()
 		l.nextChar()
-		return Token{Type: TOKEN_LCURLY, Value: "{", Line: l.line, Col: startCol}
+		return Token{Kind: KIND_LCURLY, Value: "{", Line: l.line, Col: startCol}
 	case '}':
+		// Dấu } đóng ${...} => đọc tiếp phần chuỗi còn lại của template
+		if l.inTemplateExpression() {
+			return l.readTemplateContinuation()
+		}
+		l.closeBracket()
 		l.nextChar()
-		return Token{Type: TOKEN_RCURLY, Value: "}", Line: l.line, Col: startCol}
+		return Token{Kind: KIND_RCURLY, Value: "}", Line: l.line, Col: startCol}
+	case '#':
+		switch l.peekChar() {
+		case '{':
+			l.openBracket() // Được đóng bởi '}' như một ngoặc nhọn thường
+			l.nextChar()
+			l.nextChar()
+			return Token{Kind: KIND_SET_OPEN, Value: "#{", Line: l.line, Col: startCol}
+		case '[':
+			l.openBracket() // Được đóng bởi ']'
+			l.nextChar()
+			l.nextChar()
+			return Token{Kind: KIND_FROZEN_ARRAY_OPEN, Value: "#[", Line: l.line, Col: startCol}
+		}
+		return l.readOperator()
 	case '[':
+		l.openBracket()
 		l.nextChar()
-		return Token{Type: TOKEN_LSQUARE, Value: "[", Line: l.line, Col: startCol}
+		return Token{Kind: KIND_LSQUARE, Value: "[", Line: l.line, Col: startCol}
 	case ']':
+		l.closeBracket()
 		l.nextChar()
-		return Token{Type: TOKEN_RSQUARE, Value: "]", Line: l.line, Col: startCol}
+		return Token{Kind: KIND_RSQUARE, Value: "]", Line: l.line, Col: startCol}
 	case ';':
 		l.nextChar()
-		return Token{Type: TOKEN_SEMICOLON, Value: ";", Line: l.line, Col: startCol}
+		return Token{Kind: KIND_SEMICOLON, Value: ";", Line: l.line, Col: startCol}
 	case '"':
-		return Token{Type: TOKEN_STRING, Value: l.readString(), Line: l.line, Col: startCol}
+		return l.readString()
+	case '\'', '`':
+		return l.readRawString()
+	case ':':
+		// ${price:.2f} => phần sau dấu : là format spec
+		if l.startsFormatSpec() {
+			return l.readFormatSpec()
+		}
+		return l.readOperator()
 	case '/':
 		if l.peekChar() == '/' { // Line comment (//)
 			return l.readLineComment()
@@ -62,14 +158,14 @@ func (l *Lexer) NextToken() Token {
 		}
 		// Nếu không phải comment, xử lý như toán tử /
 		l.nextChar()
-		return Token{Type: TOKEN_ARITHMETIC, Value: "/", Line: l.line, Col: startCol}
+		return Token{Kind: KIND_SLASH, Value: "/", Line: l.line, Col: startCol}
 	case 0:
-		return Token{Type: TOKEN_EOF, Value: "", Line: l.line, Col: startCol}
+		return Token{Kind: KIND_EOF, Value: "", Line: l.line, Col: startCol}
 	default:
-		if unicode.IsLetter(l.ch) {
+		if isIdentifierStart(l.ch) {
 			return l.readKeyword()
 		}
-		if unicode.IsDigit(l.ch) {
+		if isDigit(l.ch) {
 			return l.readNumber()
 		}
 		return l.readOperator()
@@ -77,33 +173,40 @@ func (l *Lexer) NextToken() Token {
 }
 
 // matchTwoCharToken handles tokens like ==, !=, >=, <=
-func (l *Lexer) matchTwoCharToken(expectedNext rune, singleType, doubleType string, startCol int) Token {
-	tok := Token{Type: singleType, Value: string(l.ch), Line: l.line, Col: startCol}
+func (l *Lexer) matchTwoCharToken(expectedNext rune, single, double Kind, startCol int) Token {
+	tok := Token{Kind: single, Value: string(l.ch), Line: l.line, Col: startCol}
 	if l.peekChar() == expectedNext {
-		tok = Token{Type: doubleType, Value: string(l.ch) + string(expectedNext), Line: l.line, Col: startCol}
+		tok = Token{Kind: double, Value: string(l.ch) + string(expectedNext), Line: l.line, Col: startCol}
 		l.nextChar()
 	}
 	l.nextChar()
 	return tok
 }
 
-// nextChar advances the position in the input
+// nextChar advances to the next UTF-8 encoded character in the input
 func (l *Lexer) nextChar() {
-	if l.readPosition >= len(l.input) {
-		l.ch = 0 // EOF
-	} else {
-		l.ch = rune(l.input[l.readPosition])
-	}
-
+	// Chỉ sang dòng mới khi đi qua ký tự '\n' (chính '\n' vẫn thuộc dòng cũ)
 	if l.ch == '\n' {
 		l.line++
-		l.col = 1 // Reset column for new line
-	} else {
-		l.col++ // Move column forward
+		l.col = 0
+	}
+
+	var width int
+	l.ch, width = l.src.runeAt(l.readPosition)
+	if width == 0 {
+		l.ch = 0 // EOF
+		if err := l.src.readError(); err != nil {
+			l.addError(customError.ReadError, l.line, l.col+1, l.file, err)
+		}
+	}
+	l.invalid = l.ch == utf8.RuneError && width == 1
+	if l.invalid {
+		l.addError(customError.InvalidUTF8, l.line, l.col+1, fmt.Sprintf("byte 0x%02X", l.src.byteAt(l.readPosition)))
 	}
 
+	l.col++ // Cột tính theo ký tự, không theo byte
 	l.position = l.readPosition
-	l.readPosition++
+	l.readPosition += width
 }
 
 // readKeyword reads an identifier or keyword or boolean
@@ -111,17 +214,17 @@ func (l *Lexer) readKeyword() Token {
 	start := l.position
 	startCol := l.col
 
-	for unicode.IsLetter(l.ch) {
+	for isIdentifierChar(l.ch) {
 		l.nextChar()
 	}
 
-	ident := l.input[start:l.position]
-	tokType := TOKEN_IDENTIFIER
-	if kwType, ok := keywords[ident]; ok {
-		tokType = kwType
+	ident := l.src.slice(start, l.position)
+	kind := KIND_IDENTIFIER
+	if kw, ok := keywords[ident]; ok {
+		kind = kw
 	}
 
-	return Token{Type: tokType, Value: ident, Line: l.line, Col: startCol}
+	return Token{Kind: kind, Value: ident, Line: l.line, Col: startCol}
 }
 
 func (l *Lexer) readOperator() Token {
@@ -147,7 +250,7 @@ func (l *Lexer) readOperator() Token {
 			op += string(l.ch)
 		}
 
-	case '/', '%', '=', '!':
+	case '/', '%', '=', '!', ':':
 		if l.peekChar() == '=' {
 			l.nextChar()
 			op += string(l.ch)
@@ -172,85 +275,34 @@ func (l *Lexer) readOperator() Token {
 			l.nextChar()
 			op += string(l.ch)
 		}
+	case '?':
+		if l.peekChar() == '?' || l.peekChar() == '.' {
+			l.nextChar()
+			op += string(l.ch)
+		}
 	}
 
 	l.nextChar()
 
-	tokType, ok := operators[op]
+	kind, ok := operators[op]
 	if !ok {
-		return Token{Type: TOKEN_UNKNOWN, Value: op, Line: l.line, Col: startCol}
+		return Token{Kind: KIND_UNKNOWN, Value: op, Line: l.line, Col: startCol}
+	}
+	if kind == KIND_QUESTION && l.inTemplateExpression() {
+		l.templates[len(l.templates)-1].questions++ // Dấu : tương ứng không mở format spec
 	}
 
-	return Token{Type: tokType, Value: op, Line: l.line, Col: startCol}
+	return Token{Kind: kind, Value: op, Line: l.line, Col: startCol}
 }
 
 // skipWhitespace skips spaces and tabs
+// Byte không phải UTF-8 đã được báo lỗi khi đọc: bỏ qua như khoảng trắng để parser không báo lỗi lần nữa
 func (l *Lexer) skipWhitespace() {
-	for unicode.IsSpace(l.ch) {
+	for unicode.IsSpace(l.ch) || l.invalid {
 		l.nextChar()
 	}
 }
 
-// readNumber reads a number (supports decimals)
-func (l *Lexer) readNumber() Token {
-	start := l.position
-	startCol := l.col
-
-	// Read the integer part
-	for unicode.IsDigit(l.ch) {
-		l.nextChar()
-	}
-
-	// Check for a decimal point
-	if l.ch == '.' {
-		l.nextChar()
-
-		// Ensure there’s at least one digit after the decimal
-		if !unicode.IsDigit(l.ch) {
-			return Token{Type: TOKEN_NUMBER, Value: l.input[start:l.position], Line: l.line, Col: startCol}
-		}
-
-		// Read the fractional part
-		for unicode.IsDigit(l.ch) {
-			l.nextChar()
-		}
-	}
-
-	return Token{Type: TOKEN_NUMBER, Value: l.input[start:l.position], Line: l.line, Col: startCol}
-}
-
-// readString reads a string enclosed in quotes and supports escape sequences
-func (l *Lexer) readString() string {
-	l.nextChar() // Skip opening quote
-	//start := l.position
-	var strBuilder []rune
-
-	for l.ch != '"' && l.ch != 0 {
-		// Xử lý escape sequence
-		if l.ch == '\\' {
-			l.nextChar()
-			switch l.ch {
-			case 'n':
-				strBuilder = append(strBuilder, '\n')
-			case 't':
-				strBuilder = append(strBuilder, '\t')
-			case '"':
-				strBuilder = append(strBuilder, '"')
-			case '\\':
-				strBuilder = append(strBuilder, '\\')
-			default:
-				strBuilder = append(strBuilder, '\\', l.ch) // Giữ nguyên nếu escape không hợp lệ
-			}
-		} else {
-			strBuilder = append(strBuilder, l.ch)
-		}
-		l.nextChar()
-	}
-
-	l.nextChar() // Skip closing quote
-	return string(strBuilder)
-}
-
 // Đọc block comment (/* */)
 func (l *Lexer) readBlockComment() Token {
 	startPos := l.position
@@ -262,9 +314,10 @@ func (l *Lexer) readBlockComment() Token {
 
 	for {
 		if l.ch == 0 { // EOF trước khi đóng comment
+			l.addError(customError.UnterminatedComment, startLine, startCol, "/*")
 			return Token{
-				Type:  TOKEN_COMMENT,
-				Value: l.input[startPos:l.position],
+				Kind:  KIND_COMMENT,
+				Value: l.src.slice(startPos, l.position),
 				Line:  startLine,
 				Col:   startCol,
 			}
@@ -278,8 +331,8 @@ func (l *Lexer) readBlockComment() Token {
 	}
 
 	return Token{
-		Type:  TOKEN_COMMENT,
-		Value: l.input[startPos:l.position],
+		Kind:  KIND_COMMENT,
+		Value: l.src.slice(startPos, l.position),
 		Line:  startLine,
 		Col:   startCol,
 	}
@@ -296,16 +349,63 @@ func (l *Lexer) readLineComment() Token {
 	}
 
 	return Token{
-		Type:  TOKEN_COMMENT,
-		Value: l.input[startPos:l.position],
+		Kind:  KIND_COMMENT,
+		Value: l.src.slice(startPos, l.position),
 		Line:  startLine,
 		Col:   startCol,
 	}
 }
 
 func (l *Lexer) peekChar() rune {
-	if l.readPosition >= len(l.input) {
-		return 0
+	return l.peekCharAt(1)
+}
+
+// peekCharAt looks n characters ahead without advancing (peekCharAt(1) == peekChar())
+func (l *Lexer) peekCharAt(n int) rune {
+	pos := l.readPosition
+	for i := 1; i < n; i++ {
+		_, width := l.src.runeAt(pos)
+		if width == 0 {
+			return 0
+		}
+		pos += width
 	}
-	return rune(l.input[l.readPosition])
+	ch, _ := l.src.runeAt(pos)
+	return ch
+}
+
+// isIdentifierStart: identifiers start with a Unicode letter or '_'
+func isIdentifierStart(ch rune) bool {
+	return unicode.IsLetter(ch) || ch == '_'
+}
+
+// isIdentifierChar: letters, digits, '_' and combining marks (tiếng Việt dạng tổ hợp)
+func isIdentifierChar(ch rune) bool {
+	return isIdentifierStart(ch) || unicode.IsDigit(ch) || unicode.In(ch, unicode.Mn, unicode.Mc)
+}
+
+// isDigit only accepts ASCII digits, number literals are always ASCII
+func isDigit(ch rune) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+// addError records a lexical error, reported by the parser as a SyntaxError
+func (l *Lexer) addError(code customError.Code, line, col int, context string, args ...interface{}) {
+	err := customError.SyntaxError{
+		PunError: customError.PunError{
+			Code:    code,
+			Message: customError.Message(code, args...),
+			Line:    line,
+			Column:  col,
+		},
+		Context: customError.Text("Near: %s", context),
+	}
+	l.errors = append(l.errors, err)
+}
+
+// TakeErrors returns the errors found since the last call and clears them
+func (l *Lexer) TakeErrors() []customError.SyntaxError {
+	errs := l.errors
+	l.errors = nil
+	return errs
 }
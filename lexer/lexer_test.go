@@ -0,0 +1,353 @@
+package lexer
+
+import (
+	"errors"
+	"io"
+	"pun/error"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// lex returns every token of input up to EOF (not included) with the lexer errors
+func lex(input string) ([]Token, []customError.SyntaxError) {
+	l := NewLexer(input)
+	var tokens []Token
+	for tok := l.NextToken(); tok.Kind != KIND_EOF; tok = l.NextToken() {
+		tokens = append(tokens, tok)
+	}
+	return tokens, l.TakeErrors()
+}
+
+func TestUnicode(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string // Kind và Value của các token
+	}{
+		{"tên = \"Việt\"", []string{"identifier tên", "'=' =", "string Việt"}},
+		{"π * r", []string{"identifier π", "'*' *", "identifier r"}},
+		{"đếm_số_1 - 1", []string{"identifier đếm_số_1", "'-' -", "number 1"}},
+		{"\uFEFFx", []string{"identifier x"}},
+		{"for x in xs", []string{"'for' for", "identifier x", "'in' in", "identifier xs"}},
+	}
+	for _, tt := range tests {
+		tokens, errors := lex(tt.input)
+		if len(errors) > 0 {
+			t.Errorf("%q: unexpected errors %v", tt.input, errors)
+		}
+		var got []string
+		for _, tok := range tokens {
+			got = append(got, tok.Kind.String()+" "+tok.Value)
+		}
+		if strings.Join(got, ", ") != strings.Join(tt.expected, ", ") {
+			t.Errorf("%q: got %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestColumnsCountCharacters(t *testing.T) {
+	tokens, _ := lex("tên = \"Việt\" + x")
+	cols := []int{1, 5, 7, 14, 16}
+	for i, tok := range tokens {
+		if tok.Col != cols[i] {
+			t.Errorf("token %q starts at column %d, want %d", tok.Value, tok.Col, cols[i])
+		}
+	}
+}
+
+func TestInvalidUTF8(t *testing.T) {
+	tests := []struct {
+		input  string
+		values []string // Giá trị các token: byte lỗi bị bỏ qua
+		errors int
+	}{
+		{"x = \xff", []string{"x", "="}, 1},
+		{"a \xfe\xfd + b", []string{"a", "+", "b"}, 2},
+		{"ab\xffcd", []string{"ab", "cd"}, 1},
+		{"\"a\xffb\"", []string{"a\uFFFDb"}, 1},
+		{"x = \"�\" + �", []string{"x", "=", "�", "+", "�"}, 0}, // U+FFFD viết đúng là một ký tự bình thường
+	}
+	for _, tt := range tests {
+		tokens, errors := lex(tt.input)
+		var values []string
+		for _, tok := range tokens {
+			values = append(values, tok.Value)
+		}
+		if strings.Join(values, " ") != strings.Join(tt.values, " ") {
+			t.Errorf("%q: got tokens %q, want %q", tt.input, values, tt.values)
+		}
+		if len(errors) != tt.errors {
+			t.Errorf("%q: got %d errors, want %d", tt.input, len(errors), tt.errors)
+		}
+		for _, err := range errors {
+			if err.Code != customError.InvalidUTF8 {
+				t.Errorf("%q: got error %s, want %s", tt.input, err.Code, customError.InvalidUTF8)
+			}
+		}
+	}
+}
+
+func TestInvalidUTF8Lossless(t *testing.T) {
+	input := "x = \xff 1\n\xfe\ny"
+	l := NewLexer(input)
+	l.Lossless = true
+	var out strings.Builder
+	skipped := 0
+	for {
+		tok := l.NextToken()
+		for _, trivia := range append(append([]Trivia{}, tok.Raw.Leading...), tok.Raw.Trailing...) {
+			if trivia.Kind == TRIVIA_SKIPPED {
+				skipped++
+			}
+		}
+		for _, trivia := range tok.Raw.Leading {
+			out.WriteString(trivia.Text)
+		}
+		out.WriteString(tok.Raw.Text)
+		for _, trivia := range tok.Raw.Trailing {
+			out.WriteString(trivia.Text)
+		}
+		if tok.Kind == KIND_EOF {
+			break
+		}
+	}
+	if out.String() != input {
+		t.Errorf("lossless tokens give back %q, want %q", out.String(), input)
+	}
+	if skipped != 2 {
+		t.Errorf("got %d skipped trivia, want 2", skipped)
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string // Kind và Value của các token
+	}{
+		{`"a${x}b"`, []string{"string a", "identifier x", "string b"}},
+		{`"${price:.2f}"`, []string{"string ", "identifier price", "format spec .2f", "string "}},
+		{`"${f(a, {1: 2})}"`, []string{"string ", "identifier f", "'(' (", "identifier a", "',' ,", "'{' {", "number 1", "':' :", "number 2", "'}' }", "')' )", "string "}},
+		{`"${c ? a : b}"`, []string{"string ", "identifier c", "'?' ?", "identifier a", "':' :", "identifier b", "string "}},
+		{`"${c ? a : b:>8}"`, []string{"string ", "identifier c", "'?' ?", "identifier a", "':' :", "identifier b", "format spec >8", "string "}},
+		{`"${c ? d ? 1 : 2 : 3}"`, []string{"string ", "identifier c", "'?' ?", "identifier d", "'?' ?", "number 1", "':' :", "number 2", "':' :", "number 3", "string "}},
+		{`"${a ?? b:x}"`, []string{"string ", "identifier a", "'??' ??", "identifier b", "format spec x", "string "}},
+		{`"${a?.b:x}"`, []string{"string ", "identifier a", "'?.' ?.", "identifier b", "format spec x", "string "}},
+		{`"${"in ${y}"} z"`, []string{"string ", "string in ", "identifier y", "string ", "string  z"}},
+	}
+	for _, tt := range tests {
+		tokens, errors := lex(tt.input)
+		if len(errors) > 0 {
+			t.Errorf("%s: unexpected errors %v", tt.input, errors)
+		}
+		var got []string
+		for _, tok := range tokens {
+			got = append(got, tok.Kind.String()+" "+tok.Value)
+		}
+		if strings.Join(got, ", ") != strings.Join(tt.expected, ", ") {
+			t.Errorf("%s:\ngot  %q\nwant %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestStrings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string // Giá trị của token chuỗi
+	}{
+		{`"a\tb\nc\r\0"`, "a\tb\nc\r\x00"},
+		{`"\"\\\$\'"`, `"\$'`},
+		{`"\x41\x7a"`, "Az"},
+		{`"\u{1F600} \u{E9}"`, "😀 é"},
+		{`'C:\new\${x}'`, `C:\new\${x}`},
+		{"`line 1\nline 2`", "line 1\nline 2"},
+		{"\"\"\"\n    a\n      b\n    \"\"\"", "a\n  b"}, // Bỏ phần thụt lề của """ đóng
+		{"\"\"\"\n    a\n      b\"\"\"", "a\n  b"},       // """ đóng không nằm riêng một dòng: bỏ phần thụt lề nhỏ nhất
+		{"\"\"\"x \"quoted\"\ny\"\"\"", "x \"quoted\"\ny"},
+	}
+	for _, tt := range tests {
+		tokens, errors := lex(tt.input)
+		if len(errors) > 0 {
+			t.Errorf("%s: unexpected errors %v", tt.input, errors)
+		}
+		if len(tokens) != 1 || tokens[0].Kind != KIND_STRING || tokens[0].Value != tt.expected {
+			t.Errorf("%s: got %v, want one string %q", tt.input, tokens, tt.expected)
+		}
+	}
+}
+
+func TestStringErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []customError.Code
+	}{
+		{`"abc`, []customError.Code{customError.UnterminatedString}},
+		{"\"ab\n", []customError.Code{customError.UnterminatedLineString}},
+		{"'ab\n'", []customError.Code{customError.UnterminatedString, customError.UnterminatedString}},
+		{"\"\"\"ab\n", []customError.Code{customError.UnterminatedString}},
+		{`"\x4"`, []customError.Code{customError.InvalidHexEscape}},
+		{`"\u12"`, []customError.Code{customError.InvalidUnicodeEscape}},
+		{`"\u{1234567}"`, []customError.Code{customError.InvalidUnicodeEscapeDigits}},
+		{`"\u{110000}"`, []customError.Code{customError.InvalidCodePoint}},
+		{`"\u{D800}"`, []customError.Code{customError.InvalidCodePoint}},
+		{`"\q"`, []customError.Code{customError.UnknownEscape}},
+	}
+	for _, tt := range tests {
+		_, errors := lex(tt.input)
+		var codes []customError.Code
+		for _, err := range errors {
+			codes = append(codes, err.Code)
+		}
+		if !slices.Equal(codes, tt.expected) {
+			t.Errorf("%q: got errors %v, want %v", tt.input, codes, tt.expected)
+		}
+	}
+}
+
+func TestNumbers(t *testing.T) {
+	// Token giữ nguyên cách viết; parser chuyển nó thành giá trị
+	tokens, errors := lex("42 1_000.5_5 0xFF_FF 0o17 0b101 1e-3 2.5E+2 2.5d 10n 0xFFn")
+	var values []string
+	for _, tok := range tokens {
+		if tok.Kind != KIND_NUMBER {
+			t.Errorf("%q: got %s, want a number", tok.Value, tok.Kind)
+		}
+		values = append(values, tok.Value)
+	}
+	if len(errors) > 0 || strings.Join(values, " ") != "42 1_000.5_5 0xFF_FF 0o17 0b101 1e-3 2.5E+2 2.5d 10n 0xFFn" {
+		t.Errorf("got numbers %q, errors %v", values, errors)
+	}
+
+	tests := []struct {
+		input    string
+		expected customError.Code
+	}{
+		{"1..2", customError.UnexpectedDotInNumber},
+		{"1.", customError.MissingFractionDigits},
+		{"1e", customError.MissingExponentDigits},
+		{"1.5n", customError.FractionalBigInt},
+		{"0b102", customError.InvalidDigit},
+		{"0x1.5", customError.InvalidDigit},
+		{"0x", customError.NoDigits},
+		{"1__0", customError.MisplacedUnderscore},
+		{"1_", customError.MisplacedUnderscore},
+	}
+	for _, tt := range tests {
+		_, errors := lex(tt.input)
+		if len(errors) != 1 || errors[0].Code != tt.expected {
+			t.Errorf("%q: got errors %v, want %s", tt.input, errors, tt.expected)
+		}
+	}
+}
+
+// streamSource has tokens of every kind, multi-byte characters and a template
+const streamSource = "// chú thích\nfunc tổng(a, b) {\n    return a + b ** 2 // cộng\n}\n" +
+	"x = #{1, 2} ?? #[3]\nprint(\"giá ${x:>8} đồng\", 'raw', 0xFF_FFn, 2.5d)\n"
+
+func TestReaderLexer(t *testing.T) {
+	expected, _ := lex(streamSource)
+
+	// Đọc từng byte một: ký tự nhiều byte và token bị cắt ở giữa các lần Read
+	readers := map[string]io.Reader{
+		"whole":    strings.NewReader(streamSource),
+		"one byte": iotest.OneByteReader(strings.NewReader(streamSource)),
+		"half":     iotest.HalfReader(strings.NewReader(streamSource)),
+	}
+	for name, r := range readers {
+		l := NewReaderLexer("stream.pun", r)
+		var tokens []Token
+		for tok := l.NextToken(); tok.Kind != KIND_EOF; tok = l.NextToken() {
+			tokens = append(tokens, tok)
+		}
+		if errors := l.TakeErrors(); len(errors) > 0 {
+			t.Errorf("%s: unexpected errors %v", name, errors)
+		}
+		if len(tokens) != len(expected) {
+			t.Errorf("%s: got %d tokens, want %d", name, len(tokens), len(expected))
+			continue
+		}
+		for i, tok := range tokens {
+			want := expected[i]
+			if tok.Kind != want.Kind || tok.Value != want.Value || tok.Line != want.Line || tok.Col != want.Col || tok.Offset != want.Offset {
+				t.Errorf("%s: token %d is %s %q at %d:%d, want %s %q at %d:%d", name, i,
+					tok.Kind, tok.Value, tok.Line, tok.Col, want.Kind, want.Value, want.Line, want.Col)
+			}
+		}
+		if l.File() != "stream.pun" {
+			t.Errorf("%s: got file %q", name, l.File())
+		}
+	}
+}
+
+func TestReaderError(t *testing.T) {
+	r := io.MultiReader(strings.NewReader("x = 1\ny"), iotest.ErrReader(errors.New("disk failed")))
+	l := NewReaderLexer("a.pun", r)
+	var values []string
+	for tok := l.NextToken(); tok.Kind != KIND_EOF; tok = l.NextToken() {
+		values = append(values, tok.Value)
+	}
+	errs := l.TakeErrors()
+	if strings.Join(values, " ") != "x = 1 y" {
+		t.Errorf("got tokens %q before the error", values)
+	}
+	// Lỗi đọc chỉ được báo một lần, ở chỗ input dừng lại
+	if len(errs) != 1 || errs[0].Code != customError.ReadError || errs[0].Line != 2 || errs[0].Column != 2 ||
+		!strings.Contains(errs[0].Message, "disk failed") {
+		t.Errorf("got errors %v, want one %s at 2:2", errs, customError.ReadError)
+	}
+}
+
+func TestKinds(t *testing.T) {
+	tests := []struct {
+		kind     Kind
+		expected string
+	}{
+		{KIND_IDENTIFIER, "identifier"},
+		{KIND_NUMBER, "number"},
+		{KIND_PLUS, "'+'"},
+		{KIND_SET_OPEN, "'#{'"},
+		{KIND_IN, "'in'"},
+		{KIND_EOF, "end of file"},
+		{Kind(-1), "unknown token"},
+	}
+	for _, tt := range tests {
+		if got := tt.kind.String(); got != tt.expected {
+			t.Errorf("Kind %d: got %q, want %q", tt.kind, got, tt.expected)
+		}
+	}
+	if !KIND_IN.IsKeyword() || !KIND_MATCH.IsKeyword() || KIND_TRUE.IsKeyword() || KIND_IDENTIFIER.IsKeyword() {
+		t.Errorf("IsKeyword is wrong for in, match, true or identifier")
+	}
+}
+
+func TestComments(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+		errors   int
+	}{
+		{"x /* một */ y // hai\nz", []string{"x", "/* một */", "y", "// hai", "z"}, 0},
+		{"/* a\n * b\n */", []string{"/* a\n * b\n */"}, 0},
+		{"a / b /* c * / d */", []string{"a", "/", "b", "/* c * / d */"}, 0},
+		// Comment chưa đóng chạy đến hết file và báo lỗi ở chỗ mở
+		{"x = 1\n/* abc", []string{"x", "=", "1", "/* abc"}, 1},
+	}
+	for _, tt := range tests {
+		tokens, errors := lex(tt.input)
+		var values []string
+		for _, tok := range tokens {
+			values = append(values, tok.Value)
+		}
+		if !slices.Equal(values, tt.expected) {
+			t.Errorf("%q: got tokens %q, want %q", tt.input, values, tt.expected)
+		}
+		if len(errors) != tt.errors {
+			t.Errorf("%q: got errors %v, want %d", tt.input, errors, tt.errors)
+		}
+	}
+
+	_, errors := lex("x = 1\n/* abc")
+	if len(errors) == 1 && (errors[0].Code != customError.UnterminatedComment || errors[0].Line != 2 || errors[0].Column != 1) {
+		t.Errorf("got error %s at %d:%d, want %s at 2:1", errors[0].Code, errors[0].Line, errors[0].Column, customError.UnterminatedComment)
+	}
+}
@@ -0,0 +1,170 @@
+package lexer
+
+import "pun/error"
+
+// readNumber reads a number literal: 123, 1.5, 1e-9, 1_000_000, 0xFF, 0o17, 0b1010, 123n, 12.30d.
+// The token keeps the source text; the parser converts it to a value.
+func (l *Lexer) readNumber() Token {
+	start := l.position
+	startLine := l.line
+	startCol := l.col
+
+	if err := l.scanNumber(); err != nil {
+		// Literal sai: bỏ qua phần còn lại để không sinh thêm lỗi dây chuyền,
+		// trả về giá trị thay thế để parser tiếp tục
+		for isIdentifierChar(l.ch) || l.ch == '.' {
+			l.nextChar()
+		}
+		l.addError(err.code, err.line, err.col, l.src.slice(start, l.position), err.args...)
+		return Token{Kind: KIND_NUMBER, Value: "0", Line: startLine, Col: startCol}
+	}
+
+	return Token{Kind: KIND_NUMBER, Value: l.src.slice(start, l.position), Line: startLine, Col: startCol}
+}
+
+// numberError points at the offending character of a malformed number literal
+type numberError struct {
+	code customError.Code
+	args []interface{}
+	line int
+	col  int
+}
+
+func (l *Lexer) numberError(code customError.Code, col int, args ...interface{}) *numberError {
+	return &numberError{code: code, args: args, line: l.line, col: col}
+}
+
+// scanNumber consumes a number literal, returning an error if it is malformed
+func (l *Lexer) scanNumber() *numberError {
+	if l.ch == '0' {
+		switch l.peekChar() {
+		case 'x', 'X':
+			return l.scanPrefixedNumber(16, "hexadecimal")
+		case 'o', 'O':
+			return l.scanPrefixedNumber(8, "octal")
+		case 'b', 'B':
+			return l.scanPrefixedNumber(2, "binary")
+		}
+	}
+
+	// Phần nguyên
+	if err := l.scanDigits(10); err != nil {
+		return err
+	}
+
+	integer := true
+
+	// Phần thập phân
+	if l.ch == '.' {
+		integer = false
+		if l.peekChar() == '.' {
+			l.nextChar()
+			return l.numberError(customError.UnexpectedDotInNumber, l.col)
+		}
+		if !isDigit(l.peekChar()) {
+			return l.numberError(customError.MissingFractionDigits, l.col+1)
+		}
+		l.nextChar() // Bỏ qua '.'
+		if err := l.scanDigits(10); err != nil {
+			return err
+		}
+	}
+
+	// Số mũ
+	if l.ch == 'e' || l.ch == 'E' {
+		integer = false
+		l.nextChar()
+		if l.ch == '+' || l.ch == '-' {
+			l.nextChar()
+		}
+		if !isDigit(l.ch) {
+			return l.numberError(customError.MissingExponentDigits, l.col)
+		}
+		if err := l.scanDigits(10); err != nil {
+			return err
+		}
+	}
+
+	if l.ch == '.' {
+		return l.numberError(customError.UnexpectedDotInNumber, l.col)
+	}
+	if err := l.scanBigIntSuffix(integer); err != nil {
+		return err
+	}
+	if l.ch == 'd' && !isIdentifierChar(l.peekChar()) {
+		l.nextChar() // Hậu tố decimal: 12.30d
+	}
+	if isIdentifierChar(l.ch) {
+		return l.numberError(customError.InvalidNumberCharacter, l.col, l.ch)
+	}
+	return nil
+}
+
+// scanBigIntSuffix consumes the 'n' of a bigint literal (123n, 0xFFn)
+func (l *Lexer) scanBigIntSuffix(integer bool) *numberError {
+	if l.ch != 'n' || isIdentifierChar(l.peekChar()) {
+		return nil
+	}
+	if !integer {
+		return l.numberError(customError.FractionalBigInt, l.col)
+	}
+	l.nextChar()
+	return nil
+}
+
+// scanPrefixedNumber reads 0x.., 0o.. or 0b.. literals
+func (l *Lexer) scanPrefixedNumber(base int, name string) *numberError {
+	l.nextChar() // Bỏ qua '0'
+	l.nextChar() // Bỏ qua x/o/b
+
+	if l.ch == '_' {
+		l.nextChar() // Cho phép 0x_FF giống Go
+	}
+	if digitValue(l.ch) >= base || digitValue(l.ch) < 0 {
+		if isIdentifierChar(l.ch) {
+			return l.numberError(customError.InvalidDigit, l.col, l.ch, name)
+		}
+		return l.numberError(customError.NoDigits, l.col, name)
+	}
+	if err := l.scanDigits(base); err != nil {
+		return err
+	}
+	if err := l.scanBigIntSuffix(true); err != nil {
+		return err
+	}
+
+	if isIdentifierChar(l.ch) || l.ch == '.' {
+		return l.numberError(customError.InvalidDigit, l.col, l.ch, name)
+	}
+	return nil
+}
+
+// scanDigits reads digits of the given base, allowing '_' between two digits
+func (l *Lexer) scanDigits(base int) *numberError {
+	for {
+		if l.ch == '_' {
+			if d := digitValue(l.peekChar()); d < 0 || d >= base {
+				return l.numberError(customError.MisplacedUnderscore, l.col)
+			}
+			l.nextChar()
+			continue
+		}
+		if d := digitValue(l.ch); d < 0 || d >= base {
+			return nil
+		}
+		l.nextChar()
+	}
+}
+
+// digitValue returns the value of a digit in bases up to 16, or -1
+func digitValue(ch rune) int {
+	switch {
+	case ch >= '0' && ch <= '9':
+		return int(ch - '0')
+	case ch >= 'a' && ch <= 'f':
+		return int(ch-'a') + 10
+	case ch >= 'A' && ch <= 'F':
+		return int(ch-'A') + 10
+	}
+	return -1
+}
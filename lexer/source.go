@@ -0,0 +1,107 @@
+package lexer
+
+import (
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Số byte đọc mỗi lần từ io.Reader
+const chunkSize = 64 * 1024
+
+// source is the input of a lexer. It reads from an io.Reader in chunks as the lexer asks for
+// more, and forgets the bytes of tokens that are already finished, so a large script never has
+// to be held in memory all at once. Offsets are always counted from the start of the input.
+type source struct {
+	r     io.Reader       // nil nếu toàn bộ input đã nằm trong text
+	buf   strings.Builder // Chứa các byte đã đọc, chỉ ghi thêm vào cuối
+	text  string          // Phần input đang giữ (buf.String()); token là chuỗi con của nó, không phải copy
+	base  int             // Offset của text[0] trong input
+	chunk []byte          // Buffer cho mỗi lần Read
+	err   error           // Lỗi (hoặc io.EOF) của lần đọc cuối, sau đó không đọc nữa
+}
+
+func newStringSource(input string) *source {
+	return &source{text: input}
+}
+
+func newReaderSource(r io.Reader) *source {
+	return &source{r: r, chunk: make([]byte, chunkSize)}
+}
+
+// has reports whether the input is at least end bytes long, reading more if needed
+func (s *source) has(end int) bool {
+	for end > s.base+len(s.text) {
+		if s.r == nil || s.err != nil {
+			return false
+		}
+		n, err := s.r.Read(s.chunk)
+		if n > 0 {
+			s.buf.Write(s.chunk[:n])
+			s.text = s.buf.String()
+		}
+		if err != nil {
+			s.err = err
+		}
+	}
+	return true
+}
+
+// byteAt returns the byte at offset off, or 0 past the end of the input
+func (s *source) byteAt(off int) byte {
+	if !s.has(off + 1) {
+		return 0
+	}
+	return s.text[off-s.base]
+}
+
+// runeAt decodes the character at offset off. The width is 0 past the end of the input.
+func (s *source) runeAt(off int) (rune, int) {
+	if i := off - s.base; i < len(s.text) && s.text[i] < utf8.RuneSelf {
+		return rune(s.text[i]), 1 // ASCII: không cần đọc thêm hay giải mã
+	}
+	s.has(off + utf8.UTFMax) // Ký tự nhiều byte có thể nằm vắt qua hai lần đọc
+	if off-s.base >= len(s.text) {
+		return 0, 0
+	}
+	return utf8.DecodeRuneInString(s.text[off-s.base:])
+}
+
+// slice returns the text between two offsets that have already been read
+func (s *source) slice(from, to int) string {
+	return s.text[from-s.base : to-s.base]
+}
+
+// rest returns the input from offset off to the end, reading all of it
+func (s *source) rest(off int) string {
+	for end := off; s.has(end + 1); {
+		end = s.base + len(s.text)
+	}
+	return s.text[off-s.base:]
+}
+
+// discard lets the source forget the input before offset off (it is never read again)
+func (s *source) discard(off int) {
+	n := off - s.base
+	// Chỉ chuyển sang buffer mới khi bỏ được nhiều: chi phí copy không vượt quá số byte bỏ đi.
+	// Buffer cũ vẫn còn nếu token nào đó giữ chuỗi con của nó.
+	if s.r == nil || n < chunkSize || 2*n < len(s.text) {
+		return
+	}
+	rest := s.text[n:]
+	s.buf = strings.Builder{}
+	s.buf.Grow(len(rest) + chunkSize)
+	s.buf.WriteString(rest)
+	s.text = s.buf.String()
+	s.base = off
+}
+
+// readError returns the error that stopped reading, once, unless it is the normal end of input
+func (s *source) readError() error {
+	err := s.err
+	if err == nil || err == io.EOF {
+		return nil
+	}
+	s.err = io.EOF
+	return err
+}
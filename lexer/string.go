@@ -0,0 +1,324 @@
+package lexer
+
+import (
+	"pun/error"
+	"strconv"
+	"strings"
+)
+
+// stringLiteral describes the string literal currently being read
+type stringLiteral struct {
+	triple      bool // """...""" (chuỗi nhiều dòng)
+	indent      int  // Số ký tự thụt lề bị bỏ ở đầu mỗi dòng (chỉ dùng cho """)
+	closingLine int  // Vị trí '\n' ngay trước """ đóng nếu """ nằm riêng một dòng, -1 nếu không
+	line        int  // Dòng mở chuỗi (dùng để báo lỗi)
+	col         int  // Cột mở chuỗi (dùng để báo lỗi)
+}
+
+// template is an open ${...} inside a string literal
+type template struct {
+	depth     int           // Độ sâu ngoặc bên trong ${...}
+	questions int           // Số dấu ? của biểu thức điều kiện chưa gặp dấu : (${ok ? a : b})
+	str       stringLiteral // Chuỗi chứa ${...} này, để đọc tiếp sau dấu }
+}
+
+// readString reads a "..." or """...""" string and supports escape sequences.
+// If the string contains ${...} it is split into a template token sequence.
+func (l *Lexer) readString() Token {
+	str := stringLiteral{line: l.line, col: l.col, closingLine: -1}
+
+	if l.peekChar() == '"' && l.peekCharAt(2) == '"' {
+		str.triple = true
+		l.nextChar()
+		l.nextChar()
+		l.nextChar() // Bỏ qua """ mở
+		l.prepareTripleQuoted(&str)
+	} else {
+		l.nextChar() // Skip opening quote
+	}
+
+	value, interpolated := l.readStringContent(str)
+
+	if interpolated {
+		return Token{Kind: KIND_TEMPLATE_HEAD, Value: value, Line: str.line, Col: str.col}
+	}
+	return Token{Kind: KIND_STRING, Value: value, Line: str.line, Col: str.col}
+}
+
+// readRawString reads a '...' or `...` string. Raw strings have no escapes and no interpolation;
+// only backtick strings may span several lines.
+func (l *Lexer) readRawString() Token {
+	startLine := l.line
+	startCol := l.col
+	quote := l.ch
+
+	l.nextChar() // Bỏ qua dấu mở
+	start := l.position
+	for l.ch != quote {
+		if l.ch == 0 || (l.ch == '\n' && quote == '\'') {
+			l.addError(customError.UnterminatedString, startLine, startCol, string(quote)+l.src.slice(start, l.position))
+			return Token{Kind: KIND_STRING, Value: l.src.slice(start, l.position), Line: startLine, Col: startCol}
+		}
+		l.nextChar()
+	}
+	value := l.src.slice(start, l.position)
+	l.nextChar() // Bỏ qua dấu đóng
+
+	return Token{Kind: KIND_STRING, Value: value, Line: startLine, Col: startCol}
+}
+
+// prepareTripleQuoted drops the newline after the opening """ and works out how much
+// indentation to strip: the indentation of the closing """ if it sits on its own line,
+// otherwise the smallest indentation of all non-blank lines.
+func (l *Lexer) prepareTripleQuoted(str *stringLiteral) {
+	// Bỏ xuống dòng ngay sau """ mở
+	i := l.position
+	for ch := l.src.byteAt(i); ch == ' ' || ch == '\t' || ch == '\r'; ch = l.src.byteAt(i) {
+		i++
+	}
+	skippedNewline := l.src.byteAt(i) == '\n'
+	if skippedNewline {
+		for l.position <= i {
+			l.nextChar()
+		}
+	}
+
+	end := findTripleQuote(l.src, l.position)
+	content := l.src.slice(l.position, end)
+
+	if last := strings.LastIndex(content, "\n"); last >= 0 && strings.Trim(content[last+1:], " \t\r") == "" {
+		str.closingLine = l.position + last
+		str.indent = len(content) - last - 1
+	} else {
+		lines := strings.Split(content, "\n")
+		if !skippedNewline {
+			lines = lines[1:] // Dòng đầu nằm cùng dòng với """ mở
+		}
+		str.indent = -1
+		for _, line := range lines {
+			trimmed := strings.TrimLeft(line, " \t")
+			if strings.TrimSpace(trimmed) == "" {
+				continue
+			}
+			if indent := len(line) - len(trimmed); str.indent < 0 || indent < str.indent {
+				str.indent = indent
+			}
+		}
+		if str.indent < 0 {
+			str.indent = 0
+		}
+	}
+
+	if skippedNewline {
+		l.skipIndent(str.indent)
+	}
+}
+
+// findTripleQuote returns the position of the closing """ (or the end of input)
+func findTripleQuote(src *source, from int) int {
+	i := from
+	for ; src.has(i + 3); i++ {
+		if src.byteAt(i) == '\\' {
+			i++
+			continue
+		}
+		if src.byteAt(i) == '"' && src.byteAt(i+1) == '"' && src.byteAt(i+2) == '"' {
+			return i
+		}
+	}
+	return src.base + len(src.text) // has đã đọc hết input
+}
+
+// skipIndent skips up to n spaces or tabs at the start of a line
+func (l *Lexer) skipIndent(n int) {
+	for i := 0; i < n && (l.ch == ' ' || l.ch == '\t'); i++ {
+		l.nextChar()
+	}
+}
+
+// readStringContent reads characters up to the closing quote or up to the next "${".
+// It returns true if it stopped at "${" (the lexer is then inside an interpolation).
+func (l *Lexer) readStringContent(str stringLiteral) (string, bool) {
+	var strBuilder []rune
+
+	for {
+		switch {
+		case l.ch == 0:
+			l.addError(customError.UnterminatedString, str.line, str.col, string(strBuilder))
+			return string(strBuilder), false
+
+		case !str.triple && l.ch == '"':
+			l.nextChar() // Skip closing quote
+			return string(strBuilder), false
+
+		case !str.triple && l.ch == '\n':
+			// Chuỗi thường không được xuống dòng => dùng """ cho chuỗi nhiều dòng
+			l.addError(customError.UnterminatedLineString, str.line, str.col, string(strBuilder))
+			return string(strBuilder), false
+
+		case str.triple && l.ch == '"' && l.peekChar() == '"' && l.peekCharAt(2) == '"':
+			l.nextChar()
+			l.nextChar()
+			l.nextChar() // Bỏ qua """ đóng
+			return string(strBuilder), false
+
+		case l.ch == '$' && l.peekChar() == '{':
+			// Bắt đầu một biểu thức nội suy
+			l.nextChar() // Bỏ qua '$'
+			l.nextChar() // Bỏ qua '{'
+			l.templates = append(l.templates, template{str: str})
+			return string(strBuilder), true
+
+		case l.ch == '\\':
+			strBuilder = l.readEscape(strBuilder, str)
+
+		case l.ch == '\r' && l.peekChar() == '\n' && str.triple:
+			l.nextChar() // CRLF được xem như LF
+
+		case l.ch == '\n' && str.triple:
+			newlinePos := l.position
+			l.nextChar()
+			// Xuống dòng cuối cùng trước """ đóng không thuộc nội dung chuỗi
+			if newlinePos == str.closingLine {
+				for l.ch == ' ' || l.ch == '\t' || l.ch == '\r' {
+					l.nextChar()
+				}
+				continue
+			}
+			strBuilder = append(strBuilder, '\n')
+			l.skipIndent(str.indent)
+
+		default:
+			strBuilder = append(strBuilder, l.ch)
+			l.nextChar()
+		}
+	}
+}
+
+// readEscape reads one escape sequence starting at '\' and appends the decoded character
+func (l *Lexer) readEscape(strBuilder []rune, str stringLiteral) []rune {
+	l.nextChar() // Bỏ qua '\'
+
+	switch l.ch {
+	case 'n':
+		strBuilder = append(strBuilder, '\n')
+	case 't':
+		strBuilder = append(strBuilder, '\t')
+	case 'r':
+		strBuilder = append(strBuilder, '\r')
+	case '0':
+		strBuilder = append(strBuilder, 0)
+	case '"', '\\', '$', '\'':
+		strBuilder = append(strBuilder, l.ch)
+	case 'x':
+		// \xHH: đúng 2 chữ số hex
+		digits := ""
+		for len(digits) < 2 && isHexDigit(l.peekChar()) {
+			l.nextChar()
+			digits += string(l.ch)
+		}
+		if len(digits) != 2 {
+			l.addError(customError.InvalidHexEscape, str.line, str.col, "\\x"+digits)
+			break
+		}
+		value, _ := strconv.ParseUint(digits, 16, 8)
+		strBuilder = append(strBuilder, rune(value))
+	case 'u':
+		// \u{XXXX}: 1 đến 6 chữ số hex
+		if l.peekChar() != '{' {
+			l.addError(customError.InvalidUnicodeEscape, str.line, str.col, "\\u")
+			break
+		}
+		l.nextChar() // Bỏ qua '{'
+		digits := ""
+		for isHexDigit(l.peekChar()) {
+			l.nextChar()
+			digits += string(l.ch)
+		}
+		if l.peekChar() != '}' || len(digits) == 0 || len(digits) > 6 {
+			l.addError(customError.InvalidUnicodeEscapeDigits, str.line, str.col, "\\u{"+digits)
+			break
+		}
+		l.nextChar() // Bỏ qua '}'
+		value, _ := strconv.ParseUint(digits, 16, 32)
+		if value > 0x10FFFF || (value >= 0xD800 && value <= 0xDFFF) {
+			l.addError(customError.InvalidCodePoint, str.line, str.col, "\\u{"+digits+"}", value)
+			break
+		}
+		strBuilder = append(strBuilder, rune(value))
+	case 0:
+		return strBuilder // Hết input, readStringContent sẽ báo lỗi chuỗi chưa đóng
+	default:
+		l.addError(customError.UnknownEscape, str.line, str.col, "\\"+string(l.ch), l.ch)
+		strBuilder = append(strBuilder, l.ch)
+	}
+
+	l.nextChar()
+	return strBuilder
+}
+
+// readTemplateContinuation reads the string part after the '}' that closes an interpolation
+func (l *Lexer) readTemplateContinuation() Token {
+	startLine := l.line
+	startCol := l.col
+
+	str := l.templates[len(l.templates)-1].str
+	l.templates = l.templates[:len(l.templates)-1]
+	l.nextChar() // Bỏ qua '}'
+	value, interpolated := l.readStringContent(str)
+
+	if interpolated {
+		return Token{Kind: KIND_TEMPLATE_MIDDLE, Value: value, Line: startLine, Col: startCol}
+	}
+	return Token{Kind: KIND_TEMPLATE_TAIL, Value: value, Line: startLine, Col: startCol}
+}
+
+// readFormatSpec reads the format spec between ':' and the closing '}' of an interpolation
+func (l *Lexer) readFormatSpec() Token {
+	startLine := l.line
+	startCol := l.col
+
+	l.nextChar() // Bỏ qua ':'
+	start := l.position
+	for l.ch != '}' && l.ch != '"' && l.ch != '\n' && l.ch != 0 {
+		l.nextChar()
+	}
+
+	return Token{Kind: KIND_FORMAT_SPEC, Value: l.src.slice(start, l.position), Line: startLine, Col: startCol}
+}
+
+// inTemplateExpression reports whether the lexer is directly inside ${...} (not inside nested brackets)
+func (l *Lexer) inTemplateExpression() bool {
+	return len(l.templates) > 0 && l.templates[len(l.templates)-1].depth == 0
+}
+
+// startsFormatSpec reports whether the ':' at the current position starts the format spec of an
+// interpolation. A ':' that ends the "? a" of a conditional expression does not.
+func (l *Lexer) startsFormatSpec() bool {
+	if !l.inTemplateExpression() {
+		return false
+	}
+	if t := &l.templates[len(l.templates)-1]; t.questions > 0 {
+		t.questions--
+		return false
+	}
+	return true
+}
+
+// openBracket/closeBracket track bracket depth inside an interpolation
+// so that '}' or ':' of a nested expression does not end it
+func (l *Lexer) openBracket() {
+	if len(l.templates) > 0 {
+		l.templates[len(l.templates)-1].depth++
+	}
+}
+
+func (l *Lexer) closeBracket() {
+	if len(l.templates) > 0 && l.templates[len(l.templates)-1].depth > 0 {
+		l.templates[len(l.templates)-1].depth--
+	}
+}
+
+func isHexDigit(ch rune) bool {
+	return (ch >= '0' && ch <= '9') || (ch >= 'a' && ch <= 'f') || (ch >= 'A' && ch <= 'F')
+}
@@ -1,87 +1,310 @@
 package lexer
 
-// Token types
+// Token types. Each Kind belongs to one of them (see Kind.Type).
 const (
-	TOKEN_EOF        = "EOF"
-	TOKEN_IDENTIFIER = "IDENTIFIER"
-	TOKEN_NUMBER     = "NUMBER"
-	TOKEN_STRING     = "STRING"
-	TOKEN_KEYWORD    = "KEYWORD"
-	TOKEN_ASSIGN     = "ASSIGN"
-	TOKEN_LPAREN     = "LPAREN"
-	TOKEN_RPAREN     = "RPAREN"
-	TOKEN_COMMA      = "COMMA"
-	TOKEN_DOT        = "DOT"
-	TOKEN_BOOLEAN    = "BOOLEAN"
-	TOKEN_LCURLY     = "LCURLY"
-	TOKEN_RCURLY     = "RCURLY"
-	TOKEN_LSQUARE    = "LSQUARE"
-	TOKEN_RSQUARE    = "RSQUARE"
-	TOKEN_SEMICOLON  = "SEMICOLON"
-	TOKEN_COMMENT    = "COMMENT"
-	TOKEN_NOTHING    = "NOTHING"
-	TOKEN_ARITHMETIC = "ARITHMETIC" // + - * / % **
-	TOKEN_COMPARISON = "COMPARISON" // == != > < >= <=
-	TOKEN_LOGICAL    = "LOGICAL"    // && || !
-	TOKEN_BITWISE    = "BITWISE"    // & | ^ ~ << >>
-	TOKEN_UNKNOWN    = "UNKNOWN"
+	TOKEN_EOF               = "EOF"
+	TOKEN_IDENTIFIER        = "IDENTIFIER"
+	TOKEN_NUMBER            = "NUMBER"
+	TOKEN_STRING            = "STRING"
+	TOKEN_KEYWORD           = "KEYWORD"
+	TOKEN_ASSIGN            = "ASSIGN"
+	TOKEN_LPAREN            = "LPAREN"
+	TOKEN_RPAREN            = "RPAREN"
+	TOKEN_COMMA             = "COMMA"
+	TOKEN_DOT               = "DOT"
+	TOKEN_BOOLEAN           = "BOOLEAN"
+	TOKEN_LCURLY            = "LCURLY"
+	TOKEN_RCURLY            = "RCURLY"
+	TOKEN_LSQUARE           = "LSQUARE"
+	TOKEN_RSQUARE           = "RSQUARE"
+	TOKEN_SEMICOLON         = "SEMICOLON"
+	TOKEN_COMMENT           = "COMMENT"
+	TOKEN_NOTHING           = "NOTHING"
+	TOKEN_ARITHMETIC        = "ARITHMETIC"        // + - * / % **
+	TOKEN_COMPARISON        = "COMPARISON"        // == != > < >= <=
+	TOKEN_LOGICAL           = "LOGICAL"           // && || !
+	TOKEN_BITWISE           = "BITWISE"           // & | ^ ~ << >>
+	TOKEN_QUESTION          = "QUESTION"          // ? (cond ? a : b)
+	TOKEN_COLON             = "COLON"             // :
+	TOKEN_WALRUS            = "WALRUS"            // := (gán trong biểu thức)
+	TOKEN_NULLISH           = "NULLISH"           // ??
+	TOKEN_OPT_DOT           = "OPT_DOT"           // ?.
+	TOKEN_SET_OPEN          = "SET_OPEN"          // #{ (mở set literal, đóng bằng })
+	TOKEN_FROZEN_ARRAY_OPEN = "FROZEN_ARRAY_OPEN" // #[ (mở array bất biến, đóng bằng ])
+	TOKEN_UNKNOWN           = "UNKNOWN"
+
+	// String interpolation: "a ${x} b ${y:.2f} c" được tách thành
+	// TEMPLATE_HEAD("a ") x TEMPLATE_MIDDLE(" b ") y FORMAT_SPEC(".2f") TEMPLATE_TAIL(" c")
+	TOKEN_TEMPLATE_HEAD   = "TEMPLATE_HEAD"
+	TOKEN_TEMPLATE_MIDDLE = "TEMPLATE_MIDDLE"
+	TOKEN_TEMPLATE_TAIL   = "TEMPLATE_TAIL"
+	TOKEN_FORMAT_SPEC     = "FORMAT_SPEC"
+)
+
+// Kind identifies a token. Every keyword and every operator has a kind of its own,
+// so the parser can match tokens without comparing strings.
+type Kind int
+
+const (
+	KIND_EOF Kind = iota
+	KIND_IDENTIFIER
+	KIND_NUMBER
+	KIND_STRING
+	KIND_TEMPLATE_HEAD
+	KIND_TEMPLATE_MIDDLE
+	KIND_TEMPLATE_TAIL
+	KIND_FORMAT_SPEC
+	KIND_COMMENT
+	KIND_UNKNOWN
+
+	// Dấu ngoặc và dấu câu
+	KIND_LPAREN            // (
+	KIND_RPAREN            // )
+	KIND_LCURLY            // {
+	KIND_RCURLY            // }
+	KIND_LSQUARE           // [
+	KIND_RSQUARE           // ]
+	KIND_SET_OPEN          // #{
+	KIND_FROZEN_ARRAY_OPEN // #[
+	KIND_COMMA             // ,
+	KIND_DOT               // .
+	KIND_SEMICOLON         // ;
+
+	// Từ khóa
+	KIND_IF
+	KIND_ELIF
+	KIND_ELSE
+	KIND_BREAK
+	KIND_CONTINUE
+	KIND_RETURN
+	KIND_FOR
+	KIND_IN
+	KIND_WHILE
+	KIND_UNTIL
+	KIND_FUNC
+	KIND_DEFER
+	KIND_RECORD
+	KIND_ENUM
+	KIND_MATCH
+	KIND_TRUE
+	KIND_FALSE
+	KIND_NOTHING
+
+	// Toán tử
+	KIND_ASSIGN      // =
+	KIND_PLUS        // +
+	KIND_MINUS       // -
+	KIND_STAR        // *
+	KIND_SLASH       // /
+	KIND_PERCENT     // %
+	KIND_POWER       // **
+	KIND_EQ          // ==
+	KIND_NOT_EQ      // !=
+	KIND_GT          // >
+	KIND_LT          // <
+	KIND_GT_EQ       // >=
+	KIND_LT_EQ       // <=
+	KIND_AND         // &&
+	KIND_OR          // ||
+	KIND_NOT         // !
+	KIND_QUESTION    // ?
+	KIND_COLON       // :
+	KIND_WALRUS      // :=
+	KIND_NULLISH     // ??
+	KIND_OPT_DOT     // ?.
+	KIND_BIT_AND     // &
+	KIND_BIT_OR      // |
+	KIND_BIT_XOR     // ^
+	KIND_BIT_NOT     // ~
+	KIND_SHIFT_LEFT  // <<
+	KIND_SHIFT_RIGHT // >>
+
+	kindCount
 )
 
+// kindInfo is the source text of a kind (empty if it has none) and its token type
+type kindInfo struct {
+	text      string
+	tokenType string
+}
+
+var kinds = [kindCount]kindInfo{
+	KIND_EOF:             {"", TOKEN_EOF},
+	KIND_IDENTIFIER:      {"", TOKEN_IDENTIFIER},
+	KIND_NUMBER:          {"", TOKEN_NUMBER},
+	KIND_STRING:          {"", TOKEN_STRING},
+	KIND_TEMPLATE_HEAD:   {"", TOKEN_TEMPLATE_HEAD},
+	KIND_TEMPLATE_MIDDLE: {"", TOKEN_TEMPLATE_MIDDLE},
+	KIND_TEMPLATE_TAIL:   {"", TOKEN_TEMPLATE_TAIL},
+	KIND_FORMAT_SPEC:     {"", TOKEN_FORMAT_SPEC},
+	KIND_COMMENT:         {"", TOKEN_COMMENT},
+	KIND_UNKNOWN:         {"", TOKEN_UNKNOWN},
+
+	KIND_LPAREN:            {"(", TOKEN_LPAREN},
+	KIND_RPAREN:            {")", TOKEN_RPAREN},
+	KIND_LCURLY:            {"{", TOKEN_LCURLY},
+	KIND_RCURLY:            {"}", TOKEN_RCURLY},
+	KIND_LSQUARE:           {"[", TOKEN_LSQUARE},
+	KIND_RSQUARE:           {"]", TOKEN_RSQUARE},
+	KIND_SET_OPEN:          {"#{", TOKEN_SET_OPEN},
+	KIND_FROZEN_ARRAY_OPEN: {"#[", TOKEN_FROZEN_ARRAY_OPEN},
+	KIND_COMMA:             {",", TOKEN_COMMA},
+	KIND_DOT:               {".", TOKEN_DOT},
+	KIND_SEMICOLON:         {";", TOKEN_SEMICOLON},
+
+	KIND_IF:       {"if", TOKEN_KEYWORD},
+	KIND_ELIF:     {"elif", TOKEN_KEYWORD},
+	KIND_ELSE:     {"else", TOKEN_KEYWORD},
+	KIND_BREAK:    {"break", TOKEN_KEYWORD},
+	KIND_CONTINUE: {"continue", TOKEN_KEYWORD},
+	KIND_RETURN:   {"return", TOKEN_KEYWORD},
+	KIND_FOR:      {"for", TOKEN_KEYWORD},
+	KIND_IN:       {"in", TOKEN_KEYWORD},
+	KIND_WHILE:    {"while", TOKEN_KEYWORD},
+	KIND_UNTIL:    {"until", TOKEN_KEYWORD},
+	KIND_FUNC:     {"func", TOKEN_KEYWORD},
+	KIND_DEFER:    {"defer", TOKEN_KEYWORD},
+	KIND_RECORD:   {"record", TOKEN_KEYWORD},
+	KIND_ENUM:     {"enum", TOKEN_KEYWORD},
+	KIND_MATCH:    {"match", TOKEN_KEYWORD},
+	KIND_TRUE:     {"true", TOKEN_BOOLEAN},
+	KIND_FALSE:    {"false", TOKEN_BOOLEAN},
+	KIND_NOTHING:  {"nothing", TOKEN_NOTHING},
+
+	KIND_ASSIGN:      {"=", TOKEN_ASSIGN},
+	KIND_PLUS:        {"+", TOKEN_ARITHMETIC},
+	KIND_MINUS:       {"-", TOKEN_ARITHMETIC},
+	KIND_STAR:        {"*", TOKEN_ARITHMETIC},
+	KIND_SLASH:       {"/", TOKEN_ARITHMETIC},
+	KIND_PERCENT:     {"%", TOKEN_ARITHMETIC},
+	KIND_POWER:       {"**", TOKEN_ARITHMETIC},
+	KIND_EQ:          {"==", TOKEN_COMPARISON},
+	KIND_NOT_EQ:      {"!=", TOKEN_COMPARISON},
+	KIND_GT:          {">", TOKEN_COMPARISON},
+	KIND_LT:          {"<", TOKEN_COMPARISON},
+	KIND_GT_EQ:       {">=", TOKEN_COMPARISON},
+	KIND_LT_EQ:       {"<=", TOKEN_COMPARISON},
+	KIND_AND:         {"&&", TOKEN_LOGICAL},
+	KIND_OR:          {"||", TOKEN_LOGICAL},
+	KIND_NOT:         {"!", TOKEN_LOGICAL},
+	KIND_QUESTION:    {"?", TOKEN_QUESTION},
+	KIND_COLON:       {":", TOKEN_COLON},
+	KIND_WALRUS:      {":=", TOKEN_WALRUS},
+	KIND_NULLISH:     {"??", TOKEN_NULLISH},
+	KIND_OPT_DOT:     {"?.", TOKEN_OPT_DOT},
+	KIND_BIT_AND:     {"&", TOKEN_BITWISE},
+	KIND_BIT_OR:      {"|", TOKEN_BITWISE},
+	KIND_BIT_XOR:     {"^", TOKEN_BITWISE},
+	KIND_BIT_NOT:     {"~", TOKEN_BITWISE},
+	KIND_SHIFT_LEFT:  {"<<", TOKEN_BITWISE},
+	KIND_SHIFT_RIGHT: {">>", TOKEN_BITWISE},
+}
+
+// Tên đọc được của các kind không có văn bản cố định, dùng trong thông báo lỗi
+var kindNames = map[Kind]string{
+	KIND_EOF:             "end of file",
+	KIND_IDENTIFIER:      "identifier",
+	KIND_NUMBER:          "number",
+	KIND_STRING:          "string",
+	KIND_TEMPLATE_HEAD:   "string",
+	KIND_TEMPLATE_MIDDLE: "string",
+	KIND_TEMPLATE_TAIL:   "string",
+	KIND_FORMAT_SPEC:     "format spec",
+	KIND_COMMENT:         "comment",
+	KIND_UNKNOWN:         "unknown token",
+}
+
+// String describes the kind for error messages: 'if', ')', identifier, end of file
+func (k Kind) String() string {
+	if k < 0 || k >= kindCount {
+		return "unknown token"
+	}
+	if text := kinds[k].text; text != "" {
+		return "'" + text + "'"
+	}
+	return kindNames[k]
+}
+
+// Type returns the token type of the kind (KEYWORD for every keyword, ARITHMETIC for + - * ...)
+func (k Kind) Type() string {
+	if k < 0 || k >= kindCount {
+		return TOKEN_UNKNOWN
+	}
+	return kinds[k].tokenType
+}
+
+// IsKeyword reports whether the kind is a keyword (true, false and nothing are literals, not keywords)
+func (k Kind) IsKeyword() bool {
+	return k >= KIND_IF && k <= KIND_MATCH
+}
+
 // Keywords in Pun
-var keywords = map[string]string{
-	"if":       TOKEN_KEYWORD,
-	"elif":     TOKEN_KEYWORD,
-	"else":     TOKEN_KEYWORD,
-	"break":    TOKEN_KEYWORD,
-	"continue": TOKEN_KEYWORD,
-	"return":   TOKEN_KEYWORD,
-	"for":      TOKEN_KEYWORD,
-	"while":    TOKEN_KEYWORD,
-	"until":    TOKEN_KEYWORD,
-	"func":     TOKEN_KEYWORD,
-	"true":     TOKEN_BOOLEAN,
-	"false":    TOKEN_BOOLEAN,
-	"nothing":  TOKEN_NOTHING,
+var keywords = map[string]Kind{}
+
+// Operators in Pun (cả các dấu chỉ có một ký tự như ? và :)
+var operators = map[string]Kind{}
+
+func init() {
+	for k := KIND_IF; k <= KIND_NOTHING; k++ {
+		keywords[kinds[k].text] = k
+	}
+	for k := KIND_ASSIGN; k <= KIND_SHIFT_RIGHT; k++ {
+		operators[kinds[k].text] = k
+	}
 }
 
-var operators = map[string]string{
-	//Gán
-	"=": TOKEN_ASSIGN,
-
-	// Số học
-	"+":  TOKEN_ARITHMETIC,
-	"-":  TOKEN_ARITHMETIC,
-	"*":  TOKEN_ARITHMETIC,
-	"/":  TOKEN_ARITHMETIC,
-	"%":  TOKEN_ARITHMETIC,
-	"**": TOKEN_ARITHMETIC,
-
-	// So sánh
-	"==": TOKEN_COMPARISON,
-	"!=": TOKEN_COMPARISON,
-	">":  TOKEN_COMPARISON,
-	"<":  TOKEN_COMPARISON,
-	">=": TOKEN_COMPARISON,
-	"<=": TOKEN_COMPARISON,
-
-	// Logic
-	"&&": TOKEN_LOGICAL,
-	"||": TOKEN_LOGICAL,
-	"!":  TOKEN_LOGICAL,
-
-	// Bitwise (nếu cần)
-	"&":  TOKEN_BITWISE,
-	"|":  TOKEN_BITWISE,
-	"^":  TOKEN_BITWISE,
-	"~":  TOKEN_BITWISE,
-	"<<": TOKEN_BITWISE,
-	">>": TOKEN_BITWISE,
+// Position is a place in the source: a byte offset (from 0), a line and a column
+// (from 1, counted in characters)
+type Position struct {
+	Offset int
+	Line   int
+	Col    int
 }
 
-// Token structure
+// Token structure. Line and Col are where the token starts, End is just after its last character.
 type Token struct {
-	Type  string
-	Value string
-	Line  int
-	Col   int
+	Kind   Kind
+	Value  string
+	Line   int
+	Col    int
+	Offset int // Vị trí byte của ký tự đầu tiên
+	End    Position
+	Raw    *Raw // Văn bản gốc và trivia, chỉ có khi lexer ở chế độ lossless
+}
+
+// Raw is the exact source of a token in lossless mode. Leading, Text and Trailing of all the
+// tokens of a file, one after the other, give back the file byte for byte.
+type Raw struct {
+	Leading  []Trivia // Trước token: xuống dòng, khoảng trắng, comment (và BOM ở đầu file)
+	Text     string   // Văn bản gốc của token (Value đã bỏ dấu nháy, xử lý escape, ...)
+	Trailing []Trivia // Sau token cho đến hết dòng, không gồm dấu xuống dòng
+}
+
+// TriviaKind tells what a piece of trivia is
+type TriviaKind int
+
+const (
+	TRIVIA_WHITESPACE    TriviaKind = iota // Dấu cách, tab, ... trên cùng một dòng
+	TRIVIA_NEWLINE                         // Một dấu xuống dòng: "\n" hoặc "\r\n"
+	TRIVIA_LINE_COMMENT                    // // ... (không gồm dấu xuống dòng)
+	TRIVIA_BLOCK_COMMENT                   // /* ... */
+	TRIVIA_BOM                             // Byte order mark ở đầu file
+	TRIVIA_SKIPPED                         // Phần input lexer không đọc được: byte không phải UTF-8, phần sau ký tự NUL
+)
+
+// Trivia is source text between tokens that the parser does not need
+type Trivia struct {
+	Kind TriviaKind
+	Text string
+}
+
+// Type returns the older, coarser string type of the token (KEYWORD, ARITHMETIC, ...),
+// kept for tools that print or compare it
+func (t Token) Type() string {
+	return t.Kind.Type()
+}
+
+// Start returns the position of the first character of the token
+func (t Token) Start() Position {
+	return Position{Offset: t.Offset, Line: t.Line, Col: t.Col}
 }
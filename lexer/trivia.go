@@ -0,0 +1,62 @@
+package lexer
+
+import "unicode"
+
+// nextLosslessToken reads the next token together with its raw text and trivia
+func (l *Lexer) nextLosslessToken() Token {
+	var leading []Trivia
+	if l.bom {
+		leading = append(leading, Trivia{Kind: TRIVIA_BOM, Text: "\uFEFF"})
+		l.bom = false
+	}
+	leading = l.readTrivia(leading, true)
+
+	tok := l.nextToken()
+	raw := &Raw{Leading: leading, Text: l.src.slice(tok.Offset, tok.End.Offset)}
+	if tok.Kind != KIND_EOF {
+		raw.Trailing = l.readTrivia(nil, false)
+	} else if !l.atEOF {
+		l.atEOF = true
+		// Ký tự NUL kết thúc input sớm: giữ phần còn lại để in lại được đúng file
+		if rest := l.src.rest(l.position); rest != "" {
+			raw.Trailing = append(raw.Trailing, Trivia{Kind: TRIVIA_SKIPPED, Text: rest})
+		}
+	}
+	tok.Raw = raw
+	return tok
+}
+
+// readTrivia reads whitespace and comments. With multiline false it stops at the end of the line
+// (trivia sau token), otherwise it also reads newlines (trivia trước token).
+func (l *Lexer) readTrivia(trivia []Trivia, multiline bool) []Trivia {
+	for {
+		start := l.position
+		switch {
+		case l.ch == '\n' || (l.ch == '\r' && l.peekChar() == '\n'):
+			if !multiline {
+				return trivia
+			}
+			if l.ch == '\r' {
+				l.nextChar()
+			}
+			l.nextChar()
+			trivia = append(trivia, Trivia{Kind: TRIVIA_NEWLINE, Text: l.src.slice(start, l.position)})
+		case unicode.IsSpace(l.ch):
+			for unicode.IsSpace(l.ch) && l.ch != '\n' && !(l.ch == '\r' && l.peekChar() == '\n') {
+				l.nextChar()
+			}
+			trivia = append(trivia, Trivia{Kind: TRIVIA_WHITESPACE, Text: l.src.slice(start, l.position)})
+		case l.invalid:
+			for l.invalid {
+				l.nextChar()
+			}
+			trivia = append(trivia, Trivia{Kind: TRIVIA_SKIPPED, Text: l.src.slice(start, l.position)})
+		case l.ch == '/' && l.peekChar() == '/':
+			trivia = append(trivia, Trivia{Kind: TRIVIA_LINE_COMMENT, Text: l.readLineComment().Value})
+		case l.ch == '/' && l.peekChar() == '*':
+			trivia = append(trivia, Trivia{Kind: TRIVIA_BLOCK_COMMENT, Text: l.readBlockComment().Value})
+		default:
+			return trivia
+		}
+	}
+}
@@ -1,13 +1,20 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
 	"pun/compiler"
+	"pun/error"
+	"pun/format"
 	"pun/lexer"
 	"pun/parser"
 	"pun/repl"
 	"pun/vm"
+	"strings"
 	"time"
 )
 
@@ -44,53 +51,198 @@ func debug() {
 	}
 }
 
-func run(filename ...string) {
-	if len(filename) > 0 && filename[0] != "" {
-		runFile(filename[0]) // Nếu có file, chạy file đó
+// runFile runs a .pun file. With format "text" errors are printed for people; with "json" or
+// "sarif" they are written to stderr for tools (an empty list if there are none), so the output
+// of the program itself stays on stdout.
+// tailCalls false keeps a frame for every call, so stack traces show each of them.
+func runFile(filename, format string, tailCalls bool) {
+	file, err := os.Open(filename)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
 		return
 	}
+	defer file.Close()
 
-	if len(os.Args) > 1 {
-		runFile(os.Args[1]) // Run .pun file
-		return
+	var diagnostics []customError.Diagnostic
+	if format != customError.FormatText {
+		defer func() { writeDiagnostics(format, filename, diagnostics) }()
 	}
-}
 
-func runFile(filename string) {
-	data, err := os.ReadFile(filename)
-	if err != nil {
-		fmt.Printf("Error reading file: %v\n", err)
-		return
+	// Lexer đọc dần file; chỉ khi có lỗi mới cần đọc lại cả file để in các dòng code
+	l := lexer.NewReaderLexer(filename, file)
+	renderer := func() *customError.Renderer {
+		data, _ := os.ReadFile(filename)
+		return customError.NewRenderer(filename, string(data), os.Stdout)
 	}
-
-	l := lexer.NewLexer(string(data))
 	p := parser.NewParser(l)
 	c := compiler.NewCompiler()
+	c.TailCalls = tailCalls
 
 	program := p.ParseProgram()
 
 	if p.HasErrors() {
-		p.PrintErrors()
+		if diagnostics = p.Diagnostics(); format == customError.FormatText {
+			p.PrintErrors(renderer())
+		}
 		return
 	}
 
 	c.CompileProgram(program)
 
 	if c.HasErrors() {
-		c.PrintErrors()
+		if diagnostics = c.Diagnostics(); format == customError.FormatText {
+			c.PrintErrors(renderer())
+		}
 		return
 	}
 
 	v := vm.NewVM(c.Constants, c.Code, len(c.GlobalSymbols))
+	v.Lines = c.Lines
 	v.Run()
 
 	if v.HasErrors() {
-		v.PrintErrors()
+		if diagnostics = v.Diagnostics(); format == customError.FormatText {
+			v.PrintErrors(renderer())
+		}
 		return
 	}
 
 }
 
+func writeDiagnostics(format, filename string, diagnostics []customError.Diagnostic) {
+	var err error
+	switch format {
+	case customError.FormatJSON:
+		err = customError.WriteJSON(os.Stderr, filename, diagnostics)
+	case customError.FormatSARIF:
+		err = customError.WriteSARIF(os.Stderr, filename, diagnostics)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing diagnostics: %v\n", err)
+	}
+}
+
+// explain prints the long explanation of an error code (pun explain P0202),
+// or the list of every code without one
+func explain(args []string) int {
+	if len(args) == 0 {
+		for _, code := range customError.Codes() {
+			fmt.Printf("%s  %s\n", code, customError.Summary(code))
+		}
+		return 0
+	}
+	status := 0
+	for i, arg := range args {
+		text, ok := customError.Explain(customError.Code(strings.ToUpper(arg)))
+		if !ok {
+			fmt.Fprintf(os.Stderr, "unknown error code %q (run `pun explain` to list them)\n", arg)
+			status = 1
+			continue
+		}
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Print(text)
+	}
+	return status
+}
+
+// formatCommand formats .pun files in place (pun fmt a.pun dir), or stdin to stdout if no file is
+// given. With --check it only lists the files that are not formatted, with --diff it prints the
+// changes instead; both exit with status 1 if a file would change. Files with syntax errors are
+// left as they are, their errors are reported in the --diagnostics format and the status is 1.
+func formatCommand(args []string, diagnostics string) int {
+	flags := flag.NewFlagSet("fmt", flag.ExitOnError)
+	check := flags.Bool("check", false, "list the files that are not formatted instead of rewriting them")
+	diff := flags.Bool("diff", false, "print the changes as a unified diff instead of rewriting the files")
+	flags.Parse(args)
+
+	status := 0
+	formatOne := func(name string, src []byte, write func(string) error) {
+		formatted, errs := format.Source(name, strings.NewReader(string(src)))
+		if len(errs) > 0 {
+			reportFormatErrors(diagnostics, name, string(src), errs)
+			status = 1
+			return
+		}
+		changed := formatted != string(src)
+		switch {
+		case *diff:
+			fmt.Print(format.Diff(name, string(src), formatted))
+		case *check:
+			if changed {
+				fmt.Println(name)
+			}
+		default:
+			if err := write(formatted); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", name, err)
+				status = 1
+			}
+			return
+		}
+		if changed {
+			status = 1
+		}
+	}
+
+	if flags.NArg() == 0 {
+		src, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading stdin: %v\n", err)
+			return 1
+		}
+		formatOne("<stdin>", src, func(formatted string) error {
+			_, err := io.WriteString(os.Stdout, formatted)
+			return err
+		})
+		return status
+	}
+
+	for _, arg := range flags.Args() {
+		// Thư mục: mọi file .pun bên trong; file ghi rõ tên thì format dù đuôi là gì
+		err := filepath.WalkDir(arg, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() || (path != arg && filepath.Ext(path) != ".pun") {
+				return nil
+			}
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			src, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			formatOne(path, src, func(formatted string) error {
+				if formatted == string(src) {
+					return nil // Không ghi lại file đã đúng format
+				}
+				return os.WriteFile(path, []byte(formatted), info.Mode().Perm())
+			})
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+			status = 1
+		}
+	}
+	return status
+}
+
+// reportFormatErrors prints the syntax errors that stop a file from being formatted to stderr
+func reportFormatErrors(diagnostics, name, source string, errs []customError.Diagnostic) {
+	if diagnostics != customError.FormatText {
+		writeDiagnostics(diagnostics, name, errs)
+		return
+	}
+	r := customError.NewRenderer(name, source, os.Stderr)
+	for _, d := range errs {
+		fmt.Fprintln(os.Stderr, r.Render(d))
+	}
+}
+
 func measureTime(fn func()) {
 	start := time.Now()
 	defer func() {
@@ -101,9 +253,37 @@ func measureTime(fn func()) {
 }
 
 func main() {
-	measureTime(func() {
-		run("example.pun")
-		//debug()
+	format := flag.String("diagnostics", customError.FormatText, "how errors are reported: text, json or sarif (json and sarif go to stderr)")
+	lang := flag.String("lang", "", "language of error messages: en or vi (default: $PUN_LANG, then the system locale)")
+	noTailCalls := flag.Bool("no-tail-calls", false, "keep a stack frame for every call (return f(x) included) so stack traces are complete")
+	flag.Parse()
 
-	})
+	if *lang != "" && !customError.SetLanguage(*lang) {
+		fmt.Fprintf(os.Stderr, "unknown language %q (use %s)\n", *lang, strings.Join(customError.Languages(), " or "))
+		os.Exit(2)
+	}
+	switch flag.Arg(0) {
+	case "explain":
+		os.Exit(explain(flag.Args()[1:]))
+	case "fmt":
+		os.Exit(formatCommand(flag.Args()[1:], *format))
+	}
+
+	filename := "example.pun"
+	if flag.NArg() > 0 {
+		filename = flag.Arg(0) // Run .pun file
+	}
+
+	switch *format {
+	case customError.FormatText:
+		measureTime(func() {
+			runFile(filename, *format, !*noTailCalls)
+			//debug()
+		})
+	case customError.FormatJSON, customError.FormatSARIF:
+		runFile(filename, *format, !*noTailCalls) // Không in thời gian chạy: chỉ có kết quả của chương trình
+	default:
+		fmt.Fprintf(os.Stderr, "unknown diagnostics format %q (use text, json or sarif)\n", *format)
+		os.Exit(2)
+	}
 }
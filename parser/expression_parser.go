@@ -1,122 +1,287 @@
 package parser
 
 import (
-	"fmt"
+	"math/big"
 	"pun/ast"
+	"pun/error"
 	"pun/lexer"
 	"strconv"
+	"strings"
 )
 
-var precedences = map[string]int{
-	"!":  7, // Unary có mức ưu tiên cao nhất
-	"**": 6,
-	"*":  5, "/": 5, "%": 5,
-	"+": 4, "-": 4,
-	"==": 3, "!=": 3, ">": 3, "<": 3, ">=": 3, "<=": 3,
-	"&&": 2, // AND cao hơn OR
-	"||": 1, // OR thấp nhất nhưng vẫn bắt đầu từ 1
+// Mức ưu tiên của toán tử, từ thấp đến cao
+const (
+	precLowest      = iota
+	precConditional // cond ? a : b
+	precNullish     // a ?? b
+	precOr          // ||
+	precAnd         // && (AND cao hơn OR)
+	precCompare     // == != > < >= <=
+	precBitOr       // |
+	precBitXor      // ^
+	precBitAnd      // &
+	precShift       // << >>
+	precSum         // + -
+	precProduct     // * / %
+	precPrefix      // -x !x ~x
+	precPower       // ** mạnh hơn dấu trừ bên trái: -2 ** 2 là -(2 ** 2)
+	precPostfix     // a[i] f(x) a.b a?.b
+)
+
+// Mức ưu tiên của các toán tử hai ngôi
+var precedences = map[lexer.Kind]int{
+	lexer.KIND_QUESTION: precConditional,
+	lexer.KIND_NULLISH:  precNullish,
+	lexer.KIND_OR:       precOr,
+	lexer.KIND_AND:      precAnd,
+	lexer.KIND_EQ:       precCompare, lexer.KIND_NOT_EQ: precCompare, lexer.KIND_GT: precCompare,
+	lexer.KIND_LT: precCompare, lexer.KIND_GT_EQ: precCompare, lexer.KIND_LT_EQ: precCompare,
+	lexer.KIND_BIT_OR:     precBitOr,
+	lexer.KIND_BIT_XOR:    precBitXor,
+	lexer.KIND_BIT_AND:    precBitAnd,
+	lexer.KIND_SHIFT_LEFT: precShift, lexer.KIND_SHIFT_RIGHT: precShift,
+	lexer.KIND_PLUS: precSum, lexer.KIND_MINUS: precSum,
+	lexer.KIND_STAR: precProduct, lexer.KIND_SLASH: precProduct, lexer.KIND_PERCENT: precProduct,
+	lexer.KIND_POWER: precPower,
+}
+
+// Toán tử kết hợp phải: 2 ** 3 ** 2 là 2 ** (3 ** 2)
+var rightAssociative = map[lexer.Kind]bool{
+	lexer.KIND_POWER: true,
+}
+
+type (
+	prefixParseFn func() ast.Expression                    // Token mở đầu một biểu thức
+	infixParseFn  func(left ast.Expression) ast.Expression // Token đứng sau một biểu thức (toán tử hai ngôi, postfix)
+)
+
+// registerParseFns fills the prefix and infix tables, keyed by token kind
+func (p *Parser) registerParseFns() {
+	p.prefixParseFns = map[lexer.Kind]prefixParseFn{
+		lexer.KIND_NUMBER:            p.parseNumberExpression,
+		lexer.KIND_STRING:            p.parseStringExpression,
+		lexer.KIND_TEMPLATE_HEAD:     p.parseTemplateExpression,
+		lexer.KIND_IDENTIFIER:        p.parseIdentifierExpression,
+		lexer.KIND_TRUE:              p.parseBooleanExpression,
+		lexer.KIND_FALSE:             p.parseBooleanExpression,
+		lexer.KIND_NOTHING:           p.parseNothingExpression,
+		lexer.KIND_LPAREN:            p.parseParenthesizedExpression,
+		lexer.KIND_LSQUARE:           p.parseArrayExpression,
+		lexer.KIND_FROZEN_ARRAY_OPEN: p.parseArrayExpression,
+		lexer.KIND_SET_OPEN:          p.parseSetExpression,
+		lexer.KIND_LCURLY:            p.parseBlockExpression,
+		lexer.KIND_MINUS:             p.parsePrefixExpression,
+		lexer.KIND_NOT:               p.parsePrefixExpression,
+		lexer.KIND_BIT_NOT:           p.parsePrefixExpression,
+	}
+	// Chỉ if và match dùng được như một giá trị, các từ khóa khác báo lỗi riêng
+	for k := lexer.KIND_IF; k <= lexer.KIND_MATCH; k++ {
+		p.prefixParseFns[k] = p.parseKeywordExpression
+	}
+
+	p.infixParseFns = map[lexer.Kind]infixParseFn{
+		lexer.KIND_QUESTION: p.parseConditionalExpression,
+		lexer.KIND_LSQUARE:  p.parseIndexExpression,
+		lexer.KIND_LPAREN:   p.parseFunctionCallExpression,
+		lexer.KIND_DOT:      p.parseMemberExpression,
+		lexer.KIND_OPT_DOT:  p.parseMemberExpression,
+	}
+	for k := range precedences {
+		if k != lexer.KIND_QUESTION {
+			p.infixParseFns[k] = p.parseBinaryExpression
+		}
+	}
 }
 
-func (p *Parser) getPrecedence(op string) int {
-	if prec, ok := precedences[op]; ok {
-		return prec
+// curPrecedence returns how strongly the current token binds to the expression before it
+// (precLowest if it cannot continue the expression)
+func (p *Parser) curPrecedence() int {
+	if _, ok := p.infixParseFns[p.curTok.Kind]; !ok {
+		return precLowest
+	}
+	switch p.curTok.Kind {
+	case lexer.KIND_LSQUARE, lexer.KIND_LPAREN:
+		// ( và [ ở đầu dòng mới mở một biểu thức mới, không phải lời gọi/index của dòng trước
+		if p.curTok.Line != p.prevTok.Line {
+			return precLowest
+		}
+		return precPostfix
+	case lexer.KIND_DOT, lexer.KIND_OPT_DOT:
+		return precPostfix
 	}
-	return 0
+	return precedences[p.curTok.Kind]
 }
 
+// parseExpression parses an expression whose operators all bind tighter than precedence
 func (p *Parser) parseExpression(precedence int) ast.Expression {
-	left := p.parsePrimaryExpression()
-	if left == nil {
+	prefix, ok := p.prefixParseFns[p.curTok.Kind]
+	if !ok {
+		p.addError(customError.UnexpectedToken, p.curTok.Line, p.curTok.Col, p.curTok.Value)
 		return nil
 	}
 
-	for p.getPrecedence(p.curTok.Value) > precedence {
-		op := p.curTok.Value
-		p.nextToken()
-		right := p.parseExpression(precedences[op])
-		if right == nil {
-			return nil
+	// Node nào chưa có span thì span của nó chạy từ start tới token cuối cùng đã dùng
+	start := p.curTok
+	left := prefix()
+	if left != nil {
+		p.setSpan(left, start)
+	}
+	for left != nil && p.curPrecedence() > precedence {
+		if left = p.infixParseFns[p.curTok.Kind](left); left != nil {
+			p.setSpan(left, start)
 		}
-		left = &ast.BinaryExpression{Left: left, Operator: op, Right: right}
 	}
 	return left
 }
 
-func (p *Parser) parsePrimaryExpression() ast.Expression {
-	switch p.curTok.Type {
-	case lexer.TOKEN_NUMBER:
-		value, err := strconv.ParseFloat(p.curTok.Value, 64)
-		if err != nil {
-			p.addError(fmt.Sprintf("Invalid number: %s", p.curTok.Value), p.curTok.Line, p.curTok.Col)
-			return nil
-		}
-		lit := &ast.NumberExpression{Value: value, Line: p.curTok.Line} // 🛠 Đổi từ string -> float64
+func (p *Parser) parseNumberExpression() ast.Expression {
+	if isDecimalLiteral(p.curTok.Value) {
+		lit := &ast.DecimalExpression{Value: strings.TrimSuffix(p.curTok.Value, "d"), Line: p.curTok.Line}
 		p.nextToken()
 		return lit
+	}
+	if strings.HasSuffix(p.curTok.Value, "n") || isIntegerLiteral(p.curTok.Value) {
+		return p.parseIntegerLiteral()
+	}
+	value, err := parseNumberLiteral(p.curTok.Value)
+	if err != nil {
+		p.addError(customError.InvalidNumber, p.curTok.Line, p.curTok.Col, p.curTok.Value)
+		return nil
+	}
+	lit := &ast.NumberExpression{Value: value, Line: p.curTok.Line} // 🛠 Đổi từ string -> float64
+	p.nextToken()
+	return lit
+}
 
-	case lexer.TOKEN_STRING:
-		lit := &ast.StringExpression{Value: p.curTok.Value, Line: p.curTok.Line}
-		p.nextToken()
-		return lit
+func (p *Parser) parseStringExpression() ast.Expression {
+	lit := &ast.StringExpression{Value: p.curTok.Value, Line: p.curTok.Line}
+	p.nextToken()
+	return lit
+}
 
-	case lexer.TOKEN_IDENTIFIER:
-		ident := &ast.Identifier{Value: p.curTok.Value, Line: p.curTok.Line}
-		p.nextToken()
-		// Nếu có dấu `[` => Đây là truy xuất mảng
-		if p.curTok.Type == lexer.TOKEN_LSQUARE {
-			return p.parseArrayIndexExpression(ident) // Truyền array vào
-		}
-		if p.curTok.Type == lexer.TOKEN_LPAREN {
-			return p.parseFunctionCallExpression(ident)
-		}
-		//Nếu có dấu . phía sau thì là method
-		if p.curTok.Type == lexer.TOKEN_DOT {
-			return p.parseMethodCallExpression(ident)
-		}
-		return ident
+func (p *Parser) parseIdentifierExpression() ast.Expression {
+	ident := p.parseIdentifier()
+	if p.curTok.Kind == lexer.KIND_WALRUS {
+		return p.parseAssignExpression(ident)
+	}
+	return ident
+}
 
-	case lexer.TOKEN_BOOLEAN:
-		booleanVal := p.curTok.Value == "true"
-		lit := &ast.BooleanExpression{Value: booleanVal, Line: p.curTok.Line}
-		p.nextToken()
-		return lit
-	case lexer.TOKEN_NOTHING:
-		p.nextToken()
-		return &ast.NothingExpression{Line: p.curTok.Line}
-	case lexer.TOKEN_LPAREN:
-		p.nextToken()
-		expr := p.parseExpression(0)
+func (p *Parser) parseBooleanExpression() ast.Expression {
+	lit := &ast.BooleanExpression{Value: p.curTok.Kind == lexer.KIND_TRUE, Line: p.curTok.Line}
+	p.nextToken()
+	return lit
+}
 
-		if p.curTok.Type != lexer.TOKEN_RPAREN {
-			p.addError("Expected closing ')'", p.curTok.Line, p.curTok.Col)
-			return nil
-		}
-		p.nextToken() // Ăn dấu ')'
-		return expr
-	case lexer.TOKEN_LSQUARE:
-		return p.parseArrayExpression()
-	case lexer.TOKEN_ARITHMETIC:
-		if p.curTok.Value == "-" {
-			operator := p.curTok.Value
-			p.nextToken()
-			value := p.parseExpression(p.getMaxPrec())
-			expr := &ast.UnaryExpression{Operator: operator, Value: value, Line: p.curTok.Line}
-			return expr
-		}
+func (p *Parser) parseNothingExpression() ast.Expression {
+	lit := &ast.NothingExpression{Line: p.curTok.Line}
+	p.nextToken()
+	return lit
+}
+
+// parseKeywordExpression parses the keywords that can be used as values: if and match
+func (p *Parser) parseKeywordExpression() ast.Expression {
+	switch p.curTok.Kind {
+	case lexer.KIND_IF:
+		return p.parseIfExpression()
+	case lexer.KIND_MATCH:
+		return p.parseMatchExpression()
+	}
+	p.addError(customError.UnexpectedKeyword, p.curTok.Line, p.curTok.Col, p.curTok.Value)
+	return nil
+}
+
+// parsePrefixExpression parses the unary operators -x, !x and ~x
+func (p *Parser) parsePrefixExpression() ast.Expression {
+	operator := p.curTok.Value
+	line := p.curTok.Line
+	p.nextToken()
+
+	value := p.parseExpression(precPrefix)
+	if value == nil {
 		return nil
-	case lexer.TOKEN_LOGICAL:
-		if p.curTok.Value == "!" {
-			operator := p.curTok.Value
-			p.nextToken()
-			value := p.parseExpression(p.getMaxPrec())
-			expr := &ast.UnaryExpression{Operator: operator, Value: value, Line: p.curTok.Line}
-			return expr
-		}
+	}
+	return &ast.UnaryExpression{Operator: operator, Value: value, Line: line}
+}
+
+func (p *Parser) parseBinaryExpression(left ast.Expression) ast.Expression {
+	op := p.curTok.Value
+	line := p.curTok.Line
+	precedence := precedences[p.curTok.Kind]
+	if rightAssociative[p.curTok.Kind] {
+		precedence-- // Vế phải được phép chứa chính toán tử này
+	}
+	p.nextToken()
+
+	right := p.parseExpression(precedence)
+	if right == nil {
 		return nil
-	default:
-		p.addError(fmt.Sprintf("Unexpected token: %s", p.curTok.Value), p.curTok.Line, p.curTok.Col)
+	}
+	return &ast.BinaryExpression{Left: left, Operator: op, Right: right, Line: line}
+}
+
+// parseAssignExpression parses name := value, an assignment that yields the assigned value
+func (p *Parser) parseAssignExpression(name *ast.Identifier) ast.Expression {
+	expr := &ast.AssignExpression{Name: name, Line: p.curTok.Line}
+	p.nextToken() // Bỏ qua :=
+
+	expr.Value = p.parseExpression(0)
+	if expr.Value == nil {
+		p.addError(customError.InvalidWalrusValue, p.curTok.Line, p.curTok.Col)
 		return nil
 	}
+	return expr
+}
+
+// parseIfExpression parses an if statement in expression position: x = if c { 1 } else { 2 }
+func (p *Parser) parseIfExpression() ast.Expression {
+	line := p.curTok.Line
+	stmt := p.parseIfStatement()
+	if stmt == nil {
+		return nil
+	}
+	return &ast.IfExpression{Statement: stmt, Line: line}
+}
+
+func (p *Parser) parseMatchExpression() ast.Expression {
+	line := p.curTok.Line
+	stmt, ok := p.parseMatchStatement().(*ast.MatchStatement)
+	if !ok {
+		return nil
+	}
+	return &ast.MatchExpression{Statement: stmt, Line: line}
+}
+
+// parseCondition parses the condition of if/elif/for/while/until or the subject and patterns of
+// match. There a '{' always opens the body, so it cannot start a block expression (except inside brackets).
+func (p *Parser) parseCondition() ast.Expression {
+	saved := p.inCondition
+	p.inCondition = true
+	expr := p.parseExpression(0)
+	p.inCondition = saved
+	return expr
+}
+
+// insideBrackets lets '{' start a block expression again until the returned function is called
+func (p *Parser) insideBrackets() func() {
+	saved := p.inCondition
+	p.inCondition = false
+	return func() { p.inCondition = saved }
+}
+
+// parseBlockExpression parses { statements } in expression position
+func (p *Parser) parseBlockExpression() ast.Expression {
+	line := p.curTok.Line
+	if p.inCondition {
+		p.addError(customError.MissingExpressionBeforeBrace, p.curTok.Line, p.curTok.Col)
+		return nil
+	}
+	block := p.parseBlockStatement()
+	if !p.expectCurrent(lexer.KIND_RCURLY) {
+		return nil
+	}
+	p.nextToken()
+	return &ast.BlockExpression{Block: block, Line: line}
 }
 
 func (p *Parser) parseIncDecExpression(ident *ast.Identifier, op string, isPrefix bool) ast.Expression {
@@ -131,32 +296,29 @@ func (p *Parser) parseIncDecExpression(ident *ast.Identifier, op string, isPrefi
 }
 
 func (p *Parser) parseArrayExpression() ast.Expression {
-	array := &ast.ArrayExpression{Line: p.curTok.Line}
+	array := &ast.ArrayExpression{Frozen: p.curTok.Kind == lexer.KIND_FROZEN_ARRAY_OPEN, Line: p.curTok.Line}
 
-	p.nextToken() //skip "["
+	p.nextToken() //skip "[" hoặc "#["
 
-	if p.curTok.Type == lexer.TOKEN_RSQUARE {
+	if p.curTok.Kind == lexer.KIND_RSQUARE {
 		p.nextToken()
 		return array
 	}
 
-	for p.curTok.Type != lexer.TOKEN_RSQUARE && p.curTok.Type != lexer.TOKEN_EOF {
-		element := p.parseExpression(0)
-		if element != nil {
-			array.Elements = append(array.Elements, element)
-		} else {
-			break
+	for p.curTok.Kind != lexer.KIND_RSQUARE && p.curTok.Kind != lexer.KIND_EOF {
+		element := p.parseListElement()
+		array.Elements = append(array.Elements, element)
+		if p.curTok.Kind != lexer.KIND_COMMA {
+			break // Thiếu dấu phẩy: phải là dấu ] đóng mảng
 		}
-		if p.curTok.Type == lexer.TOKEN_COMMA {
-			if p.peekTok.Type == lexer.TOKEN_RSQUARE {
-				p.addError("Trailling comma in array is not allowed", p.curTok.Line, p.curTok.Col)
-				return nil
-			}
-			p.nextToken()
+		if p.peekTok.Kind == lexer.KIND_RSQUARE {
+			p.addError(customError.TrailingComma, p.curTok.Line, p.curTok.Col)
+			return nil
 		}
+		p.nextToken()
 	}
 
-	if !p.expectCurrent(lexer.TOKEN_RSQUARE) {
+	if !p.expectCurrent(lexer.KIND_RSQUARE) {
 		return nil
 	}
 
@@ -166,11 +328,72 @@ func (p *Parser) parseArrayExpression() ast.Expression {
 
 }
 
-func (p *Parser) parseArrayIndexExpression(array ast.Expression) ast.Expression {
-	expr := &ast.ArrayIndexExpression{Array: array, Line: p.curTok.Line}
+// parseParenthesizedExpression parses (expr) or a tuple: (), (x,), (x, y, ...)
+func (p *Parser) parseParenthesizedExpression() ast.Expression {
+	line := p.curTok.Line
+	p.nextToken() // Bỏ qua '('
+
+	if p.curTok.Kind == lexer.KIND_RPAREN {
+		p.nextToken()
+		return &ast.TupleExpression{Line: line}
+	}
+
+	defer p.insideBrackets()()
+	expr := p.parseExpression(0)
+	if expr == nil {
+		return nil
+	}
+
+	if p.curTok.Kind == lexer.KIND_COMMA {
+		tuple := &ast.TupleExpression{Elements: []ast.Expression{expr}, Line: line}
+		for p.curTok.Kind == lexer.KIND_COMMA {
+			p.nextToken()
+			if p.curTok.Kind == lexer.KIND_RPAREN {
+				break // (x,) và (x, y,) đều hợp lệ
+			}
+			tuple.Elements = append(tuple.Elements, p.parseListElement())
+		}
+		expr = tuple
+	}
+
+	if p.curTok.Kind != lexer.KIND_RPAREN {
+		p.addError(customError.MissingCloseParen, p.curTok.Line, p.curTok.Col)
+		return nil
+	}
+	p.nextToken() // Ăn dấu ')'
+	return expr
+}
+
+// parseSetExpression parses #{a, b, ...}
+func (p *Parser) parseSetExpression() ast.Expression {
+	set := &ast.SetExpression{Line: p.curTok.Line}
+	p.nextToken() // Bỏ qua "#{"
+
+	for p.curTok.Kind != lexer.KIND_RCURLY && p.curTok.Kind != lexer.KIND_EOF {
+		set.Elements = append(set.Elements, p.parseListElement())
+		if p.curTok.Kind != lexer.KIND_COMMA {
+			break
+		}
+		p.nextToken()
+	}
+
+	if !p.expectCurrent(lexer.KIND_RCURLY) {
+		return nil
+	}
+	p.nextToken()
+	return set
+}
+
+func (p *Parser) parseIndexExpression(array ast.Expression) ast.Expression {
+	return p.parseArrayIndexExpression(array, false)
+}
+
+func (p *Parser) parseArrayIndexExpression(array ast.Expression, optional bool) ast.Expression {
+	expr := &ast.ArrayIndexExpression{Array: array, Optional: optional, Line: p.curTok.Line}
 
 	p.nextToken() // Bỏ qua '['
 
+	defer p.insideBrackets()()
 	index := p.parseExpression(0)
 
 	if index == nil {
@@ -179,7 +402,7 @@ func (p *Parser) parseArrayIndexExpression(array ast.Expression) ast.Expression
 
 	expr.Index = index
 
-	if !p.expectCurrent(lexer.TOKEN_RSQUARE) {
+	if !p.expectCurrent(lexer.KIND_RSQUARE) {
 		return nil
 	}
 
@@ -188,38 +411,175 @@ func (p *Parser) parseArrayIndexExpression(array ast.Expression) ast.Expression
 	return expr
 }
 
-func (p *Parser) getMaxPrec() int {
-	maxPrec := 0
-	for _, prec := range precedences {
-		if prec > maxPrec {
-			maxPrec = prec
+// parseMemberExpression parses .name, .name(args), ?.name, ?.name(args) and ?.[index]
+func (p *Parser) parseMemberExpression(object ast.Expression) ast.Expression {
+	optional := p.curTok.Kind == lexer.KIND_OPT_DOT
+	line := p.curTok.Line
+
+	p.nextToken() // Bỏ qua . hoặc ?.
+
+	if optional && p.curTok.Kind == lexer.KIND_LSQUARE {
+		return p.parseArrayIndexExpression(object, true)
+	}
+
+	if !p.expectCurrent(lexer.KIND_IDENTIFIER) {
+		return nil
+	}
+	name := p.curTok.Value
+	p.nextToken()
+
+	if p.curTok.Kind == lexer.KIND_LPAREN {
+		expr := &ast.MethodCallExpression{Caller: object, Method: name, Optional: optional, Line: line}
+		args, ok := p.parseArguments()
+		if !ok {
+			return nil
 		}
+		expr.Arguments = args
+		return expr
 	}
-	return maxPrec
+
+	return &ast.PropertyExpression{Object: object, Property: name, Optional: optional, Line: line}
 }
 
-func (p *Parser) parseMethodCallExpression(caller ast.Expression) ast.Expression {
-	expr := &ast.MethodCallExpression{Caller: caller, Line: p.curTok.Line}
+func (p *Parser) parseConditionalExpression(condition ast.Expression) ast.Expression {
+	expr := &ast.ConditionalExpression{Condition: condition, Line: p.curTok.Line}
 
-	p.nextToken()
+	p.nextToken() // Bỏ qua '?'
 
-	if !p.expectCurrent(lexer.TOKEN_IDENTIFIER) {
+	expr.Consequence = p.parseExpression(0)
+	if expr.Consequence == nil {
 		return nil
 	}
 
-	expr.Method = p.curTok.Value
-
-	p.nextToken()
-	expr.Arguments = p.parseArguments()
+	if !p.expectCurrent(lexer.KIND_COLON) {
+		return nil
+	}
 	p.nextToken()
 
+	// Vế else parse với precedence 0 nên a ? b : c ? d : e là a ? b : (c ? d : e)
+	expr.Alternative = p.parseExpression(0)
+	if expr.Alternative == nil {
+		return nil
+	}
+
 	return expr
 }
 
-func (p *Parser) parseFunctionCallExpression(function *ast.Identifier) ast.Expression {
+// parseFunctionCallExpression parses a call of any expression: f(x), getFn()(x), (f)(x)
+func (p *Parser) parseFunctionCallExpression(function ast.Expression) ast.Expression {
 	expr := &ast.FunctionCallExpression{Function: function, Line: p.curTok.Line}
 
-	expr.Arguments = p.parseArguments()
-
+	args, ok := p.parseArguments()
+	if !ok {
+		return nil
+	}
+	expr.Arguments = args
 	return expr
 }
+
+func (p *Parser) parseTemplateExpression() ast.Expression {
+	expr := &ast.TemplateExpression{Line: p.curTok.Line}
+	p.addTemplateLiteral(expr)
+	p.nextToken() // Bỏ qua TEMPLATE_HEAD
+
+	for {
+		part := &ast.InterpolationExpression{Line: p.curTok.Line}
+		partStart := p.curTok
+
+		if p.curTok.Kind == lexer.KIND_TEMPLATE_MIDDLE || p.curTok.Kind == lexer.KIND_TEMPLATE_TAIL {
+			p.addError(customError.EmptyInterpolation, p.curTok.Line, p.curTok.Col)
+			return nil
+		}
+
+		part.Value = p.parseExpression(0)
+		if part.Value == nil {
+			return nil
+		}
+
+		if p.curTok.Kind == lexer.KIND_FORMAT_SPEC {
+			part.Format = p.curTok.Value
+			p.nextToken()
+		}
+		p.setSpan(part, partStart)
+		expr.Parts = append(expr.Parts, part)
+
+		switch p.curTok.Kind {
+		case lexer.KIND_TEMPLATE_MIDDLE:
+			p.addTemplateLiteral(expr)
+			p.nextToken()
+		case lexer.KIND_TEMPLATE_TAIL:
+			p.addTemplateLiteral(expr)
+			p.nextToken()
+			return expr
+		default:
+			p.addError(customError.UnclosedInterpolation, p.curTok.Line, p.curTok.Col)
+			return nil
+		}
+	}
+}
+
+// addTemplateLiteral appends the literal text of the current template token (empty parts are skipped)
+func (p *Parser) addTemplateLiteral(expr *ast.TemplateExpression) {
+	if p.curTok.Value == "" {
+		return
+	}
+	lit := &ast.StringExpression{Value: p.curTok.Value, Line: p.curTok.Line}
+	lit.SetSpan(ast.Span{File: p.file, Start: p.curTok.Start(), End: p.curTok.End}) // Cả token, gồm dấu " và ${
+	expr.Parts = append(expr.Parts, lit)
+}
+
+// parseIntegerLiteral parses 123, 0xFF, 123n, 0xFFn, ... (the lexer keeps the 'n' suffix).
+// Integers that a number cannot hold exactly (above 2^53) become bigints.
+func (p *Parser) parseIntegerLiteral() ast.Expression {
+	text := strings.ReplaceAll(p.curTok.Value, "_", "")
+	explicit := strings.HasSuffix(text, "n")
+	text = strings.TrimSuffix(text, "n")
+
+	base := 10
+	if len(text) > 2 && text[0] == '0' {
+		switch text[1] {
+		case 'x', 'X':
+			base, text = 16, text[2:]
+		case 'o', 'O':
+			base, text = 8, text[2:]
+		case 'b', 'B':
+			base, text = 2, text[2:]
+		}
+	}
+	value, ok := new(big.Int).SetString(text, base)
+	if !ok {
+		p.addError(customError.InvalidNumber, p.curTok.Line, p.curTok.Col, p.curTok.Value)
+		return nil
+	}
+
+	var lit ast.Expression
+	if explicit || value.BitLen() > 53 {
+		lit = &ast.BigIntExpression{Value: value, Line: p.curTok.Line}
+	} else {
+		lit = &ast.NumberExpression{Value: float64(value.Int64()), Line: p.curTok.Line}
+	}
+	p.nextToken()
+	return lit
+}
+
+// isDecimalLiteral reports whether a number literal has the decimal suffix (0xFd is hexadecimal)
+func isDecimalLiteral(text string) bool {
+	return strings.HasSuffix(text, "d") && !isPrefixedLiteral(text)
+}
+
+func isPrefixedLiteral(text string) bool {
+	return len(text) > 2 && text[0] == '0' && strings.ContainsRune("xXoObB", rune(text[1]))
+}
+
+// isIntegerLiteral reports whether a number literal has no fraction or exponent
+func isIntegerLiteral(text string) bool {
+	if isPrefixedLiteral(text) {
+		return true
+	}
+	return !strings.ContainsAny(text, ".eE")
+}
+
+// parseNumberLiteral parses a decimal literal with a fraction or exponent (1.5, 1e-9)
+func parseNumberLiteral(text string) (float64, error) {
+	return strconv.ParseFloat(strings.ReplaceAll(text, "_", ""), 64)
+}
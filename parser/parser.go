@@ -5,144 +5,325 @@ import (
 	"pun/ast"
 	"pun/error"
 	"pun/lexer"
+	"slices"
 	"strings"
 )
 
 type Parser struct {
 	lexer   *lexer.Lexer
+	file    string      // Tên file, ghi vào Span của mọi node
+	prevTok lexer.Token // Token vừa được dùng xong (trước curTok)
 	curTok  lexer.Token
 	peekTok lexer.Token
 	errors  []customError.SyntaxError
+
+	consumed    int           // Số token đã dùng, để biết một statement lỗi đã ăn được token nào chưa
+	recovering  bool          // Đã báo lỗi cho statement hiện tại: bỏ qua các lỗi dây chuyền sau nó
+	errorAt     int           // Giá trị của consumed khi báo lỗi gần nhất, để không báo lại lỗi ở cùng token
+	inCondition bool          // Đang parse điều kiện của if/while/...: dấu { mở thân lệnh, không phải block expression
+	openers     []lexer.Token // Các dấu ngoặc mở đã dùng mà chưa gặp dấu đóng, để chỉ ra khi thiếu dấu đóng
+	tokens      []lexer.Token // Mọi token đã đọc, đến EOF (chỉ khi lexer ở chế độ lossless)
+
+	prefixParseFns map[lexer.Kind]prefixParseFn
+	infixParseFns  map[lexer.Kind]infixParseFn
 }
 
 func NewParser(l *lexer.Lexer) *Parser {
-	p := &Parser{lexer: l}
+	p := &Parser{lexer: l, file: l.File()}
+	p.registerParseFns()
 	p.nextToken()
 	p.nextToken()
 	return p
 }
 
 func (p *Parser) nextToken() {
+	switch bracketDepth(p.curTok.Kind) {
+	case 1:
+		p.openers = append(p.openers, p.curTok)
+	case -1:
+		if p.closesOpener(p.curTok.Kind) { // Dấu đóng thừa hoặc sai loại không đóng gì cả
+			p.openers = p.openers[:len(p.openers)-1]
+		}
+	}
+
+	p.prevTok = p.curTok
+	p.consumed++
 	p.curTok = p.peekTok
 	p.peekTok = p.lexer.NextToken()
 
-	for p.peekTok.Type == lexer.TOKEN_COMMENT {
+	for p.peekTok.Kind == lexer.KIND_COMMENT {
 		p.peekTok = p.lexer.NextToken()
 	}
+	if p.lexer.Lossless && (len(p.tokens) == 0 || p.tokens[len(p.tokens)-1].Kind != lexer.KIND_EOF) {
+		p.tokens = append(p.tokens, p.peekTok)
+	}
+
+	// Lỗi của lexer (chuỗi chưa đóng, escape sai, ...) cũng là SyntaxError
+	p.errors = append(p.errors, p.lexer.TakeErrors()...)
 }
 
 func (p *Parser) ParseProgram() *ast.Program {
 	program := &ast.Program{}
 
-	for p.peekTok.Type != lexer.TOKEN_EOF {
-		stmt := p.parseStatement()
-		if stmt != nil {
-			program.Statements = append(program.Statements, stmt)
-			// Biên dịch statement ngay sau khi parse
-
-		} else {
-			p.syncTo(lexer.TOKEN_SEMICOLON)
-		}
+	for p.curTok.Kind != lexer.KIND_EOF {
+		program.Statements = append(program.Statements, p.parseStatement())
 	}
+	// Program trải hết file, kể cả khoảng trắng ở đầu và cuối
+	program.SetSpan(ast.Span{File: p.file, Start: lexer.Position{Line: 1, Col: 1}, End: p.curTok.End})
 
 	return program // Trả về cả AST và compiler
 }
 
-// Hàm parseArguments GIỮ NGUYÊN như bản gốc
-func (p *Parser) parseArguments() []ast.Expression {
+// parseArguments parses (a, b, ...) and reports false if the list is broken,
+// so that the call fails with it instead of hiding the error
+func (p *Parser) parseArguments() ([]ast.Expression, bool) {
 	var args []ast.Expression
 
-	if !p.expectCurrent(lexer.TOKEN_LPAREN) {
-		return nil
+	if !p.expectCurrent(lexer.KIND_LPAREN) {
+		return nil, false
 	}
 
 	p.nextToken()
 
-	if p.curTok.Type == lexer.TOKEN_RPAREN {
+	if p.curTok.Kind == lexer.KIND_RPAREN {
 		p.nextToken()
-		return args
+		return args, true
 	}
 
-	for p.curTok.Type != lexer.TOKEN_RPAREN && p.curTok.Type != lexer.TOKEN_EOF {
-		arg := p.parseExpression(0)
-		if arg == nil {
-			return nil
-		}
-		args = append(args, arg)
+	for p.curTok.Kind != lexer.KIND_RPAREN && p.curTok.Kind != lexer.KIND_EOF {
+		args = append(args, p.parseListElement())
 
-		if p.curTok.Type == lexer.TOKEN_COMMA {
+		if p.curTok.Kind == lexer.KIND_COMMA {
 			p.nextToken()
 		} else {
 			break
 		}
 	}
 
-	if !p.expectCurrent(lexer.TOKEN_RPAREN) {
-		p.addError("Missing closing ')'", p.curTok.Line, p.curTok.Col)
-		return nil
+	if !p.expectCurrent(lexer.KIND_RPAREN) {
+		return nil, false
 	}
 
 	p.nextToken()
-	return args
+	return args, true
+}
+
+// spanFrom returns the span from the start of the token start to the end of the last consumed token
+func (p *Parser) spanFrom(start lexer.Token) ast.Span {
+	span := ast.Span{File: p.file, Start: start.Start(), End: p.prevTok.End}
+	if p.prevTok.End.Offset < start.Offset {
+		span.End = span.Start // Node rỗng (chưa dùng token nào)
+	}
+	return span
+}
+
+// setSpan gives node the span from start to the last consumed token, unless it already has one
+// (so (a + b) keeps the span of a + b, without the parentheses)
+func (p *Parser) setSpan(node ast.Node, start lexer.Token) {
+	if node.SourceSpan().IsZero() {
+		node.SetSpan(p.spanFrom(start))
+	}
+}
+
+// parseIdentifier makes an identifier of the current token and moves past it
+func (p *Parser) parseIdentifier() *ast.Identifier {
+	ident := &ast.Identifier{Value: p.curTok.Value, Line: p.curTok.Line}
+	p.nextToken()
+	p.setSpan(ident, p.prevTok)
+	return ident
 }
 
 // Hàm addError dùng SyntaxError.Error()
-func (p *Parser) addError(message string, line, col int) {
+// Chỉ lỗi đầu tiên của một statement được ghi, các lỗi sau thường chỉ là hệ quả của nó.
+// Statement sau bắt đầu đúng ở token gây lỗi (x = 1 +\n* 2) cũng không báo lại lỗi ở token đó.
+func (p *Parser) addError(code customError.Code, line, col int, args ...interface{}) *customError.SyntaxError {
+	if p.recovering || p.consumed == p.errorAt {
+		return nil
+	}
+	p.recovering = true
+	p.errorAt = p.consumed
+
 	err := customError.SyntaxError{
 		PunError: customError.PunError{
-			Message: message,
+			Code:    code,
+			Message: customError.Message(code, args...),
 			Line:    line,
 			Column:  col,
 		},
-		Context: fmt.Sprintf("Near token: %q (Type: %s)", p.curTok.Value, p.curTok.Type),
+		Context: customError.Text("Near token: %q (Type: %s)", p.curTok.Value, p.curTok.Type()),
+	}
+	// Lỗi ở đúng một token: gạch chân cả token đó
+	for _, tok := range []lexer.Token{p.curTok, p.peekTok, p.prevTok} {
+		if tok.Line == line && tok.Col == col {
+			err.EndLine, err.EndColumn = tok.End.Line, tok.End.Col
+			break
+		}
 	}
 	p.errors = append(p.errors, err)
+	return &p.errors[len(p.errors)-1]
+}
+
+// closes maps a closing bracket to the opening brackets it can close
+var closes = map[lexer.Kind][]lexer.Kind{
+	lexer.KIND_RPAREN:  {lexer.KIND_LPAREN},
+	lexer.KIND_RSQUARE: {lexer.KIND_LSQUARE, lexer.KIND_FROZEN_ARRAY_OPEN},
+	lexer.KIND_RCURLY:  {lexer.KIND_LCURLY, lexer.KIND_SET_OPEN},
 }
 
-func (p *Parser) expectPeek(t string) bool {
-	if p.peekTok.Type == t {
+// labelOpener points err at the innermost open bracket that closer would close
+func (p *Parser) labelOpener(err *customError.SyntaxError, closer lexer.Kind) {
+	if err == nil || len(p.openers) == 0 {
+		return
+	}
+	opener := p.openers[len(p.openers)-1]
+	for _, k := range closes[closer] {
+		if opener.Kind == k {
+			err.Labels = append(err.Labels, customError.Label{
+				Line:      opener.Line,
+				Column:    opener.Col,
+				EndColumn: opener.End.Col,
+				Message:   customError.Text("unclosed %q opened here", opener.Value),
+			})
+			return
+		}
+	}
+}
+
+func (p *Parser) expectPeek(k lexer.Kind) bool {
+	if p.peekTok.Kind == k {
 		p.nextToken()
 		return true
 	}
-	p.addError(
-		fmt.Sprintf("Expected next token to be %s, got %s instead", t, p.peekTok.Type),
-		p.peekTok.Line,
-		p.peekTok.Col,
-	)
+	err := p.addError(customError.ExpectedToken, p.peekTok.Line, p.peekTok.Col, kindName(k), kindName(p.peekTok.Kind))
+	p.labelOpener(err, k)
 	return false
 }
 
-func (p *Parser) expectCurrent(t string) bool {
-	if p.curTok.Type == t {
+func (p *Parser) expectCurrent(k lexer.Kind) bool {
+	if p.curTok.Kind == k {
 		return true
 	}
-	p.addError(
-		fmt.Sprintf("Expected current token to be %s, got %s instead", t, p.curTok.Type),
-		p.curTok.Line,
-		p.curTok.Col,
-	)
+	err := p.addError(customError.ExpectedToken, p.curTok.Line, p.curTok.Col, kindName(k), kindName(p.curTok.Kind))
+	p.labelOpener(err, k)
 	return false
 }
 
-// PrintErrors sẽ gọi SyntaxError.Error()
-func (p *Parser) PrintErrors() {
+// kindName names a token kind in the language of the messages
+func kindName(k lexer.Kind) string {
+	return customError.Text(k.String())
+}
+
+// PrintErrors shows every syntax error with its source line
+func (p *Parser) PrintErrors(r *customError.Renderer) {
 	if !p.HasErrors() {
 		return
 	}
 
-	fmt.Println("🚨 PARSER ERRORS:")
-	for i, err := range p.errors {
-		fmt.Printf("%d. %s\n", i+1, err.Error()) // Gọi phương thức Error() đã override
+	fmt.Println("🚨 " + customError.Text("PARSER ERRORS:"))
+	for i := range p.errors {
+		fmt.Printf("%d. %s\n", i+1, r.Render(&p.errors[i]))
 		fmt.Println(strings.Repeat("─", 60))
 	}
 }
 
+// Tokens returns every token of the source up to EOF, with its trivia, when the lexer is in
+// lossless mode (nil otherwise). Call it after ParseProgram.
+func (p *Parser) Tokens() []lexer.Token {
+	return p.tokens
+}
+
 func (p *Parser) HasErrors() bool {
 	return len(p.errors) > 0
 }
 
-func (p *Parser) syncTo(syncTokenType string) {
-	for p.curTok.Type != syncTokenType && p.curTok.Type != lexer.TOKEN_EOF {
+// Diagnostics returns the syntax errors found so far, for tools that report them themselves
+func (p *Parser) Diagnostics() []customError.Diagnostic {
+	diagnostics := make([]customError.Diagnostic, len(p.errors))
+	for i := range p.errors {
+		diagnostics[i] = &p.errors[i]
+	}
+	return diagnostics
+}
+
+// Từ khóa luôn mở đầu một statement mới
+var statementKeywords = map[lexer.Kind]bool{
+	lexer.KIND_FOR: true, lexer.KIND_WHILE: true, lexer.KIND_UNTIL: true, lexer.KIND_FUNC: true,
+	lexer.KIND_RETURN: true, lexer.KIND_DEFER: true, lexer.KIND_RECORD: true, lexer.KIND_ENUM: true,
+	lexer.KIND_BREAK: true, lexer.KIND_CONTINUE: true,
+}
+
+func (p *Parser) atStatementKeyword() bool {
+	return statementKeywords[p.curTok.Kind]
+}
+
+// bracketDepth returns +1 for an opening bracket, -1 for a closing one and 0 otherwise
+func bracketDepth(kind lexer.Kind) int {
+	switch kind {
+	case lexer.KIND_LPAREN, lexer.KIND_LSQUARE, lexer.KIND_LCURLY, lexer.KIND_SET_OPEN, lexer.KIND_FROZEN_ARRAY_OPEN:
+		return 1
+	case lexer.KIND_RPAREN, lexer.KIND_RSQUARE, lexer.KIND_RCURLY:
+		return -1
+	}
+	return 0
+}
+
+// synchronize skips the rest of a statement that failed to parse, up to the start of the next
+// statement: the first token of a new line, a statement keyword, or the '}' that closes the
+// enclosing block. Brackets opened while skipping are skipped as a whole, and so are the rest of
+// the brackets the statement had opened on the line of the error, or the braces it had opened
+// (a broken match does not leave its arms to be parsed as statements).
+// start is the value of p.consumed and openers the number of open brackets when the statement began.
+func (p *Parser) synchronize(start, openers int) {
+	failed := len(p.openers) // Các dấu ngoặc mở từ đây trở đi được mở trong lúc bỏ qua
+	if p.consumed == start && p.curTok.Kind != lexer.KIND_EOF {
+		p.nextToken() // Statement không dùng được token nào: bỏ token lỗi để không lặp vô hạn
+	}
+
+	for p.curTok.Kind != lexer.KIND_EOF {
+		if len(p.openers) > openers {
+			// Còn trong dấu ngoặc mà statement lỗi đã mở. Dấu đóng của ngoặc khác loại thuộc về bên
+			// ngoài (print(x }), còn ( và [ mở trước lỗi không kéo sang dòng sau (y = (3 + 4):
+			// coi như dấu mở không bao giờ được đóng.
+			top := len(p.openers) - 1
+			if (bracketDepth(p.curTok.Kind) < 0 && !p.closesOpener(p.curTok.Kind)) ||
+				(top < failed && p.openers[top].Kind != lexer.KIND_LCURLY && p.curTok.Line > p.prevTok.Line) {
+				p.openers = p.openers[:top]
+				continue
+			}
+		} else if p.curTok.Kind == lexer.KIND_RCURLY || p.curTok.Line > p.prevTok.Line || p.atStatementKeyword() {
+			break
+		}
+		p.nextToken()
+	}
+}
+
+// closesOpener reports whether the closing bracket closer closes the innermost open bracket
+func (p *Parser) closesOpener(closer lexer.Kind) bool {
+	if len(p.openers) == 0 {
+		return false
+	}
+	return slices.Contains(closes[closer], p.openers[len(p.openers)-1].Kind)
+}
+
+// parseListElement parses one element of an argument list or collection literal.
+// A broken element becomes a BadExpression and the tokens up to the next ',' or the closing
+// bracket are skipped, so the rest of the list is still parsed.
+func (p *Parser) parseListElement() ast.Expression {
+	start := p.curTok
+	defer p.insideBrackets()()
+	if elem := p.parseExpression(precLowest); elem != nil {
+		return elem
+	}
+
+	depth := 0
+	for p.curTok.Kind != lexer.KIND_EOF && !p.atStatementKeyword() {
+		if depth == 0 && (p.curTok.Kind == lexer.KIND_COMMA || bracketDepth(p.curTok.Kind) < 0) {
+			break
+		}
+		depth += bracketDepth(p.curTok.Kind)
 		p.nextToken()
 	}
+	bad := &ast.BadExpression{Line: start.Line}
+	p.setSpan(bad, start)
+	return bad
 }
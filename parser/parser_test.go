@@ -0,0 +1,88 @@
+package parser
+
+import (
+	"pun/ast"
+	"pun/error"
+	"pun/lexer"
+	"slices"
+	"testing"
+)
+
+// parse parses input and returns the program with the codes of its syntax errors
+func parse(input string) (*ast.Program, []customError.Code) {
+	p := NewParser(lexer.NewLexer(input))
+	program := p.ParseProgram()
+	var codes []customError.Code
+	for _, d := range p.Diagnostics() {
+		codes = append(codes, d.Base().Code)
+	}
+	return program, codes
+}
+
+func TestErrorRecovery(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []customError.Code
+	}{
+		{"missing comma in call", "print(x y)\nprint(1)", []customError.Code{customError.ExpectedToken}},
+		{"missing comma in method call", "x.foo(1 2).bar()\nprint(1)", []customError.Code{customError.ExpectedToken}},
+		{"broken call in function body", "func g() {\n  print(1 2)\n  print(3)\n}\ng()", []customError.Code{customError.ExpectedToken}},
+		{"broken call in block", "if x { print(1 2) }\nprint(2)", []customError.Code{customError.ExpectedToken}},
+		{"call closed by block brace", "func h() {\n  y = f(1 }\nprint(7)", []customError.Code{customError.ExpectedToken}},
+		{"match arm with =>", "match c {\n  1 => print(1)\n  2 => print(2)\n}\nprint(3)", []customError.Code{customError.ExpectedToken}},
+		{"unclosed parenthesis", "y = (3 + 4\nprint(y)\nz = 1 +\n", []customError.Code{customError.MissingCloseParen, customError.UnexpectedToken}},
+		{"one error per statement", "x = 1 + * 2\nprint(1 2)\nz = [1, 2, )", []customError.Code{customError.UnexpectedToken, customError.ExpectedToken, customError.UnexpectedToken}},
+		{"error after a broken match", "match 1 {\n  1 => 2\n}\nprint(3 +)", []customError.Code{customError.ExpectedToken, customError.UnexpectedToken}},
+		{"invalid UTF-8 byte", "x = 1 \xff + 2\nprint(\xfe x)", []customError.Code{customError.InvalidUTF8, customError.InvalidUTF8}},
+		{"broken list element", "print(f(1, +, 3))\nprint(2)", []customError.Code{customError.UnexpectedToken}},
+		{"for-in without body", "for x in xs print(x)\nprint(1)", []customError.Code{customError.ExpectedToken}},
+		{"error at the start of a line", "print(y\nz = [1, 2\nw = 3", []customError.Code{customError.ExpectedToken, customError.ExpectedToken}},
+		{"missing comma in array", "print([1 2])\nprint(3 +)", []customError.Code{customError.ExpectedToken, customError.UnexpectedToken}},
+		{"same token reported once", "x = 1 +\n* 2\nprint(x)", []customError.Code{customError.UnexpectedToken}},
+		{"stray closers on their own lines", ")\n)\nprint(1 2)", []customError.Code{customError.UnexpectedToken, customError.UnexpectedToken, customError.ExpectedToken}},
+	}
+	for _, tt := range tests {
+		program, codes := parse(tt.input)
+		if !slices.Equal(codes, tt.expected) {
+			t.Errorf("%s: got errors %v, want %v", tt.name, codes, tt.expected)
+		}
+		if program == nil || len(program.Statements) == 0 {
+			t.Errorf("%s: no statements parsed", tt.name)
+		}
+	}
+}
+
+func TestForIn(t *testing.T) {
+	tests := []struct {
+		input    string
+		label    string
+		variable string
+		hasElse  bool
+	}{
+		{"for x in [1, 2] { print(x) }", "", "x", false},
+		{"for v in #{1, 2} {}", "", "v", false},
+		{"outer: for item in items { break outer } else { print(0) }", "outer", "item", true},
+	}
+	for _, tt := range tests {
+		program, codes := parse(tt.input)
+		if len(codes) > 0 {
+			t.Errorf("%q: unexpected errors %v", tt.input, codes)
+			continue
+		}
+		loop, ok := program.Statements[0].(*ast.ForInStatement)
+		if !ok {
+			t.Errorf("%q: got %T, want *ast.ForInStatement", tt.input, program.Statements[0])
+			continue
+		}
+		if loop.Label != tt.label || loop.Variable.Value != tt.variable || (loop.ElseBlock != nil) != tt.hasElse {
+			t.Errorf("%q: got label %q, variable %q, else %v", tt.input, loop.Label, loop.Variable.Value, loop.ElseBlock != nil)
+		}
+	}
+
+	// Không có "in" sau tên biến: vẫn là vòng lặp kiểu C
+	program, codes := parse("for i = 0; i < 3; i = i + 1 {}")
+	if _, ok := program.Statements[0].(*ast.ForStatement); !ok || len(codes) > 0 {
+		t.Errorf("C-style for: got %T, errors %v", program.Statements[0], codes)
+	}
+}
@@ -0,0 +1,98 @@
+package parser
+
+import (
+	"fmt"
+	"pun/ast"
+	"pun/lexer"
+	"testing"
+)
+
+const spanSource = `outer: for i = 0; i < 3; i = i + 1 {
+    for j = 0; j < 3; j = j + 1 {
+        if j == 1 { continue   outer }
+        if i == 2 { break outer }
+        break
+    }
+}
+func area(w, h) {
+    return nothing
+}
+x = nothing ?? 1
+`
+
+// spanText returns the source text covered by the span of n
+func spanText(n ast.Node) string {
+	span := n.SourceSpan()
+	return spanSource[span.Start.Offset:span.End.Offset]
+}
+
+// nodesOf lists the nodes of the tree in source order
+func nodesOf(n ast.Node) []ast.Node {
+	nodes := []ast.Node{n}
+	for _, child := range ast.Children(n) {
+		nodes = append(nodes, nodesOf(child)...)
+	}
+	return nodes
+}
+
+func TestSpans(t *testing.T) {
+	p := NewParser(lexer.NewFileLexer("spans.pun", spanSource))
+	program := p.ParseProgram()
+	if p.HasErrors() {
+		t.Fatalf("unexpected errors: %v", p.Diagnostics())
+	}
+
+	// Văn bản mà span của mỗi node cần bao, theo thứ tự trong source
+	var got []string
+	for _, n := range nodesOf(program) {
+		switch n := n.(type) {
+		case *ast.BreakStatement, *ast.ContinueStatement, *ast.NothingExpression:
+			got = append(got, fmt.Sprintf("%T %s", n, spanText(n)))
+		case *ast.FunctionDefinitionStatement:
+			got = append(got, fmt.Sprintf("%T %s", n.Name, spanText(n.Name)))
+			for _, param := range n.Parameters {
+				got = append(got, fmt.Sprintf("%T %s", param, spanText(param)))
+			}
+		}
+	}
+	expected := []string{
+		"*ast.ContinueStatement continue   outer",
+		"*ast.BreakStatement break outer",
+		"*ast.BreakStatement break",
+		"*ast.Identifier area",
+		"*ast.Identifier w",
+		"*ast.Identifier h",
+		"*ast.NothingExpression nothing",
+		"*ast.NothingExpression nothing",
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("got spans\n%q\nwant\n%q", got, expected)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("span %d: got %q, want %q", i, got[i], expected[i])
+		}
+	}
+}
+
+func TestSpanFile(t *testing.T) {
+	p := NewParser(lexer.NewFileLexer("spans.pun", spanSource))
+	program := p.ParseProgram()
+	for _, n := range nodesOf(program) {
+		span := n.SourceSpan()
+		if span.File != "spans.pun" {
+			t.Errorf("%T at %d:%d has file %q, want %q", n, span.Start.Line, span.Start.Col, span.File, "spans.pun")
+		}
+		if span.IsZero() {
+			t.Errorf("%T has no span", n)
+		}
+	}
+
+	// Không có tên file (REPL): span vẫn có vị trí nhưng File rỗng
+	program = NewParser(lexer.NewLexer("print(1)")).ParseProgram()
+	for _, n := range nodesOf(program) {
+		if span := n.SourceSpan(); span.File != "" || span.IsZero() {
+			t.Errorf("%T: got span %+v", n, span)
+		}
+	}
+}
@@ -1,34 +1,75 @@
 package parser
 
 import (
-	"fmt"
 	"pun/ast"
+	"pun/error"
 	"pun/lexer"
 )
 
+// parseStatement parses one statement. It never returns nil: a statement with a syntax error
+// becomes a BadStatement and the parser skips to the start of the next statement.
 func (p *Parser) parseStatement() ast.Statement {
-	switch p.curTok.Value {
-	case "if":
-		return p.parseIfStatement()
-	case "for":
-		return p.parseForStatement()
-	case "while":
-		return p.parseWhileStatement()
-	case "until":
-		return p.parseUntilStatement()
-	case "break":
+	startTok, start, openers := p.curTok, p.consumed, len(p.openers)
+	p.recovering = false // Statement mới: lỗi của nó không phải hệ quả của lỗi trước
+	if stmt := p.tryParseStatement(); stmt != nil {
+		p.setSpan(stmt, startTok)
+		return stmt
+	}
+
+	p.addError(customError.InvalidStatement, p.curTok.Line, p.curTok.Col) // Chỉ ghi nếu chưa có lỗi nào được báo
+	p.synchronize(start, openers)
+	bad := &ast.BadStatement{Line: startTok.Line}
+	p.setSpan(bad, startTok)
+	return bad
+}
+
+// tryParseStatement parses one statement, returning nil (with the error reported) if it is invalid
+func (p *Parser) tryParseStatement() ast.Statement {
+	// Các hàm parse trả về con trỏ cụ thể: đổi con trỏ nil thành interface nil
+	switch p.curTok.Kind {
+	case lexer.KIND_IF:
+		if stmt := p.parseIfStatement(); stmt != nil {
+			return stmt
+		}
+		return nil
+	case lexer.KIND_FOR:
+		return p.parseFor("")
+	case lexer.KIND_WHILE:
+		if stmt := p.parseWhileStatement(); stmt != nil {
+			return stmt
+		}
+		return nil
+	case lexer.KIND_UNTIL:
+		if stmt := p.parseUntilStatement(); stmt != nil {
+			return stmt
+		}
+		return nil
+	case lexer.KIND_BREAK:
 		return p.parseBreakStatement()
-	case "continue":
+	case lexer.KIND_CONTINUE:
 		return p.parseContinueStatement()
-	case "return":
+	case lexer.KIND_RETURN:
 		return p.parseReturnStatement()
-	case "func":
+	case lexer.KIND_FUNC:
 		return p.parseFunctionDefinitionStatement()
-	case "++", "--":
-		return p.parseIncDecStatement()
+	case lexer.KIND_DEFER:
+		return p.parseDeferStatement()
+	case lexer.KIND_RECORD:
+		return p.parseRecordStatement()
+	case lexer.KIND_ENUM:
+		return p.parseEnumStatement()
+	case lexer.KIND_MATCH:
+		return p.parseMatchStatement()
 	default:
-		//If the current token is an identifier and the next token is =, then we know this is an assignment
-		if p.curTok.Type == lexer.TOKEN_IDENTIFIER {
+		// Nhãn của vòng lặp: outer: for ...
+		if p.curTok.Kind == lexer.KIND_IDENTIFIER && p.peekTok.Kind == lexer.KIND_COLON {
+			return p.parseLabeledStatement()
+		}
+
+		// Còn lại là biểu thức: phép gán nếu theo sau là =, không thì là expression statement
+		// (lệnh cuối của một block có thể là giá trị của block: if a > b { a } else { b })
+		if !p.curTok.Kind.IsKeyword() {
+			line := p.curTok.Line
 			// Parse expression cơ bản trước
 			expr := p.parseExpression(0)
 			if expr == nil {
@@ -36,33 +77,33 @@ func (p *Parser) parseStatement() ast.Statement {
 			}
 
 			// Xử lý theo token tiếp theo
-			switch p.curTok.Type {
-			case lexer.TOKEN_ASSIGN:
+			switch p.curTok.Kind {
+			case lexer.KIND_ASSIGN:
 				return p.parseAssignStatement(expr)
 			default: //Các trường hợp còn lại
-				return &ast.ExpressionStatement{Expression: expr, Line: p.curTok.Line}
+				return &ast.ExpressionStatement{Expression: expr, Line: line}
 			}
 		}
 	}
 
-	p.addError(fmt.Sprintf("Unexpected statement: %s", p.curTok.Value), p.curTok.Line, p.curTok.Col)
+	p.addError(customError.UnexpectedStatement, p.curTok.Line, p.curTok.Col, p.curTok.Value)
 	return nil
 }
 
 func (p *Parser) parseAssignStatement(expr ast.Expression) ast.Statement {
-	stmt := &ast.AssignStatement{Line: p.curTok.Line}
+	stmt := &ast.AssignStatement{Line: expr.SourceSpan().Start.Line}
 
 	// Parse left-hand side
 	left := expr
 	if left == nil || !p.isValidAssignmentTarget(left) {
-		p.addError("Invalid assignment target", p.curTok.Line, p.curTok.Col)
+		p.addError(customError.InvalidAssignTarget, p.curTok.Line, p.curTok.Col)
 		return nil
 	}
 
 	stmt.Name = left
 
 	// Check and consume '='
-	if !p.expectCurrent(lexer.TOKEN_ASSIGN) {
+	if !p.expectCurrent(lexer.KIND_ASSIGN) {
 		return nil
 	}
 	p.nextToken()
@@ -70,7 +111,7 @@ func (p *Parser) parseAssignStatement(expr ast.Expression) ast.Statement {
 	// Parse right-hand side
 	stmt.Value = p.parseExpression(0)
 	if stmt.Value == nil {
-		p.addError("Invalid value in assignment", p.curTok.Line, p.curTok.Col)
+		p.addError(customError.InvalidAssignValue, p.curTok.Line, p.curTok.Col)
 		return nil
 	}
 
@@ -89,23 +130,24 @@ func (p *Parser) isValidAssignmentTarget(expr ast.Expression) bool {
 
 func (p *Parser) parseIfStatement() *ast.IfStatement {
 	ifStmt := &ast.IfStatement{Line: p.curTok.Line}
+	start := p.curTok
 	p.nextToken()
-	condition := p.parseExpression(0)
+	condition := p.parseCondition()
 
 	if condition == nil {
-		p.addError("Invalid condition in 'when' statement", p.curTok.Line, p.curTok.Col)
+		p.addError(customError.InvalidCondition, p.curTok.Line, p.curTok.Col, "if")
 		return nil
 	}
 
 	ifStmt.Condition = condition
 
-	if !p.expectCurrent(lexer.TOKEN_LCURLY) {
+	if !p.expectCurrent(lexer.KIND_LCURLY) {
 		return nil
 	}
 
 	ifStmt.Body = p.parseBlockStatement()
 
-	if !p.expectCurrent(lexer.TOKEN_RCURLY) {
+	if !p.expectCurrent(lexer.KIND_RCURLY) {
 		return nil
 	}
 
@@ -113,264 +155,572 @@ func (p *Parser) parseIfStatement() *ast.IfStatement {
 
 	ifStmt.ElseIfs = []*ast.ElifStatement{}
 
-	for p.curTok.Value == "elif" {
+	for p.curTok.Kind == lexer.KIND_ELIF {
 		elifStmt := p.parseElifStatement()
-		if elifStmt != nil {
-			ifStmt.ElseIfs = append(ifStmt.ElseIfs, elifStmt)
+		if elifStmt == nil {
+			return nil
 		}
+		ifStmt.ElseIfs = append(ifStmt.ElseIfs, elifStmt)
 	}
 
-	if p.curTok.Value == "else" {
-		ifStmt.ElseBlock = p.parseElseStatement()
+	if p.curTok.Kind == lexer.KIND_ELSE {
+		if ifStmt.ElseBlock = p.parseElseStatement(); ifStmt.ElseBlock == nil {
+			return nil
+		}
 	}
 
+	p.setSpan(ifStmt, start)
 	return ifStmt
 }
 
 func (p *Parser) parseElifStatement() *ast.ElifStatement {
 	elifStmt := &ast.ElifStatement{Line: p.curTok.Line}
+	start := p.curTok
 	p.nextToken()
-	condition := p.parseExpression(0)
+	condition := p.parseCondition()
 
 	if condition == nil {
-		p.addError("Invalid condition in 'elif' statement", p.curTok.Line, p.curTok.Col)
+		p.addError(customError.InvalidCondition, p.curTok.Line, p.curTok.Col, "elif")
 		return nil
 	}
 
 	elifStmt.Condition = condition
 
-	if !p.expectCurrent(lexer.TOKEN_LCURLY) {
+	if !p.expectCurrent(lexer.KIND_LCURLY) {
 		return nil
 	}
 
 	elifStmt.Body = p.parseBlockStatement()
 
-	if !p.expectCurrent(lexer.TOKEN_RCURLY) {
+	if !p.expectCurrent(lexer.KIND_RCURLY) {
 		return nil
 	}
 
 	p.nextToken()
+	p.setSpan(elifStmt, start)
 	return elifStmt
 }
 
 func (p *Parser) parseElseStatement() *ast.ElseStatement {
 	elseStmt := &ast.ElseStatement{Line: p.curTok.Line}
+	start := p.curTok
 
 	p.nextToken()
-	if !p.expectCurrent(lexer.TOKEN_LCURLY) {
+	if !p.expectCurrent(lexer.KIND_LCURLY) {
 		return nil
 	}
 
 	elseStmt.Body = p.parseBlockStatement()
 
-	if !p.expectCurrent(lexer.TOKEN_RCURLY) {
+	if !p.expectCurrent(lexer.KIND_RCURLY) {
 		return nil
 	}
 
 	p.nextToken()
+	p.setSpan(elseStmt, start)
 	return elseStmt
 
 }
 
 func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	block := &ast.BlockStatement{Line: p.curTok.Line}
+	start := p.curTok
+	defer p.insideBrackets()()
 	p.nextToken()
-	for p.curTok.Type != lexer.TOKEN_RCURLY && p.curTok.Type != lexer.TOKEN_EOF {
-		stmt := p.parseStatement()
-		if stmt == nil {
-			p.addError("Invalid statement", p.curTok.Line, p.curTok.Col)
-			p.nextToken() // ⚠️ Quan trọng: Phải consume token lỗi để tránh infinite loop
-			continue
-		}
-		block.Statements = append(block.Statements, stmt)
+	for p.curTok.Kind != lexer.KIND_RCURLY && p.curTok.Kind != lexer.KIND_EOF {
+		block.Statements = append(block.Statements, p.parseStatement())
+	}
+
+	// Block gồm cả hai dấu ngoặc, dù dấu } do nơi gọi bỏ qua
+	block.SetSpan(p.spanFrom(start))
+	if p.curTok.Kind == lexer.KIND_RCURLY {
+		block.End = p.curTok.End
 	}
 	return block
 }
 
-func (p *Parser) parseForStatement() *ast.ForStatement {
-	forStmt := &ast.ForStatement{Line: p.curTok.Line}
+// parseFor parses `for x in xs { ... }` or the C-style `for init; condition; update { ... }`
+func (p *Parser) parseFor(label string) ast.Statement {
+	line := p.curTok.Line
+	p.nextToken() // Bỏ qua "for"
+
+	if p.curTok.Kind == lexer.KIND_IDENTIFIER && p.peekTok.Kind == lexer.KIND_IN {
+		if stmt := p.parseForInStatement(line); stmt != nil {
+			stmt.Label = label
+			return stmt
+		}
+		return nil
+	}
+	if stmt := p.parseForStatement(line); stmt != nil {
+		stmt.Label = label
+		return stmt
+	}
+	return nil
+}
+
+func (p *Parser) parseForInStatement(line int) *ast.ForInStatement {
+	forIn := &ast.ForInStatement{Line: line, Variable: p.parseIdentifier()}
+	p.nextToken() // Bỏ qua "in"
+
+	if forIn.Iterable = p.parseCondition(); forIn.Iterable == nil {
+		return nil
+	}
+
+	if !p.expectCurrent(lexer.KIND_LCURLY) {
+		return nil
+	}
+
+	forIn.Body = p.parseBlockStatement()
+
+	if !p.expectCurrent(lexer.KIND_RCURLY) {
+		return nil
+	}
+
 	p.nextToken()
 
-	init := p.parseStatement()
+	if p.curTok.Kind == lexer.KIND_ELSE {
+		if forIn.ElseBlock = p.parseElseStatement(); forIn.ElseBlock == nil {
+			return nil
+		}
+	}
+	return forIn
+}
+
+// parseForStatement parses the C-style loop after "for"
+func (p *Parser) parseForStatement(line int) *ast.ForStatement {
+	forStmt := &ast.ForStatement{Line: line}
+
+	initStart := p.curTok
+	init := p.tryParseStatement()
 
 	if init == nil {
-		p.addError("Wrong init statement!!!", p.curTok.Line, p.curTok.Col)
+		p.addError(customError.InvalidForInit, p.curTok.Line, p.curTok.Col)
 		return nil
 	}
+	p.setSpan(init, initStart)
 
-	if !p.expectCurrent(lexer.TOKEN_SEMICOLON) {
+	if !p.expectCurrent(lexer.KIND_SEMICOLON) {
 		return nil
 	}
 
 	p.nextToken()
 
-	condition := p.parseExpression(0)
+	condition := p.parseCondition()
 	if condition == nil {
-		p.addError("Invalid condition in 'for' statement", p.curTok.Line, p.curTok.Col)
+		p.addError(customError.InvalidCondition, p.curTok.Line, p.curTok.Col, "for")
 		return nil
 	}
 
-	if !p.expectCurrent(lexer.TOKEN_SEMICOLON) {
+	if !p.expectCurrent(lexer.KIND_SEMICOLON) {
 		return nil
 	}
 
 	p.nextToken()
 
-	update := p.parseStatement()
+	updateStart := p.curTok
+	update := p.tryParseStatement()
 
 	if update == nil {
-		p.addError("Wrong update statement!!!", p.curTok.Line, p.curTok.Col)
+		p.addError(customError.InvalidForUpdate, p.curTok.Line, p.curTok.Col)
 		return nil
 	}
+	p.setSpan(update, updateStart)
 
 	forStmt.Init = init
 	forStmt.Condition = condition
 	forStmt.Update = update
 
-	if !p.expectCurrent(lexer.TOKEN_LCURLY) {
+	if !p.expectCurrent(lexer.KIND_LCURLY) {
 		return nil
 	}
 
 	forStmt.Body = p.parseBlockStatement()
 
-	if !p.expectCurrent(lexer.TOKEN_RCURLY) {
+	if !p.expectCurrent(lexer.KIND_RCURLY) {
 		return nil
 	}
 
 	p.nextToken()
+
+	if p.curTok.Kind == lexer.KIND_ELSE {
+		if forStmt.ElseBlock = p.parseElseStatement(); forStmt.ElseBlock == nil {
+			return nil
+		}
+	}
 	return forStmt
 }
 
 func (p *Parser) parseWhileStatement() *ast.WhileStatement {
 	whileStmt := &ast.WhileStatement{Line: p.curTok.Line}
 	p.nextToken()
-	condition := p.parseExpression(0)
+	condition := p.parseCondition()
 
 	if condition == nil {
-		p.addError("Invalid condition in 'while' statement", p.curTok.Line, p.curTok.Col)
+		p.addError(customError.InvalidCondition, p.curTok.Line, p.curTok.Col, "while")
 		return nil
 	}
 
 	whileStmt.Condition = condition
 
-	if !p.expectCurrent(lexer.TOKEN_LCURLY) {
+	if !p.expectCurrent(lexer.KIND_LCURLY) {
 		return nil
 	}
 
 	whileStmt.Body = p.parseBlockStatement()
 
-	if !p.expectCurrent(lexer.TOKEN_RCURLY) {
+	if !p.expectCurrent(lexer.KIND_RCURLY) {
 		return nil
 	}
 
 	p.nextToken()
+
+	if p.curTok.Kind == lexer.KIND_ELSE {
+		if whileStmt.ElseBlock = p.parseElseStatement(); whileStmt.ElseBlock == nil {
+			return nil
+		}
+	}
 	return whileStmt
 }
 
 func (p *Parser) parseUntilStatement() *ast.UntilStatement {
 	untilStmt := &ast.UntilStatement{Line: p.curTok.Line}
 	p.nextToken()
-	condition := p.parseExpression(0)
+	condition := p.parseCondition()
 
 	if condition == nil {
-		p.addError("Invalid condition in 'until' statement", p.curTok.Line, p.curTok.Col)
+		p.addError(customError.InvalidCondition, p.curTok.Line, p.curTok.Col, "until")
 		return nil
 	}
 
 	untilStmt.Condition = condition
 
-	if !p.expectCurrent(lexer.TOKEN_LCURLY) {
+	if !p.expectCurrent(lexer.KIND_LCURLY) {
 		return nil
 	}
 
 	untilStmt.Body = p.parseBlockStatement()
 
-	if !p.expectCurrent(lexer.TOKEN_RCURLY) {
+	if !p.expectCurrent(lexer.KIND_RCURLY) {
 		return nil
 	}
 
 	p.nextToken()
+
+	if p.curTok.Kind == lexer.KIND_ELSE {
+		if untilStmt.ElseBlock = p.parseElseStatement(); untilStmt.ElseBlock == nil {
+			return nil
+		}
+	}
 	return untilStmt
 }
 
-func (p *Parser) parseFunctionDefinitionStatement() *ast.FunctionDefinitionStatement {
-	stmt := &ast.FunctionDefinitionStatement{Line: p.curTok.Line}
+func (p *Parser) parseFunctionDefinitionStatement() ast.Statement {
+	line := p.curTok.Line
 	p.nextToken()
 
-	if !p.expectCurrent(lexer.TOKEN_IDENTIFIER) {
+	if !p.expectCurrent(lexer.KIND_IDENTIFIER) {
+		return nil
+	}
+
+	name := p.parseIdentifier()
+
+	// func Vec.length() { ... } là method của record Vec
+	if p.curTok.Kind == lexer.KIND_DOT {
+		p.nextToken()
+		if !p.expectCurrent(lexer.KIND_IDENTIFIER) {
+			return nil
+		}
+		method := &ast.MethodDefinitionStatement{
+			Receiver: name,
+			Name:     p.parseIdentifier(),
+			Line:     line,
+		}
+		method.Parameters, method.Body = p.parseFunctionRest()
+		if method.Body == nil {
+			return nil
+		}
+		return method
+	}
+
+	stmt := &ast.FunctionDefinitionStatement{Name: name, Line: line}
+	stmt.Parameters, stmt.Body = p.parseFunctionRest()
+	if stmt.Body == nil {
 		return nil
 	}
+	return stmt
+}
 
-	stmt.Name = &ast.Identifier{Value: p.curTok.Value}
+// parseFunctionRest parses the parameter list and the body of a function or method
+func (p *Parser) parseFunctionRest() ([]*ast.Identifier, *ast.BlockStatement) {
+	params := p.parseParameterList()
+	if params == nil {
+		return nil, nil
+	}
+
+	if !p.expectCurrent(lexer.KIND_LCURLY) {
+		return nil, nil
+	}
+	body := p.parseBlockStatement()
+
+	if !p.expectCurrent(lexer.KIND_RCURLY) {
+		return nil, nil
+	}
 
 	p.nextToken()
 
-	if !p.expectCurrent(lexer.TOKEN_LPAREN) {
+	return params, body
+}
+
+// parseParameterList parses (a, b, c), returning nil on error
+func (p *Parser) parseParameterList() []*ast.Identifier {
+	if !p.expectCurrent(lexer.KIND_LPAREN) {
 		return nil
 	}
 
 	p.nextToken()
 
 	// Parse danh sách tham số
-	stmt.Parameters = []*ast.Identifier{}
+	params := []*ast.Identifier{}
 
-	for p.curTok.Type != lexer.TOKEN_RPAREN && p.curTok.Type != lexer.TOKEN_EOF {
-		if !p.expectCurrent(lexer.TOKEN_IDENTIFIER) {
+	for p.curTok.Kind != lexer.KIND_RPAREN && p.curTok.Kind != lexer.KIND_EOF {
+		if !p.expectCurrent(lexer.KIND_IDENTIFIER) {
 			return nil
 		}
-		param := &ast.Identifier{Value: p.curTok.Value, Line: p.curTok.Line}
-		stmt.Parameters = append(stmt.Parameters, param)
+		params = append(params, p.parseIdentifier())
 
-		p.nextToken()
-
-		if p.curTok.Type == lexer.TOKEN_COMMA {
+		if p.curTok.Kind == lexer.KIND_COMMA {
 			p.nextToken()
 		}
 	}
-	if !p.expectCurrent(lexer.TOKEN_RPAREN) {
+	if !p.expectCurrent(lexer.KIND_RPAREN) {
 		return nil
 	}
 	p.nextToken()
 
-	if !p.expectCurrent(lexer.TOKEN_LCURLY) {
+	return params
+}
+
+// parseRecordStatement parses record Name(field, ...)
+func (p *Parser) parseRecordStatement() ast.Statement {
+	stmt := &ast.RecordStatement{Line: p.curTok.Line}
+	p.nextToken() // Bỏ qua "record"
+
+	if !p.expectCurrent(lexer.KIND_IDENTIFIER) {
 		return nil
 	}
-	stmt.Body = p.parseBlockStatement()
+	stmt.Name = p.parseIdentifier()
 
-	if !p.expectCurrent(lexer.TOKEN_RCURLY) {
+	stmt.Fields = p.parseParameterList()
+	if stmt.Fields == nil {
 		return nil
 	}
-
-	p.nextToken()
-
 	return stmt
-
 }
 
 func (p *Parser) parseBreakStatement() *ast.BreakStatement {
-	p.nextToken() // Bỏ qua "stop"
-	return &ast.BreakStatement{Line: p.curTok.Line}
+	stmt := &ast.BreakStatement{Line: p.curTok.Line}
+	p.nextToken() // Bỏ qua "break"
+	stmt.Label = p.parseLoopLabel(stmt.Line)
+	return stmt
 }
 
 func (p *Parser) parseContinueStatement() *ast.ContinueStatement {
+	stmt := &ast.ContinueStatement{Line: p.curTok.Line}
 	p.nextToken() // Bỏ qua "continue"
-	return &ast.ContinueStatement{Line: p.curTok.Line}
+	stmt.Label = p.parseLoopLabel(stmt.Line)
+	return stmt
+}
+
+// parseLoopLabel reads the optional label after break/continue (phải nằm cùng dòng)
+func (p *Parser) parseLoopLabel(line int) string {
+	if p.curTok.Kind != lexer.KIND_IDENTIFIER || p.curTok.Line != line {
+		return ""
+	}
+	label := p.curTok.Value
+	p.nextToken()
+	return label
+}
+
+// parseLabeledStatement parses `label: for/while/until ...`
+func (p *Parser) parseLabeledStatement() ast.Statement {
+	label := p.curTok.Value
+	line, col := p.curTok.Line, p.curTok.Col
+
+	p.nextToken() // Bỏ qua tên nhãn
+	p.nextToken() // Bỏ qua ':'
+
+	switch p.curTok.Kind {
+	case lexer.KIND_FOR:
+		return p.parseFor(label)
+	case lexer.KIND_WHILE:
+		if stmt := p.parseWhileStatement(); stmt != nil {
+			stmt.Label = label
+			return stmt
+		}
+	case lexer.KIND_UNTIL:
+		if stmt := p.parseUntilStatement(); stmt != nil {
+			stmt.Label = label
+			return stmt
+		}
+	default:
+		p.addError(customError.LabelWithoutLoop, line, col, label)
+	}
+	return nil
 }
 
-func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
+func (p *Parser) parseReturnStatement() ast.Statement {
 	stmt := &ast.ReturnStatement{Line: p.curTok.Line}
 	p.nextToken() // Bỏ qua "return"
 
-	// Nếu có giá trị return thì parse nó
-	if p.curTok.Type != lexer.TOKEN_SEMICOLON && p.curTok.Type != lexer.TOKEN_RCURLY {
-		stmt.Value = p.parseExpression(0)
+	// Nếu có giá trị return (cùng dòng với return) thì parse nó
+	if p.curTok.Line == stmt.Line && p.curTok.Kind != lexer.KIND_SEMICOLON &&
+		p.curTok.Kind != lexer.KIND_RCURLY && p.curTok.Kind != lexer.KIND_EOF {
+		if stmt.Value = p.parseExpression(0); stmt.Value == nil {
+			return nil
+		}
 	}
 
 	return stmt
 }
 
-func (p *Parser) parseIncDecStatement() *ast.ExpressionStatement {
-	expr := p.parseExpression(0)
+func (p *Parser) parseDeferStatement() ast.Statement {
+	stmt := &ast.DeferStatement{Line: p.curTok.Line}
+	line, col := p.curTok.Line, p.curTok.Col
+	p.nextToken() // Bỏ qua "defer"
+
+	stmt.Call = p.parseExpression(0)
+	if stmt.Call == nil {
+		return nil
+	}
+
+	// Chỉ hoãn được lời gọi hàm hoặc method
+	switch call := stmt.Call.(type) {
+	case *ast.FunctionCallExpression:
+	case *ast.MethodCallExpression:
+		if call.Optional {
+			p.addError(customError.OptionalDefer, line, col)
+		}
+	default:
+		// Vẫn trả về statement (lỗi đã được ghi) để không sinh thêm lỗi dây chuyền
+		p.addError(customError.DeferNotCall, line, col)
+	}
+	return stmt
+}
+
+// parseEnumStatement parses enum Name { Variant, Variant(field, ...), ... }
+func (p *Parser) parseEnumStatement() ast.Statement {
+	stmt := &ast.EnumStatement{Line: p.curTok.Line}
+	p.nextToken() // Bỏ qua "enum"
+
+	if !p.expectCurrent(lexer.KIND_IDENTIFIER) {
+		return nil
+	}
+	stmt.Name = p.parseIdentifier()
+
+	if !p.expectCurrent(lexer.KIND_LCURLY) {
+		return nil
+	}
 	p.nextToken()
-	return &ast.ExpressionStatement{Expression: expr, Line: p.curTok.Line}
+
+	for p.curTok.Kind != lexer.KIND_RCURLY && p.curTok.Kind != lexer.KIND_EOF {
+		if !p.expectCurrent(lexer.KIND_IDENTIFIER) {
+			return nil
+		}
+		variantStart := p.curTok
+		variant := &ast.EnumVariant{Name: p.parseIdentifier()}
+
+		// Variant có giá trị đi kèm: Ok(value)
+		if p.curTok.Kind == lexer.KIND_LPAREN {
+			variant.Fields = p.parseParameterList()
+			if variant.Fields == nil {
+				return nil
+			}
+		}
+		variant.Span = p.spanFrom(variantStart)
+		stmt.Variants = append(stmt.Variants, variant)
+
+		if p.curTok.Kind == lexer.KIND_COMMA {
+			p.nextToken()
+		}
+	}
+
+	if !p.expectCurrent(lexer.KIND_RCURLY) {
+		return nil
+	}
+	p.nextToken()
+
+	if len(stmt.Variants) == 0 {
+		p.addError(customError.EmptyEnum, stmt.Line, 0, stmt.Name.Value)
+		return nil
+	}
+	return stmt
+}
+
+// parseMatchStatement parses match subject { pattern, ... { body } ... else { body } }
+func (p *Parser) parseMatchStatement() ast.Statement {
+	stmt := &ast.MatchStatement{Line: p.curTok.Line}
+	start := p.curTok
+	p.nextToken() // Bỏ qua "match"
+
+	stmt.Subject = p.parseCondition()
+	if stmt.Subject == nil {
+		p.addError(customError.InvalidMatchSubject, p.curTok.Line, p.curTok.Col)
+		return nil
+	}
+
+	if !p.expectCurrent(lexer.KIND_LCURLY) {
+		return nil
+	}
+	p.nextToken()
+
+	for p.curTok.Kind != lexer.KIND_RCURLY && p.curTok.Kind != lexer.KIND_EOF {
+		if p.curTok.Kind == lexer.KIND_ELSE {
+			if stmt.ElseBlock != nil {
+				p.addError(customError.DuplicateElseArm, p.curTok.Line, p.curTok.Col)
+				return nil
+			}
+			stmt.ElseBlock = p.parseElseStatement()
+			if stmt.ElseBlock == nil {
+				return nil
+			}
+			continue
+		}
+		if stmt.ElseBlock != nil {
+			p.addError(customError.ElseArmNotLast, p.curTok.Line, p.curTok.Col)
+			return nil
+		}
+
+		arm := &ast.MatchArm{Line: p.curTok.Line}
+		armStart := p.curTok
+		for {
+			pattern := p.parseCondition()
+			if pattern == nil {
+				p.addError(customError.InvalidMatchPattern, p.curTok.Line, p.curTok.Col)
+				return nil
+			}
+			arm.Patterns = append(arm.Patterns, pattern)
+			if p.curTok.Kind != lexer.KIND_COMMA {
+				break
+			}
+			p.nextToken()
+		}
+
+		if !p.expectCurrent(lexer.KIND_LCURLY) {
+			return nil
+		}
+		arm.Body = p.parseBlockStatement()
+		if !p.expectCurrent(lexer.KIND_RCURLY) {
+			return nil
+		}
+		p.nextToken()
+		arm.Span = p.spanFrom(armStart)
+		stmt.Arms = append(stmt.Arms, arm)
+	}
+
+	if !p.expectCurrent(lexer.KIND_RCURLY) {
+		return nil
+	}
+	p.nextToken()
+	p.setSpan(stmt, start)
+	return stmt
 }
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"pun/compiler"
+	"pun/error"
 	"pun/lexer"
 	"pun/parser"
 	"strings"
@@ -31,20 +32,21 @@ func StartCompiler() {
 		}
 
 		l := lexer.NewLexer(input)
+		r := customError.NewRenderer("", input, os.Stdout)
 		p := parser.NewParser(l)
 		c := compiler.NewCompiler()
 
 		program := p.ParseProgram()
 
 		if p.HasErrors() {
-			p.PrintErrors()
+			p.PrintErrors(r)
 			continue
 		}
 
 		c.CompileProgram(program)
 
 		if c.HasErrors() {
-			c.PrintErrors()
+			c.PrintErrors(r)
 			continue
 		}
 
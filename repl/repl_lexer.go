@@ -36,24 +36,31 @@ func StartLexer() {
 
 // lexFile reads a .pun file and prints tokens
 func lexFile(filename string) {
-	data, err := os.ReadFile(filename)
+	file, err := os.Open(filename)
 	if err != nil {
 		fmt.Printf("Error reading file: %v\n", err)
 		return
 	}
+	defer file.Close()
 
-	lexInput(string(data))
+	printTokens(lexer.NewReaderLexer(filename, file))
 }
 
 // lexInput tokenizes input and prints tokens
 func lexInput(input string) {
-	l := lexer.NewLexer(input)
+	printTokens(lexer.NewLexer(input))
+}
 
+func printTokens(l *lexer.Lexer) {
 	for {
 		tok := l.NextToken()
-		fmt.Printf("{Type:%s, Value:%q, Line:%d, Col:%d}\n", tok.Type, tok.Value, tok.Line, tok.Col)
-		if tok.Type == lexer.TOKEN_EOF {
+		fmt.Printf("{Type:%s, Value:%q, Line:%d, Col:%d}\n", tok.Type(), tok.Value, tok.Line, tok.Col)
+		if tok.Kind == lexer.KIND_EOF {
 			break
 		}
 	}
+
+	for _, err := range l.TakeErrors() {
+		fmt.Println(err.Error())
+	}
 }
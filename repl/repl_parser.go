@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"pun/ast"
+	"pun/error"
 	"pun/lexer"
 	"pun/parser"
 	"strings"
@@ -30,11 +31,12 @@ func StartParser() {
 		}
 
 		l := lexer.NewLexer(input)
+		r := customError.NewRenderer("", input, os.Stdout)
 		p := parser.NewParser(l)
 		program := p.ParseProgram()
 
 		if p.HasErrors() {
-			p.PrintErrors()
+			p.PrintErrors(r)
 			continue // 🚨 Đừng exit, cho nhập lại!
 		}
 
@@ -84,21 +86,35 @@ func astToString(node ast.Node) string {
 		return s
 
 	case *ast.ForStatement:
-		return fmt.Sprintf("FOR (%s; %s; %s) %s",
+		s := fmt.Sprintf("%sFOR (%s; %s; %s) %s",
+			loopLabel(n.Label),
 			astToString(n.Init),
 			astToString(n.Condition),
 			astToString(n.Update),
 			astToString(n.Body))
+		return s + loopElse(n.ElseBlock)
+
+	case *ast.ForInStatement:
+		s := fmt.Sprintf("%sFOR (%s IN %s) %s",
+			loopLabel(n.Label),
+			astToString(n.Variable),
+			astToString(n.Iterable),
+			astToString(n.Body))
+		return s + loopElse(n.ElseBlock)
 
 	case *ast.WhileStatement:
-		return fmt.Sprintf("WHILE (%s) %s",
+		s := fmt.Sprintf("%sWHILE (%s) %s",
+			loopLabel(n.Label),
 			astToString(n.Condition),
 			astToString(n.Body))
+		return s + loopElse(n.ElseBlock)
 
 	case *ast.UntilStatement:
-		return fmt.Sprintf("UNTIL (%s) %s",
+		s := fmt.Sprintf("%sUNTIL (%s) %s",
+			loopLabel(n.Label),
 			astToString(n.Condition),
 			astToString(n.Body))
+		return s + loopElse(n.ElseBlock)
 
 	case *ast.ExpressionStatement:
 		return astToString(n.Expression)
@@ -113,6 +129,38 @@ func astToString(node ast.Node) string {
 			strings.Join(params, ", "),
 			astToString(n.Body))
 
+	case *ast.RecordStatement:
+		fields := []string{}
+		for _, f := range n.Fields {
+			fields = append(fields, astToString(f))
+		}
+		return fmt.Sprintf("RECORD %s(%s)", astToString(n.Name), strings.Join(fields, ", "))
+
+	case *ast.EnumStatement:
+		variants := []string{}
+		for _, v := range n.Variants {
+			if len(v.Fields) == 0 {
+				variants = append(variants, astToString(v.Name))
+				continue
+			}
+			fields := []string{}
+			for _, f := range v.Fields {
+				fields = append(fields, astToString(f))
+			}
+			variants = append(variants, fmt.Sprintf("%s(%s)", astToString(v.Name), strings.Join(fields, ", ")))
+		}
+		return fmt.Sprintf("ENUM %s { %s }", astToString(n.Name), strings.Join(variants, ", "))
+
+	case *ast.MatchStatement:
+		arms := []string{}
+		for _, arm := range n.Arms {
+			arms = append(arms, fmt.Sprintf("CASE %s %s", joinExpressions(arm.Patterns), astToString(arm.Body)))
+		}
+		if n.ElseBlock != nil {
+			arms = append(arms, "ELSE "+astToString(n.ElseBlock.Body))
+		}
+		return fmt.Sprintf("MATCH(%s) { %s }", astToString(n.Subject), strings.Join(arms, " "))
+
 	case *ast.MethodDefinitionStatement:
 		params := []string{}
 		for _, p := range n.Parameters {
@@ -124,12 +172,24 @@ func astToString(node ast.Node) string {
 			strings.Join(params, ", "),
 			astToString(n.Body))
 
+	case *ast.BadStatement, *ast.BadExpression:
+		return "<bad>"
+
 	case *ast.BreakStatement:
+		if n.Label != "" {
+			return "BREAK " + n.Label
+		}
 		return "BREAK"
 
 	case *ast.ContinueStatement:
+		if n.Label != "" {
+			return "CONTINUE " + n.Label
+		}
 		return "CONTINUE"
 
+	case *ast.DeferStatement:
+		return fmt.Sprintf("DEFER %s", astToString(n.Call))
+
 	case *ast.ReturnStatement:
 		if n.Value != nil {
 			return fmt.Sprintf("RETURN %s", astToString(n.Value))
@@ -143,12 +203,37 @@ func astToString(node ast.Node) string {
 	case *ast.NumberExpression:
 		return fmt.Sprintf("NUM(%v)", n.Value)
 
+	case *ast.BigIntExpression:
+		return fmt.Sprintf("BIGINT(%s)", n.Value)
+
+	case *ast.TupleExpression:
+		return fmt.Sprintf("TUPLE(%s)", joinExpressions(n.Elements))
+
+	case *ast.SetExpression:
+		return fmt.Sprintf("SET{%s}", joinExpressions(n.Elements))
+
+	case *ast.DecimalExpression:
+		return fmt.Sprintf("DECIMAL(%s)", n.Value)
+
 	case *ast.StringExpression:
 		return fmt.Sprintf("STR(%q)", n.Value)
 
 	case *ast.BooleanExpression:
 		return fmt.Sprintf("BOOL(%v)", n.Value)
 
+	case *ast.TemplateExpression:
+		parts := []string{}
+		for _, part := range n.Parts {
+			parts = append(parts, astToString(part))
+		}
+		return fmt.Sprintf("TEMPLATE(%s)", strings.Join(parts, " + "))
+
+	case *ast.InterpolationExpression:
+		if n.Format != "" {
+			return fmt.Sprintf("${%s:%s}", astToString(n.Value), n.Format)
+		}
+		return fmt.Sprintf("${%s}", astToString(n.Value))
+
 	case *ast.NothingExpression:
 		return "NOTHING"
 
@@ -174,13 +259,17 @@ func astToString(node ast.Node) string {
 			n.Operator)
 
 	case *ast.ArrayExpression:
-		elements := []string{}
-		for _, el := range n.Elements {
-			elements = append(elements, astToString(el))
+		if n.Frozen {
+			return fmt.Sprintf("FROZEN_ARRAY[%s]", joinExpressions(n.Elements))
 		}
-		return fmt.Sprintf("ARRAY[%s]", strings.Join(elements, ", "))
+		return fmt.Sprintf("ARRAY[%s]", joinExpressions(n.Elements))
 
 	case *ast.ArrayIndexExpression:
+		if n.Optional {
+			return fmt.Sprintf("INDEX(%s?.[%s])",
+				astToString(n.Array),
+				astToString(n.Index))
+		}
 		return fmt.Sprintf("INDEX(%s[%s])",
 			astToString(n.Array),
 			astToString(n.Index))
@@ -199,12 +288,64 @@ func astToString(node ast.Node) string {
 		for _, arg := range n.Arguments {
 			args = append(args, astToString(arg))
 		}
-		return fmt.Sprintf("METHOD_CALL %s.%s(%s)",
+		dot := "."
+		if n.Optional {
+			dot = "?."
+		}
+		return fmt.Sprintf("METHOD_CALL %s%s%s(%s)",
 			astToString(n.Caller),
+			dot,
 			n.Method,
 			strings.Join(args, ", "))
 
+	case *ast.PropertyExpression:
+		dot := "."
+		if n.Optional {
+			dot = "?."
+		}
+		return fmt.Sprintf("PROPERTY(%s%s%s)", astToString(n.Object), dot, n.Property)
+
+	case *ast.IfExpression:
+		return "(" + astToString(n.Statement) + ")"
+
+	case *ast.MatchExpression:
+		return "(" + astToString(n.Statement) + ")"
+
+	case *ast.BlockExpression:
+		return "(" + astToString(n.Block) + ")"
+
+	case *ast.AssignExpression:
+		return fmt.Sprintf("(%s := %s)", astToString(n.Name), astToString(n.Value))
+
+	case *ast.ConditionalExpression:
+		return fmt.Sprintf("COND(%s ? %s : %s)",
+			astToString(n.Condition),
+			astToString(n.Consequence),
+			astToString(n.Alternative))
+
 	default:
 		return fmt.Sprintf("UNKNOWN_NODE(%T)", n)
 	}
 }
+
+func loopLabel(label string) string {
+	if label == "" {
+		return ""
+	}
+	return label + ": "
+}
+
+func loopElse(elseBlock *ast.ElseStatement) string {
+	if elseBlock == nil {
+		return ""
+	}
+	return fmt.Sprintf(" ELSE %s", astToString(elseBlock.Body))
+}
+
+func joinExpressions(exprs []ast.Expression) string {
+	elements := []string{}
+	for _, el := range exprs {
+		elements = append(elements, astToString(el))
+	}
+	return strings.Join(elements, ", ")
+}
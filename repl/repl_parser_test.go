@@ -0,0 +1,64 @@
+package repl
+
+import (
+	"pun/lexer"
+	"pun/parser"
+	"testing"
+)
+
+// dump parses input and returns the AST dump of its first statement
+func dump(t *testing.T, input string) string {
+	t.Helper()
+	p := parser.NewParser(lexer.NewLexer(input))
+	program := p.ParseProgram()
+	if p.HasErrors() {
+		t.Fatalf("parse %q: %v", input, p.Diagnostics())
+	}
+	return astToString(program.Statements[0])
+}
+
+func TestPrecedence(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1 + 2 * 3", "BINARY_OP(NUM(1) + BINARY_OP(NUM(2) * NUM(3)))"},
+		{"1 - 2 - 3", "BINARY_OP(BINARY_OP(NUM(1) - NUM(2)) - NUM(3))"},
+		{"2 ** 3 ** 2", "BINARY_OP(NUM(2) ** BINARY_OP(NUM(3) ** NUM(2)))"},
+		{"-x ** 2", "UNARY_OP(-BINARY_OP(ID(x) ** NUM(2)))"},
+		{"-x * 2", "BINARY_OP(UNARY_OP(-ID(x)) * NUM(2))"},
+		{"a || b && !c", "BINARY_OP(ID(a) || BINARY_OP(ID(b) && UNARY_OP(!ID(c))))"},
+		{"a & b == c", "BINARY_OP(BINARY_OP(ID(a) & ID(b)) == ID(c))"},
+		{"a & b | c ^ d << 1", "BINARY_OP(BINARY_OP(ID(a) & ID(b)) | BINARY_OP(ID(c) ^ BINARY_OP(ID(d) << NUM(1))))"},
+		{"a ?? b ?? c", "BINARY_OP(BINARY_OP(ID(a) ?? ID(b)) ?? ID(c))"},
+		{"a ?? b || c", "BINARY_OP(ID(a) ?? BINARY_OP(ID(b) || ID(c)))"},
+		{"c ? a : d ? e : f", "COND(ID(c) ? ID(a) : COND(ID(d) ? ID(e) : ID(f)))"},
+		{"x > 0 ? a + 1 : b", "COND(BINARY_OP(ID(x) > NUM(0)) ? BINARY_OP(ID(a) + NUM(1)) : ID(b))"},
+		{"(1 + 2) * 3", "BINARY_OP(BINARY_OP(NUM(1) + NUM(2)) * NUM(3))"},
+	}
+	for _, tt := range tests {
+		if got := dump(t, tt.input); got != tt.expected {
+			t.Errorf("%s:\ngot  %s\nwant %s", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestPostfixChains(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"matrix[0][1]", "INDEX(INDEX(ID(matrix)[NUM(0)])[NUM(1)])"},
+		{"getFn()(x)", "CALL CALL ID(getFn)()(ID(x))"},
+		{"(f)(x)", "CALL ID(f)(ID(x))"},
+		{"[1, 2][0]", "INDEX(ARRAY[NUM(1), NUM(2)][NUM(0)])"},
+		{"a.b.c()", "METHOD_CALL PROPERTY(ID(a).b).c()"},
+		{"f(x)[1].y?.z(2)", "METHOD_CALL PROPERTY(INDEX(CALL ID(f)(ID(x))[NUM(1)]).y)?.z(NUM(2))"},
+		{"-a[0]", "UNARY_OP(-INDEX(ID(a)[NUM(0)]))"},
+	}
+	for _, tt := range tests {
+		if got := dump(t, tt.input); got != tt.expected {
+			t.Errorf("%s:\ngot  %s\nwant %s", tt.input, got, tt.expected)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"pun/compiler"
+	"pun/error"
 	"pun/lexer"
 	"pun/parser"
 	"pun/vm"
@@ -32,28 +33,30 @@ func StartVM() {
 		}
 
 		l := lexer.NewLexer(input)
+		r := customError.NewRenderer("", input, os.Stdout)
 		p := parser.NewParser(l)
 		c := compiler.NewCompiler()
 
 		program := p.ParseProgram()
 
 		if p.HasErrors() {
-			p.PrintErrors()
+			p.PrintErrors(r)
 			continue
 		}
 
 		c.CompileProgram(program)
 
 		if c.HasErrors() {
-			c.PrintErrors()
+			c.PrintErrors(r)
 			continue
 		}
 
 		machine := vm.NewVM(c.Constants, c.Code, len(c.GlobalSymbols))
+		machine.Lines = c.Lines
 		machine.Run()
 
 		if machine.HasErrors() {
-			machine.PrintErrors()
+			machine.PrintErrors(r)
 			continue
 		}
 
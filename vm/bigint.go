@@ -0,0 +1,290 @@
+package vm
+
+import (
+	"math"
+	"math/big"
+	"pun/decimal"
+	"pun/error"
+)
+
+// 2^53: từ đây trở lên float64 không còn biểu diễn được mọi số nguyên.
+// Kết quả nguyên đạt tới giá trị này được tính lại bằng bigint.
+const maxSafeInteger = 1 << 53
+
+// Số bit dịch tối đa cho << (tránh cấp phát bộ nhớ khổng lồ)
+const maxShift = 1 << 20
+
+func isBigInt(val interface{}) bool {
+	_, ok := val.(*big.Int)
+	return ok
+}
+
+// isInt64Value reports whether f is a whole number that fits in 64 bits
+func isInt64Value(f float64) bool {
+	return f == math.Trunc(f) && math.Abs(f) < 1<<63
+}
+
+// toBigInt converts a bigint or a whole float64 to *big.Int
+func toBigInt(val interface{}) (*big.Int, bool) {
+	switch v := val.(type) {
+	case *big.Int:
+		return v, true
+	case float64:
+		if v != math.Trunc(v) || math.IsInf(v, 0) {
+			return nil, false
+		}
+		result, _ := big.NewFloat(v).Int(nil)
+		return result, true
+	}
+	return nil, false
+}
+
+// toFloat converts a number (float64 or bigint) to float64, losing precision for big values
+func toFloat(val interface{}) (float64, bool) {
+	switch v := val.(type) {
+	case float64:
+		return v, true
+	case *big.Int:
+		f, _ := new(big.Float).SetInt(v).Float64()
+		return f, true
+	}
+	return 0, false
+}
+
+// normalizeBigInt turns an automatically promoted result back into a float64 when it is exact
+func normalizeBigInt(b *big.Int) interface{} {
+	if b.IsInt64() {
+		if n := b.Int64(); n <= maxSafeInteger && n >= -maxSafeInteger {
+			return float64(n)
+		}
+	}
+	return b
+}
+
+// needsBigInt reports whether a float64 result of two 64-bit integers lost precision
+func needsBigInt(op string, left, right, result float64) bool {
+	if !isInt64Value(left) || !isInt64Value(right) {
+		return false
+	}
+	switch op {
+	case "+", "-", "*":
+		// Kết quả đúng nhỏ hơn 2^53 thì float64 tính chính xác; kết quả làm tròn thành 2^53 có thể là 2^53 + 1
+		return math.Abs(result) >= maxSafeInteger
+	case "**":
+		return right >= 0 && math.Abs(result) >= maxSafeInteger
+	case "%":
+		return math.Abs(left) >= maxSafeInteger || math.Abs(right) >= maxSafeInteger
+	}
+	return false
+}
+
+func (v *VM) floatArithmetic(op string, leftVal, rightVal float64) (float64, bool) {
+	switch op {
+	case "+":
+		return leftVal + rightVal, true
+	case "-":
+		return leftVal - rightVal, true
+	case "*":
+		return leftVal * rightVal, true
+	case "/":
+		if rightVal == 0 {
+			v.addError(customError.DivisionByZero, "arithmetic operation")
+			return 0, false
+		}
+		return leftVal / rightVal, true
+	case "%":
+		if rightVal == 0 {
+			v.addError(customError.DivisionByZero, "arithmetic operation")
+			return 0, false
+		}
+		return math.Mod(leftVal, rightVal), true // Cùng dấu với số bị chia
+	case "**":
+		return math.Pow(leftVal, rightVal), true
+	}
+	v.addError(customError.UnsupportedOperator, "arithmetic operation", op)
+	return 0, false
+}
+
+// bigArithmetic computes op exactly with math/big. If one operand is not a whole number
+// the operation falls back to float64. Division stays exact: a quotient that is not whole
+// becomes a decimal rounded like decimal division (10n / 4 = 2.5d, 10n / 3n = 3.3333333333333333d).
+func (v *VM) bigArithmetic(op string, left, right interface{}) (interface{}, bool) {
+	l, lok := toBigInt(left)
+	r, rok := toBigInt(right)
+	if !lok || !rok {
+		leftVal, ok1 := toFloat(left)
+		rightVal, ok2 := toFloat(right)
+		if !ok1 || !ok2 {
+			v.addError(customError.NonNumericOperands, "arithmetic operation", typeName(left), typeName(right))
+			return nil, false
+		}
+		return v.floatArithmetic(op, leftVal, rightVal)
+	}
+
+	result := new(big.Int)
+	switch op {
+	case "+":
+		result.Add(l, r)
+	case "-":
+		result.Sub(l, r)
+	case "*":
+		result.Mul(l, r)
+	case "/":
+		if r.Sign() == 0 {
+			v.addError(customError.DivisionByZero, "arithmetic operation")
+			return nil, false
+		}
+		// Chia hết thì giữ bigint, không thì chia decimal: số thực sẽ làm mất các chữ số của bigint
+		remainder := new(big.Int)
+		result.QuoRem(l, r, remainder)
+		if remainder.Sign() != 0 {
+			quotient, err := decimal.Quo(decimal.FromInt(l), decimal.FromInt(r), v.Rounding)
+			if err != nil {
+				v.addErrorFrom(err, "arithmetic operation")
+				return nil, false
+			}
+			return quotient, true
+		}
+	case "%":
+		if r.Sign() == 0 {
+			v.addError(customError.DivisionByZero, "arithmetic operation")
+			return nil, false
+		}
+		result.Rem(l, r) // Cùng dấu với số bị chia, giống % của số thường
+	case "**":
+		if r.Sign() < 0 {
+			leftVal, _ := toFloat(l)
+			rightVal, _ := toFloat(r)
+			return math.Pow(leftVal, rightVal), true
+		}
+		if r.BitLen() > 32 {
+			v.addError(customError.ExponentTooLarge, "arithmetic operation")
+			return nil, false
+		}
+		result.Exp(l, r, nil)
+	default:
+		v.addError(customError.UnsupportedOperator, "arithmetic operation", op)
+		return nil, false
+	}
+	return result, true
+}
+
+// compareNumbers compares two numbers exactly, returning -1, 0 or +1
+func compareNumbers(left, right interface{}) int {
+	toBigFloat := func(val interface{}) *big.Float {
+		if b, ok := val.(*big.Int); ok {
+			return new(big.Float).SetInt(b)
+		}
+		return big.NewFloat(val.(float64))
+	}
+	return toBigFloat(left).Cmp(toBigFloat(right))
+}
+
+func (v *VM) compareBigInt(op string, left, right interface{}) {
+	_, ok1 := toFloat(left)
+	_, ok2 := toFloat(right)
+	if !ok1 || !ok2 {
+		v.addError(customError.CannotCompare, "comparison operation", typeName(left), typeName(right))
+		return
+	}
+
+	// NaN không bằng và không so sánh được với bất kỳ số nào
+	if f, ok := left.(float64); ok && math.IsNaN(f) {
+		v.push(op == "!=")
+		return
+	}
+	if f, ok := right.(float64); ok && math.IsNaN(f) {
+		v.push(op == "!=")
+		return
+	}
+
+	cmp := compareNumbers(left, right)
+	switch op {
+	case "==":
+		v.push(cmp == 0)
+	case "!=":
+		v.push(cmp != 0)
+	case "<":
+		v.push(cmp < 0)
+	case ">":
+		v.push(cmp > 0)
+	case "<=":
+		v.push(cmp <= 0)
+	case ">=":
+		v.push(cmp >= 0)
+	default:
+		v.addError(customError.UnsupportedComparison, "comparison operation", op)
+	}
+}
+
+func (v *VM) executeBitwise(op string) {
+	if v.Sp < 1 {
+		v.addError(customError.StackUnderflow, "bitwise operation")
+		return
+	}
+
+	right := v.pop()
+	left := v.pop()
+
+	// | & ^ giữa hai set là hợp, giao, hiệu đối xứng
+	if (isCollection(left) || isCollection(right)) && v.executeCollectionOperator(op, left, right) {
+		return
+	}
+
+	l, lok := toBigInt(left)
+	r, rok := toBigInt(right)
+	if !lok || !rok {
+		v.addError(customError.BitwiseIntegers, "bitwise operation", typeName(left), typeName(right))
+		return
+	}
+
+	result := new(big.Int)
+	switch op {
+	case "&":
+		result.And(l, r)
+	case "|":
+		result.Or(l, r)
+	case "^":
+		result.Xor(l, r)
+	case "<<", ">>":
+		if r.Sign() < 0 || !r.IsInt64() || r.Int64() > maxShift {
+			v.addError(customError.InvalidShift, "bitwise operation", r)
+			return
+		}
+		if op == "<<" {
+			result.Lsh(l, uint(r.Int64()))
+		} else {
+			result.Rsh(l, uint(r.Int64()))
+		}
+	default:
+		v.addError(customError.UnsupportedBitwise, "bitwise operation", op)
+		return
+	}
+
+	if isBigInt(left) || isBigInt(right) {
+		v.push(result)
+	} else {
+		v.push(normalizeBigInt(result))
+	}
+}
+
+func (v *VM) executeBitNot() {
+	if v.Sp < 0 {
+		v.addError(customError.StackUnderflow, "bitwise operation")
+		return
+	}
+
+	val := v.pop()
+	n, ok := toBigInt(val)
+	if !ok {
+		v.addError(customError.BitwiseInteger, "bitwise operation", typeName(val))
+		return
+	}
+
+	result := new(big.Int).Not(n)
+	if isBigInt(val) {
+		v.push(result)
+	} else {
+		v.push(normalizeBigInt(result))
+	}
+}
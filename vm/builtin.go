@@ -3,19 +3,28 @@ package vm
 import (
 	"bufio"
 	"fmt"
+	"math/big"
 	"os"
+	"pun/decimal"
+	"pun/error"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 type BuiltinFunction func(args ...interface{}) interface{}
 
 func (v *VM) builtinPrint(args ...interface{}) interface{} {
-	for _, arg := range args {
-		if arg == nil {
-			fmt.Print("nothing", " ")
-		} else {
-			fmt.Print(arg, " ")
+	strs := make([]string, len(args))
+	for i, arg := range args {
+		str, ok := v.display(arg)
+		if !ok {
+			return nil
 		}
-
+		strs[i] = str
+	}
+	for _, str := range strs {
+		fmt.Print(str, " ")
 	}
 	fmt.Println()
 	return nil
@@ -29,6 +38,94 @@ func (v *VM) builtinAsk(args ...interface{}) interface{} {
 	return scanner.Text()
 }
 
+// bigint(x) converts a whole number or a string ("123", "0xFF") to a bigint
+func (v *VM) builtinBigInt(args ...interface{}) interface{} {
+	if len(args) != 1 {
+		v.addError(customError.BuiltinArgumentCount, "bigint", "bigint", len(args))
+		return nil
+	}
+
+	switch arg := args[0].(type) {
+	case string:
+		text := strings.ReplaceAll(strings.TrimSpace(arg), "_", "")
+		base := 0 // Hiểu được 0x, 0o, 0b
+		if len(text) > 1 && text[0] == '0' && text[1] >= '0' && text[1] <= '9' {
+			base = 10 // "010" là 10, không phải số bát phân
+		}
+		if n, ok := new(big.Int).SetString(text, base); ok {
+			return n
+		}
+		v.addError(customError.ConvertString, "bigint", arg, "bigint")
+		return nil
+	default:
+		if n, ok := toBigInt(arg); ok {
+			return new(big.Int).Set(n)
+		}
+		v.addError(customError.ConvertValue, "bigint", typeName(arg), stringify(arg), "bigint")
+		return nil
+	}
+}
+
+// number(x) converts a bigint, decimal or string to a regular number (may lose precision)
+func (v *VM) builtinNumber(args ...interface{}) interface{} {
+	if len(args) != 1 {
+		v.addError(customError.BuiltinArgumentCount, "number", "number", len(args))
+		return nil
+	}
+
+	if arg, ok := args[0].(string); ok {
+		n, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(arg), "_", ""), 64)
+		if err != nil {
+			v.addError(customError.ConvertString, "number", arg, "number")
+			return nil
+		}
+		return n
+	}
+	if d, ok := args[0].(*decimal.Decimal); ok {
+		return d.Float64()
+	}
+	if n, ok := toFloat(args[0]); ok {
+		return n
+	}
+	v.addError(customError.ConvertType, "number", typeName(args[0]), "number")
+	return nil
+}
+
+// sort(collection) returns a new array with the elements in ascending order, compared with <
+// (records are ordered by their __lt__ method, strings alphabetically). Equal elements keep their order.
+func (v *VM) builtinSort(args ...interface{}) interface{} {
+	if len(args) != 1 {
+		v.addError(customError.BuiltinArgumentCount, "sort", "sort", len(args))
+		return nil
+	}
+	elements, ok := collectionElements(args[0])
+	if !ok {
+		v.addError(customError.SortType, "sort", typeName(args[0]))
+		return nil
+	}
+
+	sorted := append([]interface{}{}, elements...)
+	failed := false
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if failed {
+			return false
+		}
+		// Toán tử < không so sánh chuỗi, nhưng sort thì xếp chuỗi theo thứ tự từ điển
+		if a, ok := sorted[i].(string); ok {
+			if b, ok := sorted[j].(string); ok {
+				return a < b
+			}
+		}
+		less, ok := v.lessThan(sorted[i], sorted[j])
+		failed = !ok
+		return less
+	})
+	if failed {
+		return nil
+	}
+	return newArray(sorted)
+}
+
 func (v *VM) builtinLen(arg interface{}) int {
 	return 1
 }
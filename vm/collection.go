@@ -0,0 +1,454 @@
+package vm
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"pun/bytecode"
+	"pun/decimal"
+	"pun/error"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Array is a mutable sequence of values shared by reference: [1, "a"].
+// A frozen array (#[...] or freeze(a)) can no longer be modified.
+type Array struct {
+	Elements []interface{}
+	frozen   bool
+	shared   bool // Elements dùng chung với một bản copy() khác: phải sao chép trước khi ghi
+}
+
+func newArray(elements []interface{}) *Array {
+	return &Array{Elements: elements}
+}
+
+// set assigns an element, copying the shared storage first (copy-on-write)
+func (a *Array) set(index int, val interface{}) error {
+	if a.frozen {
+		return customError.Errorf(customError.FrozenArray)
+	}
+	if a.shared {
+		a.Elements = append([]interface{}{}, a.Elements...)
+		a.shared = false
+	}
+	a.Elements[index] = val
+	return nil
+}
+
+func (a *Array) String() string {
+	parts := make([]string, len(a.Elements))
+	for i, elem := range a.Elements {
+		parts[i] = stringify(elem)
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
+
+// Tuple is an immutable sequence of values: (1, "a")
+type Tuple struct {
+	Elements []interface{}
+}
+
+func (t *Tuple) String() string {
+	parts := make([]string, len(t.Elements))
+	for i, elem := range t.Elements {
+		parts[i] = elementString(elem)
+	}
+	if len(parts) == 1 {
+		return "(" + parts[0] + ",)"
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+// Set is an immutable set of hashable values, kept in insertion order: #{1, 2}
+type Set struct {
+	keys  []string
+	items map[string]interface{}
+}
+
+func newSet() *Set {
+	return &Set{items: make(map[string]interface{})}
+}
+
+// add inserts val unless an equal value is already in the set
+func (s *Set) add(val interface{}) error {
+	key, ok := hashKey(val)
+	if !ok {
+		return unhashable(val)
+	}
+	if _, exists := s.items[key]; !exists {
+		s.keys = append(s.keys, key)
+		s.items[key] = val
+	}
+	return nil
+}
+
+func (s *Set) Contains(val interface{}) bool {
+	key, ok := lookupKey(val)
+	if !ok {
+		return false
+	}
+	_, exists := s.items[key]
+	return exists
+}
+
+func (s *Set) Len() int {
+	return len(s.keys)
+}
+
+// Values returns the elements in insertion order
+func (s *Set) Values() []interface{} {
+	values := make([]interface{}, len(s.keys))
+	for i, key := range s.keys {
+		values[i] = s.items[key]
+	}
+	return values
+}
+
+func (s *Set) String() string {
+	parts := make([]string, len(s.keys))
+	for i, key := range s.keys {
+		parts[i] = elementString(s.items[key])
+	}
+	return "#{" + strings.Join(parts, ", ") + "}"
+}
+
+// hashKey returns a string that is the same for two values exactly when valuesEqual reports them equal.
+// Mutable values (arrays that are not frozen) are not hashable.
+func hashKey(val interface{}) (string, bool) {
+	return valueKey(val, false)
+}
+
+// lookupKey is hashKey for finding a value in a set: the value is not stored, so an array
+// that is not frozen is looked up like the frozen array equal to it.
+func lookupKey(val interface{}) (string, bool) {
+	return valueKey(val, true)
+}
+
+func valueKey(val interface{}, lookup bool) (string, bool) {
+	switch v := val.(type) {
+	case nil:
+		return "nothing", true
+	case bool:
+		return "b:" + strconv.FormatBool(v), true
+	case string:
+		return "s:" + strconv.Quote(v), true
+	case float64:
+		// 1, 1n và 1.0d bằng nhau nên có cùng khóa
+		if n, ok := toBigInt(v); ok {
+			return "i:" + n.String(), true
+		}
+		if math.IsNaN(v) {
+			return "", false // NaN không bằng chính nó
+		}
+		return "f:" + strconv.FormatFloat(v, 'g', -1, 64), true
+	case *big.Int:
+		return "i:" + v.String(), true
+	case *decimal.Decimal:
+		if v.IsInteger() {
+			return "i:" + v.Int().String(), true
+		}
+		return "d:" + v.Reduce().String(), true
+	case *Array:
+		// Chỉ array đã đóng băng mới hash được (giá trị không còn thay đổi)
+		if !v.frozen && !lookup {
+			return "", false
+		}
+		keys := make([]string, len(v.Elements))
+		for i, elem := range v.Elements {
+			key, ok := valueKey(elem, lookup)
+			if !ok {
+				return "", false
+			}
+			keys[i] = key
+		}
+		return "a[" + strings.Join(keys, ",") + "]", true
+	case *Tuple:
+		keys := make([]string, len(v.Elements))
+		for i, elem := range v.Elements {
+			key, ok := valueKey(elem, lookup)
+			if !ok {
+				return "", false
+			}
+			keys[i] = key
+		}
+		return "t(" + strings.Join(keys, ",") + ")", true
+	case *Set:
+		// Không phụ thuộc thứ tự thêm phần tử
+		keys := append([]string(nil), v.keys...)
+		sort.Strings(keys)
+		return "set{" + strings.Join(keys, ",") + "}", true
+	case *Record:
+		// Record tự định nghĩa __eq__ thì không biết hash thế nào cho khớp với ==
+		if _, ok := v.Type.Methods["__eq__"]; ok {
+			return "", false
+		}
+		keys := make([]string, len(v.Fields))
+		for i, field := range v.Fields {
+			key, ok := valueKey(field, lookup)
+			if !ok {
+				return "", false
+			}
+			keys[i] = key
+		}
+		return fmt.Sprintf("r:%p(%s)", v.Type, strings.Join(keys, ",")), true
+	case *bytecode.EnumVariant:
+		return fmt.Sprintf("e:%p", v), true
+	case *EnumValue:
+		keys := make([]string, len(v.Values))
+		for i, val := range v.Values {
+			key, ok := valueKey(val, lookup)
+			if !ok {
+				return "", false
+			}
+			keys[i] = key
+		}
+		return fmt.Sprintf("e:%p(%s)", v.Variant, strings.Join(keys, ",")), true
+	case *bytecode.Function:
+		return fmt.Sprintf("fn:%p", v), true
+	}
+	return "", false
+}
+
+// unhashable explains why hashKey refused val, naming the innermost element that cannot be hashed
+func unhashable(val interface{}) error {
+	var elements []interface{}
+	switch v := val.(type) {
+	case *Record:
+		if _, ok := v.Type.Methods["__eq__"]; ok {
+			return customError.Errorf(customError.CustomEqualityUnhashable, v.Type.Name)
+		}
+		elements = v.Fields
+	case *Tuple:
+		elements = v.Elements
+	case *Array:
+		if v.frozen {
+			elements = v.Elements
+		}
+	case *EnumValue:
+		elements = v.Values
+	}
+	for _, elem := range elements {
+		if _, ok := hashKey(elem); !ok {
+			return unhashable(elem)
+		}
+	}
+	return customError.Errorf(customError.Unhashable, typeName(val))
+}
+
+// collectionElements returns the elements of an array, tuple or set
+func collectionElements(val interface{}) ([]interface{}, bool) {
+	switch v := val.(type) {
+	case *Array:
+		return v.Elements, true
+	case *Tuple:
+		return v.Elements, true
+	case *Set:
+		return v.Values(), true
+	}
+	return nil, false
+}
+
+// Iterator is the position of a for-in loop in the elements of a collection. The elements are
+// copied when the loop starts: changing the collection inside the loop does not change the loop.
+type Iterator struct {
+	elements []interface{}
+	next     int
+}
+
+func (v *VM) executeIter() {
+	val := v.pop()
+	elements, ok := collectionElements(val)
+	if !ok {
+		v.addError(customError.NotIterable, "for-in", typeName(val))
+		return
+	}
+	v.push(&Iterator{elements: append([]interface{}(nil), elements...)})
+}
+
+func (v *VM) executeIterNext(exit int) {
+	iter := v.pop().(*Iterator)
+	if iter.next == len(iter.elements) {
+		v.Ip = exit
+		return
+	}
+	v.push(iter.elements[iter.next])
+	iter.next++
+}
+
+func (v *VM) executeMakeTuple(size int) {
+	if v.Sp+1 < size {
+		v.addError(customError.StackUnderflow, "make tuple")
+		return
+	}
+	v.push(&Tuple{Elements: v.popArgs(size)})
+}
+
+func (v *VM) executeMakeSet(size int) {
+	if v.Sp+1 < size {
+		v.addError(customError.StackUnderflow, "make set")
+		return
+	}
+	set := newSet()
+	for _, elem := range v.popArgs(size) {
+		if err := set.add(elem); err != nil {
+			v.addErrorFrom(err, "make set")
+			return
+		}
+	}
+	v.push(set)
+}
+
+// set() or set(collection) builds a set from an array, tuple, set or the characters of a string
+func (v *VM) builtinSet(args ...interface{}) interface{} {
+	set := newSet()
+	if len(args) == 0 {
+		return set
+	}
+	if len(args) != 1 {
+		v.addError(customError.SetArgumentCount, "set", len(args))
+		return nil
+	}
+
+	elements, ok := collectionElements(args[0])
+	if str, isString := args[0].(string); isString {
+		for _, ch := range str {
+			elements = append(elements, string(ch))
+		}
+		ok = true
+	}
+	if !ok {
+		v.addError(customError.SetFrom, "set", typeName(args[0]))
+		return nil
+	}
+
+	for _, elem := range elements {
+		if err := set.add(elem); err != nil {
+			v.addErrorFrom(err, "set")
+			return nil
+		}
+	}
+	return set
+}
+
+// setOperation computes | (union), & (intersection), - (difference) and ^ (symmetric difference)
+func setOperation(op string, left, right *Set) (*Set, error) {
+	result := newSet()
+	switch op {
+	case "|":
+		for _, elem := range left.Values() {
+			result.add(elem)
+		}
+		for _, elem := range right.Values() {
+			result.add(elem)
+		}
+	case "&":
+		for _, elem := range left.Values() {
+			if right.Contains(elem) {
+				result.add(elem)
+			}
+		}
+	case "-":
+		for _, elem := range left.Values() {
+			if !right.Contains(elem) {
+				result.add(elem)
+			}
+		}
+	case "^":
+		for _, elem := range left.Values() {
+			if !right.Contains(elem) {
+				result.add(elem)
+			}
+		}
+		for _, elem := range right.Values() {
+			if !left.Contains(elem) {
+				result.add(elem)
+			}
+		}
+	default:
+		return nil, customError.Errorf(customError.UnsupportedSetOperator, op)
+	}
+	return result, nil
+}
+
+// executeCollectionOperator handles operators between sets and tuples.
+// It returns false if the operands are not collections, leaving them for the number operators.
+func (v *VM) executeCollectionOperator(op string, left, right interface{}) bool {
+	leftSet, ok1 := left.(*Set)
+	rightSet, ok2 := right.(*Set)
+	if ok1 || ok2 {
+		if !ok1 || !ok2 {
+			v.addError(customError.SetOperands, "set operation", op, typeName(left), typeName(right))
+			return true
+		}
+		result, err := setOperation(op, leftSet, rightSet)
+		if err != nil {
+			v.addErrorFrom(err, "set operation")
+			return true
+		}
+		v.push(result)
+		return true
+	}
+
+	leftTuple, ok1 := left.(*Tuple)
+	rightTuple, ok2 := right.(*Tuple)
+	if ok1 || ok2 {
+		if op != "+" || !ok1 || !ok2 {
+			v.addError(customError.TupleOperator, "tuple operation", op, typeName(left), typeName(right))
+			return true
+		}
+		elements := append(append([]interface{}{}, leftTuple.Elements...), rightTuple.Elements...)
+		v.push(&Tuple{Elements: elements})
+		return true
+	}
+	return false
+}
+
+// compareCollections handles == and != by value, and subset tests (<, <=, >, >=) between sets
+func (v *VM) compareCollections(op string, left, right interface{}) {
+	switch op {
+	case "==", "!=":
+		if same, ok := v.equal(left, right); ok {
+			v.push(same == (op == "=="))
+		}
+		return
+	}
+
+	leftSet, ok1 := left.(*Set)
+	rightSet, ok2 := right.(*Set)
+	if !ok1 || !ok2 {
+		v.addError(customError.EqualityOnly, "comparison operation", typeName(left), typeName(right))
+		return
+	}
+
+	switch op {
+	case "<=":
+		v.push(isSubset(leftSet, rightSet))
+	case "<":
+		v.push(leftSet.Len() < rightSet.Len() && isSubset(leftSet, rightSet))
+	case ">=":
+		v.push(isSubset(rightSet, leftSet))
+	case ">":
+		v.push(rightSet.Len() < leftSet.Len() && isSubset(rightSet, leftSet))
+	default:
+		v.addError(customError.UnsupportedComparison, "comparison operation", op)
+	}
+}
+
+func isSubset(a, b *Set) bool {
+	for _, elem := range a.Values() {
+		if !b.Contains(elem) {
+			return false
+		}
+	}
+	return true
+}
+
+func isCollection(val interface{}) bool {
+	switch val.(type) {
+	case *Tuple, *Set:
+		return true
+	}
+	return false
+}
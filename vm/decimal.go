@@ -0,0 +1,205 @@
+package vm
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"pun/decimal"
+	"pun/error"
+)
+
+func isDecimal(val interface{}) bool {
+	_, ok := val.(*decimal.Decimal)
+	return ok
+}
+
+// toDecimal converts a value to a decimal without losing precision.
+// Only decimals, bigints and whole numbers convert; 0.1 (float) does not, it needs decimal(x).
+func toDecimal(val interface{}) (*decimal.Decimal, bool) {
+	switch v := val.(type) {
+	case *decimal.Decimal:
+		return v, true
+	case *big.Int:
+		return decimal.FromInt(v), true
+	case float64:
+		if n, ok := toBigInt(v); ok {
+			return decimal.FromInt(n), true
+		}
+	}
+	return nil, false
+}
+
+// decimalOperands converts both operands of a decimal operation, reporting why it is not possible
+func (v *VM) decimalOperands(left, right interface{}, context string) (*decimal.Decimal, *decimal.Decimal, bool) {
+	l, lok := toDecimal(left)
+	r, rok := toDecimal(right)
+	if lok && rok {
+		return l, r, true
+	}
+
+	other := left
+	if lok {
+		other = right
+	}
+	if _, isFloat := other.(float64); isFloat {
+		v.addError(customError.InexactDecimal, context, stringify(other))
+	} else {
+		v.addError(customError.NonNumericOperands, context, typeName(left), typeName(right))
+	}
+	return nil, nil, false
+}
+
+func (v *VM) decimalArithmetic(op string, left, right interface{}) {
+	l, r, ok := v.decimalOperands(left, right, "arithmetic operation")
+	if !ok {
+		return
+	}
+
+	var result *decimal.Decimal
+	var err error
+	switch op {
+	case "+":
+		result = decimal.Add(l, r)
+	case "-":
+		result = decimal.Sub(l, r)
+	case "*":
+		result = decimal.Mul(l, r)
+	case "/":
+		result, err = decimal.Quo(l, r, v.Rounding)
+	case "%":
+		result, err = decimal.Rem(l, r)
+	case "**":
+		if !r.IsInteger() || !r.Int().IsInt64() {
+			v.addError(customError.DecimalExponent, "arithmetic operation", r)
+			return
+		}
+		result, err = decimal.Pow(l, r.Int().Int64(), v.Rounding)
+	default:
+		v.addError(customError.UnsupportedOperator, "arithmetic operation", op)
+		return
+	}
+
+	if err != nil {
+		v.addErrorFrom(err, "arithmetic operation")
+		return
+	}
+	v.push(result)
+}
+
+func (v *VM) compareDecimal(op string, left, right interface{}) {
+	l, r, ok := v.decimalOperands(left, right, "comparison operation")
+	if !ok {
+		return
+	}
+
+	cmp := decimal.Cmp(l, r)
+	switch op {
+	case "==":
+		v.push(cmp == 0)
+	case "!=":
+		v.push(cmp != 0)
+	case "<":
+		v.push(cmp < 0)
+	case ">":
+		v.push(cmp > 0)
+	case "<=":
+		v.push(cmp <= 0)
+	case ">=":
+		v.push(cmp >= 0)
+	default:
+		v.addError(customError.UnsupportedComparison, "comparison operation", op)
+	}
+}
+
+// decimal(x) converts a number, bigint or string to a decimal.
+// Floats use their shortest representation: decimal(0.1) is 0.1d, not 0.1000000000000000055...
+func (v *VM) builtinDecimal(args ...interface{}) interface{} {
+	if len(args) != 1 {
+		v.addError(customError.BuiltinArgumentCount, "decimal", "decimal", len(args))
+		return nil
+	}
+
+	switch arg := args[0].(type) {
+	case *decimal.Decimal:
+		return arg
+	case *big.Int:
+		return decimal.FromInt(arg)
+	case float64:
+		d, err := decimal.FromFloat(arg)
+		if err != nil {
+			v.addError(customError.ConvertValue, "decimal", typeName(arg), stringify(arg), "decimal")
+			return nil
+		}
+		return d
+	case string:
+		d, err := decimal.Parse(arg)
+		if errors.Is(err, decimal.ErrExponentTooLarge) {
+			v.addErrorFrom(err, "decimal")
+			return nil
+		}
+		if err != nil {
+			v.addError(customError.ConvertString, "decimal", arg, "decimal")
+			return nil
+		}
+		return d
+	}
+	v.addError(customError.ConvertType, "decimal", typeName(args[0]), "decimal")
+	return nil
+}
+
+// round(x, places) or round(x, places, "half_up") rounds a decimal or a number to `places` digits
+func (v *VM) builtinRound(args ...interface{}) interface{} {
+	if len(args) != 2 && len(args) != 3 {
+		v.addError(customError.RoundArgumentCount, "round", len(args))
+		return nil
+	}
+
+	places, ok := args[1].(float64)
+	if !ok || places != math.Trunc(places) || places < 0 || places > 1000 {
+		v.addError(customError.RoundPlaces, "round", stringify(args[1]))
+		return nil
+	}
+
+	mode := v.Rounding
+	if len(args) == 3 {
+		name, _ := args[2].(string)
+		if mode, ok = decimal.ParseRoundingMode(name); !ok {
+			v.addError(customError.UnknownRounding, "round", stringify(args[2]))
+			return nil
+		}
+	}
+
+	switch x := args[0].(type) {
+	case *decimal.Decimal:
+		return x.Round(int(places), mode)
+	case float64:
+		// Làm tròn theo chữ số thập phân mà người dùng thấy, không theo biểu diễn nhị phân
+		d, err := decimal.FromFloat(x)
+		if err != nil {
+			return x // NaN, Inf giữ nguyên
+		}
+		return d.Round(int(places), mode).Float64()
+	case *big.Int:
+		return x
+	}
+	v.addError(customError.RoundType, "round", typeName(args[0]))
+	return nil
+}
+
+// rounding("half_up") sets the rounding mode of decimal division and round(),
+// returning the previous mode. rounding() only returns the current mode.
+func (v *VM) builtinRounding(args ...interface{}) interface{} {
+	previous := v.Rounding.String()
+	if len(args) == 0 {
+		return previous
+	}
+
+	name, _ := args[0].(string)
+	mode, ok := decimal.ParseRoundingMode(name)
+	if len(args) != 1 || !ok {
+		v.addError(customError.UnknownRounding, "rounding", stringify(args[0]))
+		return nil
+	}
+	v.Rounding = mode
+	return previous
+}
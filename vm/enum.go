@@ -0,0 +1,86 @@
+package vm
+
+import (
+	"pun/bytecode"
+	"pun/error"
+	"strings"
+)
+
+// EnumValue is a variant with associated values: Result.Ok(5).
+// Variants without values are represented by their *bytecode.EnumVariant and compare by identity.
+// Two enum values are equal when they are the same variant (by identity, never by name) and their
+// values are equal, so Result.Ok(1) == Result.Ok(1) just as (1,) == (1,).
+type EnumValue struct {
+	Variant *bytecode.EnumVariant
+	Values  []interface{}
+}
+
+func (e *EnumValue) String() string {
+	parts := make([]string, len(e.Values))
+	for i, val := range e.Values {
+		parts[i] = stringify(val)
+	}
+	return e.Variant.Name + "(" + strings.Join(parts, ", ") + ")"
+}
+
+// field returns the associated value called name
+func (e *EnumValue) field(name string) (interface{}, bool) {
+	for i, fieldName := range e.Variant.Fields {
+		if fieldName == name {
+			return e.Values[i], true
+		}
+	}
+	return nil, false
+}
+
+// enumVariant returns the variant of an enum value
+func enumVariant(val interface{}) (*bytecode.EnumVariant, bool) {
+	switch v := val.(type) {
+	case *bytecode.EnumVariant:
+		return v, true
+	case *EnumValue:
+		return v.Variant, true
+	}
+	return nil, false
+}
+
+// getVariant handles Color.Red: variants without values are values themselves
+func (v *VM) getVariant(enum *bytecode.EnumType, name string) {
+	variant, ok := enum.Variant(name)
+	if !ok {
+		v.addError(customError.UnknownVariant, "get property", enum.Name, name)
+		return
+	}
+	if len(variant.Fields) > 0 {
+		v.addError(customError.VariantNeedsValues, "get property", enum.Name, name, strings.Join(variant.Fields, ", "))
+		return
+	}
+	v.push(variant)
+}
+
+// newEnumValue handles Result.Ok(5)
+func (v *VM) newEnumValue(enum *bytecode.EnumType, name string, args []interface{}) (interface{}, bool) {
+	variant, ok := enum.Variant(name)
+	if !ok {
+		v.addError(customError.UnknownVariant, "call method", enum.Name, name)
+		return nil, false
+	}
+	if len(variant.Fields) == 0 {
+		v.addError(customError.VariantHasNoValues, "call method", enum.Name, name)
+		return nil, false
+	}
+	if len(args) != len(variant.Fields) {
+		v.addError(customError.VariantArity, "call method", enum.Name, name, len(variant.Fields), len(args))
+		return nil, false
+	}
+	return &EnumValue{Variant: variant, Values: args}, true
+}
+
+func (v *VM) executeIsVariant(constIndex int) {
+	if v.Sp < 0 {
+		v.addError(customError.StackUnderflow, "match")
+		return
+	}
+	variant, _ := enumVariant(v.pop())
+	v.push(variant == v.Constants[constIndex])
+}
@@ -1,52 +1,65 @@
 package vm
 
 import (
-	"fmt"
-	"math"
+	"math/big"
 	"pun/bytecode"
+	"pun/decimal"
+	"pun/error"
+	"strings"
 )
 
 func (v *VM) executeArithmetic(op string) {
 	if v.Sp < 1 {
-		v.addError("stack underflow", 0, 0, "arithmetic operation")
+		v.addError(customError.StackUnderflow, "arithmetic operation")
 		return
 	}
 
 	right := v.pop()
 	left := v.pop()
 
+	if isRecord(left) || isRecord(right) {
+		v.recordArithmetic(op, left, right)
+		return
+	}
+
+	if (isCollection(left) || isCollection(right)) && v.executeCollectionOperator(op, left, right) {
+		return
+	}
+
+	if isDecimal(left) || isDecimal(right) {
+		v.decimalArithmetic(op, left, right)
+		return
+	}
+
+	if isBigInt(left) || isBigInt(right) {
+		if result, ok := v.bigArithmetic(op, left, right); ok {
+			v.push(result)
+		}
+		return
+	}
+
 	leftVal, ok1 := left.(float64)
 	rightVal, ok2 := right.(float64)
 
 	if !ok1 || !ok2 {
-		v.addError(fmt.Sprintf("operations only supported between numbers, got %T and %T", left, right), 0, 0, "arithmetic operation")
+		v.addError(customError.NonNumericOperands, "arithmetic operation", typeName(left), typeName(right))
 		return
 	}
 
-	var result float64
-	switch op {
-	case "+":
-		result = leftVal + rightVal
-	case "-":
-		result = leftVal - rightVal
-	case "*":
-		result = leftVal * rightVal
-	case "/":
-		if rightVal == 0 {
-			v.addError("division by zero", 0, 0, "arithmetic operation")
-			return
-		}
-		result = leftVal / rightVal
-	case "%":
-		if rightVal == 0 {
-			v.addError("division by zero", 0, 0, "arithmetic operation")
-			return
+	result, ok := v.floatArithmetic(op, leftVal, rightVal)
+	if !ok {
+		return
+	}
+
+	// Số nguyên tràn khỏi khoảng float64 chính xác: tự động chuyển sang bigint
+	if needsBigInt(op, leftVal, rightVal, result) {
+		if exact, ok := v.bigArithmetic(op, left, right); ok {
+			if b, isBig := exact.(*big.Int); isBig {
+				v.push(normalizeBigInt(b))
+			} else {
+				v.push(exact)
+			}
 		}
-		result = float64(int64(leftVal) % int64(rightVal))
-	case "**":
-		result = math.Pow(leftVal, rightVal)
-	default:
-		v.addError(fmt.Sprintf("unsupported operator: %s", op), 0, 0, "arithmetic operation")
 		return
 	}
 
@@ -55,18 +68,38 @@ func (v *VM) executeArithmetic(op string) {
 
 func (v *VM) executeComparison(op string) {
 	if v.Sp < 1 {
-		v.addError("stack underflow", 0, 0, "comparison operation")
+		v.addError(customError.StackUnderflow, "comparison operation")
 		return
 	}
 
 	right := v.pop()
 	left := v.pop()
 
+	if isRecord(left) || isRecord(right) {
+		v.recordComparison(op, left, right)
+		return
+	}
+
+	if isCollection(left) || isCollection(right) {
+		v.compareCollections(op, left, right)
+		return
+	}
+
+	if isDecimal(left) || isDecimal(right) {
+		v.compareDecimal(op, left, right)
+		return
+	}
+
+	if isBigInt(left) || isBigInt(right) {
+		v.compareBigInt(op, left, right)
+		return
+	}
+
 	switch leftVal := left.(type) {
 	case float64:
 		rightVal, ok := right.(float64)
 		if !ok {
-			v.addError(fmt.Sprintf("cannot compare float with %T", right), 0, 0, "comparison operation")
+			v.addError(customError.CannotCompare, "comparison operation", typeName(left), typeName(right))
 			return
 		}
 		var result bool
@@ -84,7 +117,7 @@ func (v *VM) executeComparison(op string) {
 		case ">=":
 			result = leftVal >= rightVal
 		default:
-			v.addError(fmt.Sprintf("unsupported comparison operator: %s", op), 0, 0, "comparison operation")
+			v.addError(customError.UnsupportedComparison, "comparison operation", op)
 			return
 		}
 		v.push(result)
@@ -92,7 +125,7 @@ func (v *VM) executeComparison(op string) {
 	case string:
 		rightVal, ok := right.(string)
 		if !ok {
-			v.addError(fmt.Sprintf("cannot compare string with %T", right), 0, 0, "comparison operation")
+			v.addError(customError.CannotCompare, "comparison operation", typeName(left), typeName(right))
 			return
 		}
 		switch op {
@@ -101,18 +134,26 @@ func (v *VM) executeComparison(op string) {
 		case "!=":
 			v.push(leftVal != rightVal)
 		default:
-			v.addError("string only supports == and != operators", 0, 0, "comparison operation")
+			v.addError(customError.StringEquality, "comparison operation")
 			return
 		}
 
 	default:
-		v.addError(fmt.Sprintf("unsupported type for comparison: %T", left), 0, 0, "comparison operation")
+		// Các kiểu còn lại (boolean, nothing, array...) chỉ so sánh bằng
+		switch op {
+		case "==", "!=":
+			if same, ok := v.equal(left, right); ok {
+				v.push(same == (op == "=="))
+			}
+		default:
+			v.addError(customError.UnsupportedComparisonType, "comparison operation", typeName(left))
+		}
 	}
 }
 
 func (v *VM) executeLogical(op string) {
 	if v.Sp < 1 {
-		v.addError("stack underflow", 0, 0, "logical operation")
+		v.addError(customError.StackUnderflow, "logical operation")
 		return
 	}
 
@@ -123,7 +164,7 @@ func (v *VM) executeLogical(op string) {
 	rightBool, ok2 := right.(bool)
 
 	if !ok1 || !ok2 {
-		v.addError("logical operators require boolean operands", 0, 0, "logical operation")
+		v.addError(customError.LogicalOperands, "logical operation")
 		return
 	}
 
@@ -134,7 +175,7 @@ func (v *VM) executeLogical(op string) {
 	case "||":
 		result = leftBool || rightBool
 	default:
-		v.addError(fmt.Sprintf("unsupported logical operator: %s", op), 0, 0, "logical operation")
+		v.addError(customError.UnsupportedLogical, "logical operation", op)
 		return
 	}
 
@@ -143,21 +184,27 @@ func (v *VM) executeLogical(op string) {
 
 func (v *VM) executeNegate() {
 	if v.Sp < 0 {
-		v.addError("stack underflow", 0, 0, "unary operation")
+		v.addError(customError.StackUnderflow, "unary operation")
 		return
 	}
 
 	val := v.pop()
-	if num, ok := val.(float64); ok {
+	if isRecord(val) {
+		v.recordNegate(val)
+	} else if num, ok := val.(float64); ok {
 		v.push(-num)
+	} else if num, ok := val.(*big.Int); ok {
+		v.push(new(big.Int).Neg(num))
+	} else if num, ok := val.(*decimal.Decimal); ok {
+		v.push(num.Neg())
 	} else {
-		v.addError(fmt.Sprintf("cannot negate non-number type: %T", val), 0, 0, "unary operation")
+		v.addError(customError.NegateNonNumber, "unary operation", typeName(val))
 	}
 }
 
 func (v *VM) executeNot() {
 	if v.Sp < 0 {
-		v.addError("stack underflow", 0, 0, "logical operation")
+		v.addError(customError.StackUnderflow, "logical operation")
 		return
 	}
 
@@ -165,25 +212,54 @@ func (v *VM) executeNot() {
 	if b, ok := val.(bool); ok {
 		v.push(!b)
 	} else {
-		v.addError(fmt.Sprintf("cannot logical NOT non-boolean type: %T", val), 0, 0, "logical operation")
+		v.addError(customError.NotNonBoolean, "logical operation", typeName(val))
+	}
+}
+
+// executeJumpIfFalse jumps to target when the condition on top of the stack is false
+func (v *VM) executeJumpIfFalse(target int) {
+	val := v.pop()
+	condition, ok := val.(bool)
+	if !ok {
+		v.addError(customError.ConditionNotBoolean, "condition check", typeName(val))
+		return
+	}
+	if !condition {
+		v.Ip = target
+	}
+}
+
+// scopeAt returns the scope `depth` levels up from the current one (1 = current scope)
+func (v *VM) scopeAt(depth int) *Scope {
+	index := len(v.ScopeStack) - depth
+	if depth < 1 || index < 1 { // Index 0 là global scope, không chứa local
+		v.addError(customError.ScopeDepthOutOfBounds, "runtime", depth)
+		return nil
 	}
+	return v.ScopeStack[index]
 }
 
 func (v *VM) executeLoadLocal(slot, depth int) {
-	scope := v.ScopeStack[depth]
+	scope := v.scopeAt(depth)
+	if scope == nil {
+		return
+	}
 
 	if slot >= len(scope.Locals) {
-		v.addError(fmt.Sprintf("local variable slot %d out of bounds", slot), 0, 0, "runtime")
+		v.addError(customError.LocalSlotOutOfBounds, "runtime", slot)
 		return
 	}
 	v.push(scope.Locals[slot])
 }
 
 func (v *VM) executeStoreLocal(slot, depth int) {
-	scope := v.ScopeStack[depth]
+	scope := v.scopeAt(depth)
+	if scope == nil {
+		return
+	}
 
 	if slot >= len(scope.Locals) {
-		v.addError(fmt.Sprintf("local variable slot %d out of bounds", slot), 0, 0, "runtime")
+		v.addError(customError.LocalSlotOutOfBounds, "runtime", slot)
 		return
 	}
 	scope.Locals[slot] = v.pop()
@@ -191,59 +267,42 @@ func (v *VM) executeStoreLocal(slot, depth int) {
 
 func (v *VM) executeCall(argCount int) {
 	fn := v.pop()
+	args := v.popArgs(argCount)
 
 	switch f := fn.(type) {
-	case string: // Built-in function
-		if builtin, ok := v.Builtins[f]; ok {
-			args := make([]interface{}, argCount)
-			for i := argCount - 1; i >= 0; i-- {
-				args[i] = v.pop()
-			}
-
-			if argCount != len(args) {
-				v.addError("wrong number of arguments for this function", 0, 0, f)
-				return
-			}
-
-			result := builtin(args...)
-			if result != nil {
-				v.push(result)
-			}
-		} else {
-			v.addError("undefined builtin function", 0, 0, f)
-		}
-
 	case *bytecode.Function: // User-defined function
-		// Validate argument count
-		if argCount != f.Arity {
-			v.addError(fmt.Sprintf("expected %d arguments, got %d", f.Arity, argCount), 0, 0, f.Name)
-			return
-		}
-
-		// Push a new scope for the function
-		v.pushScope(f.LocalSize)
-
-		// Set up local variables (parameters)
-		for i := f.Arity - 1; i >= 0; i-- {
-			v.CurrentScope.Locals[i] = v.pop()
+		v.callFunction(f, args, false)
+	default:
+		if result, ok := v.callBuiltin(fn, args); ok {
+			v.push(result) // Builtin không trả gì thì kết quả là nothing
 		}
+	}
+}
 
-		// Save the current instruction pointer for returning
-		v.push(v.Ip)
+// callBuiltin runs a built-in function, reporting false if fn is not one
+func (v *VM) callBuiltin(fn interface{}, args []interface{}) (interface{}, bool) {
+	if recordType, ok := fn.(*bytecode.RecordType); ok {
+		return v.newRecord(recordType, args)
+	}
 
-		// Jump to the function's start
-		v.Ip = f.StartPC
+	name, ok := fn.(string)
+	if !ok {
+		v.addError(customError.NotCallable, "execute call", fn, fn)
+		return nil, false
+	}
 
-	default:
-		// Add more context to the error message
-		v.addError(fmt.Sprintf("not callable: expected function, got %T (value: %v)", fn, fn), 0, 0, "execute call")
+	builtin, ok := v.Builtins[name]
+	if !ok {
+		v.addError(customError.UndefinedBuiltin, name)
+		return nil, false
 	}
+	return builtin(args...), true
 }
 
 func (v *VM) executeMakeArray(size int) {
-	//Nếu số lượng phần tử trong array != op của make array thì lỗi
-	if len(v.Stack) != size {
-		v.addError("wrong array size", 0, 0, "make array")
+	//Nếu stack không đủ phần tử cho array thì lỗi
+	if v.Sp+1 < size {
+		v.addError(customError.WrongArraySize, "make array")
 		return
 	}
 
@@ -253,31 +312,42 @@ func (v *VM) executeMakeArray(size int) {
 		arr[i] = v.pop()
 	}
 
-	v.push(arr)
+	v.push(newArray(arr))
 }
 
 func (v *VM) executeArrayGet() {
 	indexInterface := v.pop() // Giả sử index luôn là int (nếu không, cần check thêm)
 	arrInterface := v.pop()   // Lấy giá trị từ stack (kiểu interface{})
 
+	// Record tự xử lý index bằng __index__ (index có thể là bất kỳ giá trị nào)
+	if isRecord(arrInterface) {
+		v.recordIndex(arrInterface, indexInterface)
+		return
+	}
+
 	indexFloat, ok := indexInterface.(float64)
 	if !ok {
-		v.addError(fmt.Sprintf("expected index to be a number, got %T instead", indexInterface), 0, 0, "array get")
+		v.addError(customError.IndexNotNumber, "array get", typeName(indexInterface))
 		return
 	}
 
 	index := int(indexFloat)
 
-	// Check 1: arr có phải slice không?
-	arr, ok := arrInterface.([]interface{})
-	if !ok {
-		v.addError(fmt.Sprintf("expected array type, got %T", arrInterface), 0, 0, "array get")
+	// Check 1: arr có phải array hoặc tuple không?
+	var arr []interface{}
+	switch a := arrInterface.(type) {
+	case *Array:
+		arr = a.Elements
+	case *Tuple:
+		arr = a.Elements
+	default:
+		v.addError(customError.NotIndexable, "array get", typeName(arrInterface))
 		return
 	}
 
 	// Check 2: Index có hợp lệ không?
 	if index < 0 || index >= len(arr) {
-		v.addError(fmt.Sprintf("index %d out of bounds (array size: %d)", index, len(arr)), 0, 0, "array get")
+		v.addError(customError.IndexOutOfBounds, "array get", index, len(arr))
 		return
 	}
 
@@ -291,26 +361,33 @@ func (v *VM) executeArraySet() {
 	//Kiểm tra xem index có phải là float64 không (mặc định trong Pun kiểu number tương ứng với float64 trong Go)
 	indexFloat, ok := indexInterface.(float64)
 	if !ok {
-		v.addError(fmt.Sprintf("expected index to be a number, got %T instead", indexInterface), 0, 0, "array get")
+		v.addError(customError.IndexNotNumber, "array get", typeName(indexInterface))
 		return
 	}
 	//Sau đó chuyển thành int
 	index := int(indexFloat)
 
-	// Check 1: arr có phải slice không?
-	arr, ok := arrInterface.([]interface{})
+	// Check 1: arr có phải slice không? (tuple, set và record là bất biến)
+	if isCollection(arrInterface) || isRecord(arrInterface) {
+		v.addError(customError.ImmutableElement, "array set", typeName(arrInterface))
+		return
+	}
+	arr, ok := arrInterface.(*Array)
 	if !ok {
-		v.addError(fmt.Sprintf("expected array type, got %T", arrInterface), 0, 0, "array get")
+		v.addError(customError.NotIndexable, "array get", typeName(arrInterface))
 		return
 	}
 
 	// Check 2: Index có hợp lệ không?
-	if index < 0 || index >= len(arr) {
-		v.addError(fmt.Sprintf("index %d out of bounds (array size: %d)", index, len(arr)), 0, 0, "array get")
+	if index < 0 || index >= len(arr.Elements) {
+		v.addError(customError.IndexOutOfBounds, "array get", index, len(arr.Elements))
 		return
 	}
 
-	arr[index] = v.pop() //Lưu vào array
+	// Check 3: array có bị đóng băng không?
+	if err := arr.set(index, v.pop()); err != nil {
+		v.addErrorFrom(err, "array set")
+	}
 }
 
 func (v *VM) executeMakeFunction() {
@@ -320,7 +397,7 @@ func (v *VM) executeMakeFunction() {
 	// Ensure the popped value is of type *bytecode.Function
 	fn, ok := fnInterface.(*bytecode.Function)
 	if !ok {
-		v.addError(fmt.Sprintf("expected function object, got %T", fnInterface), 0, 0, "make function")
+		v.addError(customError.NotAFunctionObject, "make function", fnInterface)
 		return
 	}
 
@@ -328,20 +405,48 @@ func (v *VM) executeMakeFunction() {
 	v.push(fn)
 }
 
-func (v *VM) executeReturn() {
-	// 1. Pop the return value from the stack
-	returnValue := v.pop()
-
-	// 2. Restore the instruction pointer (IP) from the stack
-	if v.Sp < 0 {
-		v.addError("stack underflow: missing return address", 0, 0, "return")
+func (v *VM) executeBuildString(count int) {
+	if v.Sp < count-1 {
+		v.addError(customError.StackUnderflow, "build string")
 		return
 	}
-	v.Ip = v.pop().(int)
 
-	// 3. Exit the current scope
-	v.popScope()
+	// Chuyển từng phần thành chuỗi trước để biết tổng độ dài, sau đó nối với một lần cấp phát
+	parts := v.Stack[v.Sp-count+1 : v.Sp+1]
+	strs := make([]string, count)
+	total := 0
+	for i, part := range parts {
+		str, ok := v.display(part)
+		if !ok {
+			return
+		}
+		strs[i] = str
+		total += len(strs[i])
+	}
+
+	var sb strings.Builder
+	sb.Grow(total)
+	for _, str := range strs {
+		sb.WriteString(str)
+	}
+
+	for i := 0; i < count; i++ {
+		v.pop()
+	}
+	v.push(sb.String())
+}
+
+func (v *VM) executeFormat(specIndex int) {
+	spec, ok := v.Constants[specIndex].(string)
+	if !ok {
+		v.addError(customError.NotAFormatSpec, "format", v.Constants[specIndex])
+		return
+	}
 
-	// 4. Push the return value back onto the stack
-	v.push(returnValue)
+	result, err := formatValue(v.pop(), spec, v.Rounding)
+	if err != nil {
+		v.addErrorFrom(err, "format")
+		return
+	}
+	v.push(result)
 }
@@ -0,0 +1,319 @@
+package vm
+
+import (
+	"math"
+	"math/big"
+	"pun/decimal"
+	"pun/error"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// formatSpec is a parsed ${value:spec} format spec.
+// Syntax: [[fill]align][sign][0][width][,|_][.precision][type]
+type formatSpec struct {
+	fill      rune
+	align     rune // '<', '>', '^' hoặc 0 (mặc định)
+	sign      rune // '+', '-', ' ' hoặc 0
+	zeroPad   bool
+	width     int
+	grouping  rune // ',' hoặc '_' hoặc 0
+	precision int  // -1 nếu không có
+	verb      rune // f F e E g G d x X o b s % hoặc 0
+}
+
+func parseFormatSpec(spec string) (formatSpec, error) {
+	fs := formatSpec{fill: ' ', precision: -1}
+	runes := []rune(spec)
+	i := 0
+
+	isAlign := func(r rune) bool { return r == '<' || r == '>' || r == '^' }
+
+	// [[fill]align]
+	if len(runes) >= 2 && isAlign(runes[1]) {
+		fs.fill, fs.align = runes[0], runes[1]
+		i = 2
+	} else if len(runes) >= 1 && isAlign(runes[0]) {
+		fs.align = runes[0]
+		i = 1
+	}
+
+	// [sign]
+	if i < len(runes) && (runes[i] == '+' || runes[i] == '-' || runes[i] == ' ') {
+		fs.sign = runes[i]
+		i++
+	}
+
+	// [0]
+	if i < len(runes) && runes[i] == '0' {
+		fs.zeroPad = true
+		i++
+	}
+
+	// [width]
+	start := i
+	for i < len(runes) && runes[i] >= '0' && runes[i] <= '9' {
+		i++
+	}
+	if i > start {
+		fs.width, _ = strconv.Atoi(string(runes[start:i]))
+	}
+
+	// [,|_]
+	if i < len(runes) && (runes[i] == ',' || runes[i] == '_') {
+		fs.grouping = runes[i]
+		i++
+	}
+
+	// [.precision]
+	if i < len(runes) && runes[i] == '.' {
+		i++
+		start = i
+		for i < len(runes) && runes[i] >= '0' && runes[i] <= '9' {
+			i++
+		}
+		if i == start {
+			return fs, customError.Errorf(customError.FormatMissingPrecision, spec)
+		}
+		fs.precision, _ = strconv.Atoi(string(runes[start:i]))
+	}
+
+	// [type]
+	if i < len(runes) {
+		if !strings.ContainsRune("fFeEgGdxXobs%", runes[i]) {
+			return fs, customError.Errorf(customError.UnknownFormatType, spec, runes[i])
+		}
+		fs.verb = runes[i]
+		i++
+	}
+
+	if i != len(runes) {
+		return fs, customError.Errorf(customError.InvalidFormatSpec, spec)
+	}
+	return fs, nil
+}
+
+// formatValue formats a runtime value according to a format spec.
+// rounding is used when a decimal has more digits than the precision.
+func formatValue(val interface{}, spec string, rounding decimal.RoundingMode) (string, error) {
+	fs, err := parseFormatSpec(spec)
+	if err != nil {
+		return "", err
+	}
+
+	switch v := val.(type) {
+	case *big.Int:
+		return formatBigInt(v, spec, fs, rounding)
+	case *decimal.Decimal:
+		return formatDecimal(v, spec, fs, rounding)
+	}
+
+	num, isNumber := val.(float64)
+
+	var body string
+	switch fs.verb {
+	case 0, 's':
+		if !isNumber && (fs.sign != 0 || fs.grouping != 0) {
+			return "", customError.Errorf(customError.FormatNeedsNumber, spec, typeName(val))
+		}
+		if isNumber && fs.verb == 0 {
+			// Không có type: có precision thì như 'f', không thì giống print
+			if fs.precision >= 0 {
+				body = strconv.FormatFloat(math.Abs(num), 'f', fs.precision, 64)
+			} else {
+				body = stringify(math.Abs(num))
+			}
+			body = groupDigits(body, fs.grouping)
+			break
+		}
+		body = stringify(val)
+		if fs.precision >= 0 && utf8.RuneCountInString(body) > fs.precision {
+			body = string([]rune(body)[:fs.precision])
+		}
+	default:
+		if !isNumber {
+			return "", customError.Errorf(customError.FormatTypeNeedsNumber, fs.verb, typeName(val))
+		}
+		body, err = formatNumber(num, fs)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// Phần thân số luôn không dấu, dấu được thêm ở đây
+	if isNumber && fs.verb != 's' {
+		body = applySign(body, num, fs.sign)
+	}
+
+	return pad(body, fs, isNumber && fs.verb != 's'), nil
+}
+
+func formatNumber(num float64, fs formatSpec) (string, error) {
+	precision := fs.precision
+
+	switch fs.verb {
+	case 'f', 'F', 'e', 'E', 'g', 'G':
+		if precision < 0 {
+			precision = 6
+		}
+		body := strconv.FormatFloat(math.Abs(num), byte(fs.verb), precision, 64)
+		return groupDigits(body, fs.grouping), nil
+	case '%':
+		if precision < 0 {
+			precision = 6
+		}
+		body := strconv.FormatFloat(math.Abs(num)*100, 'f', precision, 64)
+		return groupDigits(body, fs.grouping) + "%", nil
+	case 'd', 'x', 'X', 'o', 'b':
+		if num != math.Trunc(num) || math.IsInf(num, 0) || math.IsNaN(num) {
+			return "", customError.Errorf(customError.FormatNeedsWhole, fs.verb, num)
+		}
+		n := int64(math.Abs(num))
+		switch fs.verb {
+		case 'd':
+			return groupDigits(strconv.FormatInt(n, 10), fs.grouping), nil
+		case 'x':
+			return strconv.FormatInt(n, 16), nil
+		case 'X':
+			return strings.ToUpper(strconv.FormatInt(n, 16)), nil
+		case 'o':
+			return strconv.FormatInt(n, 8), nil
+		default:
+			return strconv.FormatInt(n, 2), nil
+		}
+	}
+	return "", customError.Errorf(customError.UnsupportedFormatType, fs.verb)
+}
+
+// formatBigInt formats a bigint exactly for integer types; other number types go through float64
+func formatBigInt(b *big.Int, spec string, fs formatSpec, rounding decimal.RoundingMode) (string, error) {
+	abs := new(big.Int).Abs(b)
+
+	var body string
+	switch fs.verb {
+	case 0, 'd':
+		body = groupDigits(abs.String(), fs.grouping)
+	case 'x':
+		body = abs.Text(16)
+	case 'X':
+		body = strings.ToUpper(abs.Text(16))
+	case 'o':
+		body = abs.Text(8)
+	case 'b':
+		body = abs.Text(2)
+	case 's':
+		return formatValue(b.String(), spec, rounding)
+	default:
+		f, _ := toFloat(b)
+		return formatValue(f, spec, rounding)
+	}
+
+	return pad(applySign(body, float64(b.Sign()), fs.sign), fs, true), nil
+}
+
+// formatDecimal formats a decimal exactly. Without a precision the decimal keeps its own scale,
+// so ${price} and ${price:,} print 12.30 rather than 12.3
+func formatDecimal(d *decimal.Decimal, spec string, fs formatSpec, rounding decimal.RoundingMode) (string, error) {
+	suffix := ""
+	switch fs.verb {
+	case 0, 'f', 'F':
+	case '%':
+		d = decimal.Mul(d, decimal.FromInt(big.NewInt(100)))
+		suffix = "%"
+	case 'd':
+		if !d.IsInteger() {
+			return "", customError.Errorf(customError.FormatNeedsWhole, 'd', d)
+		}
+		d = d.Round(0, rounding)
+	case 's':
+		return formatValue(d.String(), spec, rounding)
+	default:
+		return formatValue(d.Float64(), spec, rounding)
+	}
+
+	if fs.precision >= 0 {
+		d = d.Round(fs.precision, rounding)
+	}
+
+	body := strings.TrimPrefix(d.String(), "-")
+	body = groupDigits(body, fs.grouping) + suffix
+	return pad(applySign(body, float64(d.Sign()), fs.sign), fs, true), nil
+}
+
+// applySign adds the sign prefix to an unsigned number body
+func applySign(body string, num float64, sign rune) string {
+	switch {
+	case num < 0:
+		return "-" + body
+	case sign == '+':
+		return "+" + body
+	case sign == ' ':
+		return " " + body
+	}
+	return body
+}
+
+// groupDigits inserts a separator every three digits of the integer part
+func groupDigits(body string, sep rune) string {
+	if sep == 0 {
+		return body
+	}
+	intPart, rest := body, ""
+	if idx := strings.IndexAny(body, ".eE"); idx >= 0 {
+		intPart, rest = body[:idx], body[idx:]
+	}
+	if len(intPart) <= 3 {
+		return body
+	}
+
+	var sb strings.Builder
+	first := len(intPart) % 3
+	if first > 0 {
+		sb.WriteString(intPart[:first])
+	}
+	for i := first; i < len(intPart); i += 3 {
+		if sb.Len() > 0 {
+			sb.WriteRune(sep)
+		}
+		sb.WriteString(intPart[i : i+3])
+	}
+	return sb.String() + rest
+}
+
+// pad applies width, fill and alignment. Numbers align right by default, text aligns left.
+func pad(body string, fs formatSpec, isNumber bool) string {
+	length := utf8.RuneCountInString(body)
+	if length >= fs.width {
+		return body
+	}
+	missing := fs.width - length
+
+	// Zero padding được chèn sau dấu
+	if fs.zeroPad && fs.align == 0 && isNumber {
+		sign := ""
+		if strings.HasPrefix(body, "-") || strings.HasPrefix(body, "+") || strings.HasPrefix(body, " ") {
+			sign, body = body[:1], body[1:]
+		}
+		return sign + strings.Repeat("0", missing) + body
+	}
+
+	align := fs.align
+	if align == 0 {
+		align = '<'
+		if isNumber {
+			align = '>'
+		}
+	}
+
+	fill := string(fs.fill)
+	switch align {
+	case '>':
+		return strings.Repeat(fill, missing) + body
+	case '^':
+		left := missing / 2
+		return strings.Repeat(fill, left) + body + strings.Repeat(fill, missing-left)
+	default:
+		return body + strings.Repeat(fill, missing)
+	}
+}
@@ -0,0 +1,229 @@
+package vm
+
+import (
+	"pun/bytecode"
+	"pun/error"
+)
+
+// Frame is the state of one running call of a user-defined function
+type Frame struct {
+	Function    *bytecode.Function
+	ReturnIp    int            // Lệnh tiếp theo của nơi gọi
+	ScopeDepth  int            // len(ScopeStack) trước khi gọi, để bỏ hết scope của hàm khi return
+	StackBase   int            // Sp trước khi gọi (sau khi đã lấy argument)
+	Defers      []deferredCall // Các lời gọi bị hoãn, chạy theo thứ tự LIFO khi hàm kết thúc
+	isDeferred  bool           // Frame này là một lời gọi bị hoãn: kết quả bị bỏ, xong thì chạy tiếp defer của frame cha
+	isSync      bool           // Frame được gọi từ Go (callSync): luôn trả kết quả về, kể cả khi đang unwind
+	returnValue interface{}
+}
+
+// deferredCall is a call registered by `defer`, with its arguments already evaluated
+type deferredCall struct {
+	fn     interface{} // *bytecode.Function, tên builtin, hoặc receiver nếu là method
+	method string      // Tên method (rỗng nếu là lời gọi hàm)
+	args   []interface{}
+}
+
+func (v *VM) currentFrame() *Frame {
+	if len(v.Frames) == 0 {
+		return nil
+	}
+	return v.Frames[len(v.Frames)-1]
+}
+
+// popArgs pops argCount values, keeping their order
+func (v *VM) popArgs(argCount int) []interface{} {
+	args := make([]interface{}, argCount)
+	for i := argCount - 1; i >= 0; i-- {
+		args[i] = v.pop()
+	}
+	return args
+}
+
+// callFunction pushes a new frame for fn and jumps to its body
+func (v *VM) callFunction(fn *bytecode.Function, args []interface{}, deferred bool) {
+	if len(args) != fn.Arity {
+		v.addError(customError.ArgumentCount, fn.Name, fn.Arity, len(args))
+		return
+	}
+
+	frame := &Frame{
+		Function:   fn,
+		ReturnIp:   v.Ip,
+		ScopeDepth: len(v.ScopeStack),
+		StackBase:  v.Sp,
+		isDeferred: deferred,
+	}
+
+	// Scope của hàm chứa params + biến local
+	v.pushScope(fn.LocalSize)
+	copy(v.CurrentScope.Locals, args)
+
+	v.Frames = append(v.Frames, frame)
+	v.Ip = fn.StartPC
+}
+
+// callSync runs a user function to completion from Go code (print, sort, operators of records)
+// and returns its result. It reports false if the call raised a runtime error.
+func (v *VM) callSync(fn *bytecode.Function, args []interface{}) (interface{}, bool) {
+	depth := len(v.Frames) + 1
+	v.callFunction(fn, args, false)
+	if len(v.Frames) < depth {
+		return nil, false // Sai số argument
+	}
+	v.Frames[depth-1].isSync = true
+
+	if !v.run(depth) {
+		// Frame bị bỏ dở vì lỗi: để run ngoài cùng unwind nó như một frame thường
+		if len(v.Frames) >= depth {
+			v.Frames[depth-1].isSync = false
+		}
+		return nil, false
+	}
+	return v.pop(), true
+}
+
+// executeTailCall runs `return f(...)` by replacing the current frame with the callee,
+// so deep tail recursion uses constant stack and scope space
+func (v *VM) executeTailCall(argCount int) {
+	fn := v.pop()
+	args := v.popArgs(argCount)
+
+	f, ok := fn.(*bytecode.Function)
+	frame := v.currentFrame()
+	if !ok || frame == nil || len(frame.Defers) > 0 {
+		// Không dùng lại frame được: gọi bình thường, OP_RETURN ngay sau sẽ trả kết quả
+		if ok {
+			v.callFunction(f, args, false)
+		} else if result, ok := v.callBuiltin(fn, args); ok {
+			v.push(result)
+		}
+		return
+	}
+
+	if len(args) != f.Arity {
+		v.addError(customError.ArgumentCount, f.Name, f.Arity, len(args))
+		return
+	}
+
+	// Bỏ scope và giá trị tạm của lần gọi cũ, giữ nguyên địa chỉ trả về
+	v.ScopeStack = v.ScopeStack[:frame.ScopeDepth]
+	v.CurrentScope = v.ScopeStack[len(v.ScopeStack)-1]
+	if v.Sp > frame.StackBase {
+		v.Stack = v.Stack[:frame.StackBase+1]
+		v.Sp = frame.StackBase
+	}
+
+	v.pushScope(f.LocalSize)
+	copy(v.CurrentScope.Locals, args)
+
+	frame.Function = f
+	v.Ip = f.StartPC
+}
+
+func (v *VM) executeDefer(argCount int) {
+	fn := v.pop()
+	args := v.popArgs(argCount)
+	v.registerDefer(deferredCall{fn: fn, args: args})
+}
+
+func (v *VM) executeDeferMethod(nameIndex, argCount int) {
+	name := v.Constants[nameIndex].(string)
+	args := v.popArgs(argCount)
+	receiver := v.pop()
+	v.registerDefer(deferredCall{fn: receiver, method: name, args: args})
+}
+
+func (v *VM) registerDefer(call deferredCall) {
+	frame := v.currentFrame()
+	if frame == nil {
+		v.addError(customError.DeferOutsideFunction, "defer")
+		return
+	}
+	frame.Defers = append(frame.Defers, call)
+}
+
+func (v *VM) executeReturn() {
+	frame := v.currentFrame()
+	if frame == nil {
+		v.addError(customError.ReturnOutsideFunction, "return")
+		return
+	}
+
+	frame.returnValue = v.pop()
+	v.finishFrames()
+}
+
+// finishFrames runs the pending deferred calls of the top frame (last registered first) and then
+// leaves it. A deferred user function runs as a normal frame; when it returns, finishFrames
+// continues with the remaining defers of its parent. While unwinding after a runtime error,
+// every frame is left this way without resuming the caller.
+func (v *VM) finishFrames() {
+	for len(v.Frames) > 0 {
+		frame := v.Frames[len(v.Frames)-1]
+
+		for len(frame.Defers) > 0 {
+			call := frame.Defers[len(frame.Defers)-1]
+			frame.Defers = frame.Defers[:len(frame.Defers)-1]
+			if v.runDeferred(call) {
+				return // Hàm bị hoãn đang chạy, quay lại đây khi nó return
+			}
+		}
+
+		v.leaveFrame(frame)
+
+		if !frame.isSync && (frame.isDeferred || v.unwinding) {
+			continue
+		}
+		v.Ip = frame.ReturnIp
+		v.push(frame.returnValue)
+		return
+	}
+
+	if v.unwinding {
+		v.Ip = len(v.Code) // Không còn frame nào: dừng chương trình
+	}
+}
+
+// runDeferred calls a deferred call. It returns true if a user function was started,
+// false if the call already finished (builtins and methods run immediately).
+func (v *VM) runDeferred(call deferredCall) bool {
+	if call.method != "" {
+		if method, ok := recordMethod(call.fn, call.method); ok {
+			before := len(v.Frames)
+			v.callFunction(method, append([]interface{}{call.fn}, call.args...), true)
+			return len(v.Frames) > before
+		}
+		v.callMethod(call.fn, call.method, call.args)
+		return false
+	}
+
+	switch fn := call.fn.(type) {
+	case *bytecode.Function:
+		before := len(v.Frames)
+		v.callFunction(fn, call.args, true)
+		return len(v.Frames) > before
+	default:
+		v.callBuiltin(fn, call.args)
+		return false
+	}
+}
+
+// leaveFrame drops the frame with every scope and stack value it still owns
+func (v *VM) leaveFrame(frame *Frame) {
+	v.Frames = v.Frames[:len(v.Frames)-1]
+
+	v.ScopeStack = v.ScopeStack[:frame.ScopeDepth]
+	v.CurrentScope = v.ScopeStack[len(v.ScopeStack)-1]
+
+	if v.Sp > frame.StackBase {
+		v.Stack = v.Stack[:frame.StackBase+1]
+		v.Sp = frame.StackBase
+	}
+}
+
+// unwind is called after a runtime error: deferred calls of every active frame still run
+func (v *VM) unwind() {
+	v.unwinding = true
+	v.finishFrames()
+}
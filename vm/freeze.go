@@ -0,0 +1,136 @@
+package vm
+
+import (
+	"pun/error"
+)
+
+// freezeValue marks an array and every array nested inside it as frozen.
+// Tuples and sets are already immutable, but their elements are frozen too.
+func freezeValue(val interface{}) interface{} {
+	switch v := val.(type) {
+	case *Array:
+		if !v.frozen {
+			v.frozen = true
+			for _, elem := range v.Elements {
+				freezeValue(elem)
+			}
+		}
+	case *Tuple:
+		for _, elem := range v.Elements {
+			freezeValue(elem)
+		}
+	case *Record:
+		for _, field := range v.Fields {
+			freezeValue(field)
+		}
+	case *EnumValue:
+		for _, val := range v.Values {
+			freezeValue(val)
+		}
+	}
+	return val
+}
+
+// isFrozen reports whether nothing inside val can be modified
+func isFrozen(val interface{}) bool {
+	switch v := val.(type) {
+	case *Array:
+		return v.frozen // Đóng băng luôn là deep
+	case *Tuple:
+		for _, elem := range v.Elements {
+			if !isFrozen(elem) {
+				return false
+			}
+		}
+	case *Record:
+		for _, field := range v.Fields {
+			if !isFrozen(field) {
+				return false
+			}
+		}
+	case *EnumValue:
+		for _, val := range v.Values {
+			if !isFrozen(val) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// copyValue returns a mutable deep copy of val. Arrays without nested arrays share their storage
+// with the original until one of them is modified (copy-on-write).
+func copyValue(val interface{}) interface{} {
+	switch v := val.(type) {
+	case *Array:
+		elements := copyElements(v.Elements)
+		if elements == nil {
+			v.shared = true
+			return &Array{Elements: v.Elements, shared: true}
+		}
+		return newArray(elements)
+	case *Tuple:
+		if elements := copyElements(v.Elements); elements != nil {
+			return &Tuple{Elements: elements}
+		}
+	case *Record:
+		if fields := copyElements(v.Fields); fields != nil {
+			return &Record{Type: v.Type, Fields: fields}
+		}
+	case *EnumValue:
+		if values := copyElements(v.Values); values != nil {
+			return &EnumValue{Variant: v.Variant, Values: values}
+		}
+	}
+	return val
+}
+
+// copyElements copies the nested arrays of elements, returning nil if there are none
+func copyElements(elements []interface{}) []interface{} {
+	var copied []interface{}
+	for i, elem := range elements {
+		c := copyValue(elem)
+		if c == elem {
+			continue
+		}
+		if copied == nil {
+			copied = append([]interface{}{}, elements...)
+		}
+		copied[i] = c
+	}
+	return copied
+}
+
+func (v *VM) executeFreeze() {
+	if v.Sp < 0 {
+		v.addError(customError.StackUnderflow, "freeze")
+		return
+	}
+	v.push(freezeValue(v.pop()))
+}
+
+// freeze(value) makes an array and everything nested inside it immutable, and returns it
+func (v *VM) builtinFreeze(args ...interface{}) interface{} {
+	if len(args) != 1 {
+		v.addError(customError.BuiltinArgumentCount, "freeze", "freeze", len(args))
+		return nil
+	}
+	return freezeValue(args[0])
+}
+
+func (v *VM) builtinIsFrozen(args ...interface{}) interface{} {
+	if len(args) != 1 {
+		v.addError(customError.BuiltinArgumentCount, "isFrozen", "isFrozen", len(args))
+		return nil
+	}
+	return isFrozen(args[0])
+}
+
+// copy(value) returns a mutable copy of a (possibly frozen) value
+func (v *VM) builtinCopy(args ...interface{}) interface{} {
+	if len(args) != 1 {
+		v.addError(customError.BuiltinArgumentCount, "copy", "copy", len(args))
+		return nil
+	}
+	return copyValue(args[0])
+}
@@ -1,42 +1,173 @@
 package vm
 
 import (
+	"errors"
 	"fmt"
+	"math"
+	"pun/decimal"
 	"pun/error"
+	"strconv"
 	"strings"
 )
 
-// Thêm lỗi vào danh sách
-func (v *VM) addError(message string, line, col int, context string) {
+// Thêm lỗi vào danh sách. Lỗi luôn thuộc về lệnh đang chạy, nên vị trí và call stack
+// được lấy từ line table thay vì do nơi gọi truyền vào
+func (v *VM) addError(code customError.Code, context string, args ...interface{}) {
+	stack := v.stackTrace()
+	position, _ := v.Lines.Lookup(v.pc)
 	err := customError.RuntimeError{
 		PunError: customError.PunError{
-			Message: message,
-			Line:    line,
-			Column:  col,
+			Code:      code,
+			Message:   customError.Message(code, args...),
+			Line:      position.Line,
+			Column:    position.Col,
+			EndLine:   position.EndLine,
+			EndColumn: position.EndCol,
 		},
-		Context: context,
+		Context: customError.Text(context),
+		Stack:   stack,
 	}
 	v.Errors = append(v.Errors, err)
 }
 
+// addErrorFrom reports an error returned by a helper (set, format, decimal...) with its code
+func (v *VM) addErrorFrom(err error, context string) {
+	var coded *customError.CodedError
+	switch {
+	case errors.As(err, &coded):
+		v.addError(coded.Code, context, coded.Args...)
+	case errors.Is(err, decimal.ErrDivisionByZero):
+		v.addError(customError.DivisionByZero, context)
+	case errors.Is(err, decimal.ErrExponentTooLarge):
+		v.addError(customError.ExponentTooLarge, context)
+	default:
+		v.addError(customError.InternalError, context, err.Error())
+	}
+}
+
+// stackTrace lists the running calls, innermost first, ending with the top-level code
+func (v *VM) stackTrace() []customError.StackFrame {
+	stack := make([]customError.StackFrame, 0, len(v.Frames)+1)
+	pc := v.pc
+	for i := len(v.Frames) - 1; i >= 0; i-- {
+		frame := v.Frames[i]
+		stack = append(stack, v.stackFrame(frame.Function.Name, pc))
+		pc = frame.ReturnIp - 1 // ReturnIp nằm ngay sau lệnh gọi
+	}
+	return append(stack, v.stackFrame("<main>", pc))
+}
+
+func (v *VM) stackFrame(function string, pc int) customError.StackFrame {
+	frame := customError.StackFrame{Function: function}
+	if v.Lines != nil {
+		position, _ := v.Lines.Lookup(pc)
+		frame.File, frame.Line, frame.Column = v.Lines.File, position.Line, position.Col
+	}
+	return frame
+}
+
 // Kiểm tra có lỗi hay không
 func (v *VM) HasErrors() bool {
 	return len(v.Errors) > 0
 }
 
+// Diagnostics returns the runtime errors as diagnostics, for tools that report them themselves
+func (v *VM) Diagnostics() []customError.Diagnostic {
+	diagnostics := make([]customError.Diagnostic, len(v.Errors))
+	for i := range v.Errors {
+		diagnostics[i] = &v.Errors[i]
+	}
+	return diagnostics
+}
+
 // In tất cả lỗi
-func (v *VM) PrintErrors() {
+func (v *VM) PrintErrors(r *customError.Renderer) {
 	if !v.HasErrors() {
 		return
 	}
 
-	fmt.Println("🚨 RUNTIME ERRORS:")
-	for i, err := range v.Errors {
-		fmt.Printf("%d. %s\n", i+1, err.Error())
+	fmt.Println("🚨 " + customError.Text("RUNTIME ERRORS:"))
+	for i := range v.Errors {
+		fmt.Printf("%d. %s\n", i+1, r.Render(&v.Errors[i]))
 		fmt.Println(strings.Repeat("─", 60))
 	}
 }
 
+// stringify converts a runtime value to the text print would show
+func stringify(val interface{}) string {
+	if val == nil {
+		return "nothing"
+	}
+	// Số nguyên in đầy đủ chữ số thay vì dạng 9.007199254740992e+15
+	if f, ok := val.(float64); ok && f == math.Trunc(f) && math.Abs(f) < 1e21 {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(val)
+}
+
+// elementString is stringify for an element of a tuple or set: strings are quoted,
+// so that (1, "a, b") does not print like (1, a, b)
+func elementString(val interface{}) string {
+	if s, ok := val.(string); ok {
+		return strconv.Quote(s)
+	}
+	return stringify(val)
+}
+
+// valuesEqual compares two runtime values by value (numbers of different kinds, arrays element by element)
+func valuesEqual(a, b interface{}) bool {
+	if isDecimal(a) || isDecimal(b) {
+		l, lok := toDecimal(a)
+		r, rok := toDecimal(b)
+		return lok && rok && decimal.Cmp(l, r) == 0
+	}
+
+	aFloat, aNumber := toFloat(a)
+	bFloat, bNumber := toFloat(b)
+	if aNumber && bNumber {
+		if math.IsNaN(aFloat) || math.IsNaN(bFloat) {
+			return false
+		}
+		if isBigInt(a) || isBigInt(b) {
+			return compareNumbers(a, b) == 0
+		}
+		return a.(float64) == b.(float64)
+	}
+
+	switch x := a.(type) {
+	case *Record:
+		y, ok := b.(*Record)
+		return ok && x.Type == y.Type && valuesEqual(x.Fields, y.Fields)
+	case *EnumValue:
+		// Cùng một variant (so sánh con trỏ, không so tên) và các giá trị bằng nhau
+		y, ok := b.(*EnumValue)
+		return ok && x.Variant == y.Variant && valuesEqual(x.Values, y.Values)
+	case *Array:
+		y, ok := b.(*Array)
+		return ok && valuesEqual(x.Elements, y.Elements)
+	case *Tuple:
+		y, ok := b.(*Tuple)
+		return ok && valuesEqual(x.Elements, y.Elements)
+	case *Set:
+		y, ok := b.(*Set)
+		return ok && x.Len() == y.Len() && isSubset(x, y)
+	}
+
+	if aArr, ok := a.([]interface{}); ok {
+		bArr, ok := b.([]interface{})
+		if !ok || len(aArr) != len(bArr) {
+			return false
+		}
+		for i := range aArr {
+			if !valuesEqual(aArr[i], bArr[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
+
 // Helper methods
 func (v *VM) push(val interface{}) {
 	v.Sp++
@@ -54,6 +185,15 @@ func (v *VM) pop() interface{} {
 	return val
 }
 
+// peek returns the value on top of the stack without popping it
+func (v *VM) peek() interface{} {
+	if v.Sp < 0 {
+		v.addError(customError.StackUnderflow, "peek")
+		return nil
+	}
+	return v.Stack[v.Sp]
+}
+
 func (v *VM) pushScope(localSize int) {
 	scope := &Scope{Locals: make([]interface{}, localSize), Parent: v.CurrentScope}
 	v.ScopeStack = append(v.ScopeStack, scope)
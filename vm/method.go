@@ -0,0 +1,232 @@
+package vm
+
+import (
+	"fmt"
+	"math/big"
+	"pun/bytecode"
+	"pun/decimal"
+	"pun/error"
+	"strings"
+	"unicode/utf8"
+)
+
+// BuiltinMethod is a method available on built-in values (string.upper(), array.contains(x), ...)
+type BuiltinMethod func(receiver interface{}, args ...interface{}) (interface{}, error)
+
+var stringMethods = map[string]BuiltinMethod{
+	"upper": func(receiver interface{}, args ...interface{}) (interface{}, error) {
+		return strings.ToUpper(receiver.(string)), nil
+	},
+	"lower": func(receiver interface{}, args ...interface{}) (interface{}, error) {
+		return strings.ToLower(receiver.(string)), nil
+	},
+	"trim": func(receiver interface{}, args ...interface{}) (interface{}, error) {
+		return strings.TrimSpace(receiver.(string)), nil
+	},
+	"contains": func(receiver interface{}, args ...interface{}) (interface{}, error) {
+		sub, ok := args[0].(string)
+		if !ok {
+			return nil, customError.Errorf(customError.ContainsType, typeName(args[0]))
+		}
+		return strings.Contains(receiver.(string), sub), nil
+	},
+}
+
+var arrayMethods = map[string]BuiltinMethod{
+	"contains": func(receiver interface{}, args ...interface{}) (interface{}, error) {
+		for _, elem := range receiver.(*Array).Elements {
+			if valuesEqual(elem, args[0]) {
+				return true, nil
+			}
+		}
+		return false, nil
+	},
+}
+
+var tupleMethods = map[string]BuiltinMethod{
+	"contains": func(receiver interface{}, args ...interface{}) (interface{}, error) {
+		return arrayMethods["contains"](newArray(receiver.(*Tuple).Elements), args...)
+	},
+	"toArray": func(receiver interface{}, args ...interface{}) (interface{}, error) {
+		return newArray(append([]interface{}{}, receiver.(*Tuple).Elements...)), nil
+	},
+}
+
+var setMethods = map[string]BuiltinMethod{
+	"contains": func(receiver interface{}, args ...interface{}) (interface{}, error) {
+		return receiver.(*Set).Contains(args[0]), nil
+	},
+	"union": func(receiver interface{}, args ...interface{}) (interface{}, error) {
+		return setMethod("|", receiver, args[0])
+	},
+	"intersection": func(receiver interface{}, args ...interface{}) (interface{}, error) {
+		return setMethod("&", receiver, args[0])
+	},
+	"difference": func(receiver interface{}, args ...interface{}) (interface{}, error) {
+		return setMethod("-", receiver, args[0])
+	},
+	"toArray": func(receiver interface{}, args ...interface{}) (interface{}, error) {
+		return newArray(receiver.(*Set).Values()), nil
+	},
+}
+
+// setMethod applies a set operator, accepting any collection as the argument: s.union([1, 2])
+func setMethod(op string, receiver, arg interface{}) (interface{}, error) {
+	other, ok := arg.(*Set)
+	if !ok {
+		elements, isCollection := collectionElements(arg)
+		if !isCollection {
+			return nil, customError.Errorf(customError.ExpectedSet, typeName(arg))
+		}
+		other = newSet()
+		for _, elem := range elements {
+			if err := other.add(elem); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return setOperation(op, receiver.(*Set), other)
+}
+
+// Số argument của từng method
+var methodArity = map[string]int{
+	"upper":        0,
+	"lower":        0,
+	"trim":         0,
+	"contains":     1,
+	"toArray":      0,
+	"union":        1,
+	"intersection": 1,
+	"difference":   1,
+}
+
+func (v *VM) executeGetProperty(nameIndex int) {
+	name := v.Constants[nameIndex].(string)
+	object := v.pop()
+
+	switch obj := object.(type) {
+	case string:
+		if name == "length" {
+			v.push(float64(utf8.RuneCountInString(obj)))
+			return
+		}
+	case *Array:
+		if name == "length" {
+			v.push(float64(len(obj.Elements)))
+			return
+		}
+	case *Tuple:
+		if name == "length" {
+			v.push(float64(len(obj.Elements)))
+			return
+		}
+	case *Set:
+		if name == "length" {
+			v.push(float64(obj.Len()))
+			return
+		}
+	case *Record:
+		if value, ok := obj.field(name); ok {
+			v.push(value)
+			return
+		}
+	case *bytecode.EnumType:
+		v.getVariant(obj, name)
+		return
+	case *EnumValue:
+		if value, ok := obj.field(name); ok {
+			v.push(value)
+			return
+		}
+	case *decimal.Decimal:
+		if name == "scale" {
+			v.push(float64(obj.Scale()))
+			return
+		}
+	}
+
+	v.addError(customError.NoProperty, "get property", typeName(object), name)
+}
+
+func (v *VM) executeCallMethod(nameIndex, argCount int) {
+	name := v.Constants[nameIndex].(string)
+	args := v.popArgs(argCount)
+	receiver := v.pop()
+
+	// Method của record là hàm của người dùng: gọi như một hàm với self là argument đầu tiên
+	if method, ok := recordMethod(receiver, name); ok {
+		v.callFunction(method, append([]interface{}{receiver}, args...), false)
+		return
+	}
+
+	if result, ok := v.callMethod(receiver, name, args); ok {
+		v.push(result)
+	}
+}
+
+// callMethod calls a built-in method on receiver, reporting false on error
+func (v *VM) callMethod(receiver interface{}, name string, args []interface{}) (interface{}, bool) {
+	if enum, ok := receiver.(*bytecode.EnumType); ok {
+		return v.newEnumValue(enum, name, args)
+	}
+
+	var method BuiltinMethod
+	switch receiver.(type) {
+	case string:
+		method = stringMethods[name]
+	case *Array:
+		method = arrayMethods[name]
+	case *Tuple:
+		method = tupleMethods[name]
+	case *Set:
+		method = setMethods[name]
+	}
+	if method == nil {
+		v.addError(customError.NoMethod, "call method", typeName(receiver), name)
+		return nil, false
+	}
+
+	if len(args) != methodArity[name] {
+		v.addError(customError.MethodArgumentCount, "call method", name, methodArity[name], len(args))
+		return nil, false
+	}
+
+	result, err := method(receiver, args...)
+	if err != nil {
+		v.addErrorFrom(err, "call method")
+		return nil, false
+	}
+	return result, true
+}
+
+// typeName returns the Pun name of a runtime value's type
+func typeName(val interface{}) string {
+	switch val.(type) {
+	case nil:
+		return "nothing"
+	case float64:
+		return "number"
+	case *big.Int:
+		return "bigint"
+	case *decimal.Decimal:
+		return "decimal"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case *Array:
+		return "array"
+	case *Tuple:
+		return "tuple"
+	case *Set:
+		return "set"
+	case *Record:
+		return val.(*Record).Type.Name
+	case *bytecode.EnumVariant:
+		return val.(*bytecode.EnumVariant).Enum.Name
+	case *EnumValue:
+		return val.(*EnumValue).Variant.Enum.Name
+	default:
+		return fmt.Sprintf("%T", val)
+	}
+}
@@ -0,0 +1,299 @@
+package vm
+
+import (
+	"pun/bytecode"
+	"pun/error"
+	"strconv"
+	"strings"
+)
+
+// Record is an immutable instance of a record type: Vec(1, 2)
+type Record struct {
+	Type   *bytecode.RecordType
+	Fields []interface{}
+}
+
+// String prints the fields by name: Vec(x: 1, y: 2). print() uses __str__ instead if it is defined.
+func (r *Record) String() string {
+	parts := make([]string, len(r.Fields))
+	for i, field := range r.Fields {
+		parts[i] = r.Type.Fields[i] + ": " + stringify(field)
+	}
+	return r.Type.Name + "(" + strings.Join(parts, ", ") + ")"
+}
+
+// field returns the value of the field called name
+func (r *Record) field(name string) (interface{}, bool) {
+	for i, fieldName := range r.Type.Fields {
+		if fieldName == name {
+			return r.Fields[i], true
+		}
+	}
+	return nil, false
+}
+
+func isRecord(val interface{}) bool {
+	_, ok := val.(*Record)
+	return ok
+}
+
+// Method đặc biệt ứng với từng toán tử số học
+var operatorMethods = map[string]string{
+	"+":  "__add__",
+	"-":  "__sub__",
+	"*":  "__mul__",
+	"/":  "__div__",
+	"%":  "__mod__",
+	"**": "__pow__",
+}
+
+// newRecord calls a record type: Vec(1, 2) creates an instance with the fields in order
+func (v *VM) newRecord(recordType *bytecode.RecordType, args []interface{}) (interface{}, bool) {
+	if len(args) != len(recordType.Fields) {
+		v.addError(customError.RecordArgumentCount, recordType.Name, recordType.Name, len(recordType.Fields), len(args))
+		return nil, false
+	}
+	return &Record{Type: recordType, Fields: args}, true
+}
+
+// recordMethod returns the user-defined method `name` of val if val is a record that has one
+func recordMethod(val interface{}, name string) (*bytecode.Function, bool) {
+	record, ok := val.(*Record)
+	if !ok {
+		return nil, false
+	}
+	method, ok := record.Type.Methods[name]
+	return method, ok
+}
+
+// callRecordMethod calls method with the record as self and returns its result
+func (v *VM) callRecordMethod(method *bytecode.Function, self interface{}, args ...interface{}) (interface{}, bool) {
+	return v.callSync(method, append([]interface{}{self}, args...))
+}
+
+// recordArithmetic dispatches an arithmetic operator to __add__, __sub__, ... of the left operand,
+// or to __radd__, __rsub__, ... of the right operand (2 * money)
+func (v *VM) recordArithmetic(op string, left, right interface{}) {
+	name := operatorMethods[op]
+	if method, ok := recordMethod(left, name); ok {
+		if result, ok := v.callRecordMethod(method, left, right); ok {
+			v.push(result)
+		}
+		return
+	}
+	if method, ok := recordMethod(right, "__r"+name[2:]); ok {
+		if result, ok := v.callRecordMethod(method, right, left); ok {
+			v.push(result)
+		}
+		return
+	}
+	v.addError(customError.RecordOperator, "arithmetic operation", op, typeName(left), typeName(right), name)
+}
+
+// recordEqual compares with __eq__ if the left (or else the right) operand defines it,
+// otherwise records are equal when they have the same type and equal fields
+func (v *VM) recordEqual(left, right interface{}) (bool, bool) {
+	method, ok := recordMethod(left, "__eq__")
+	self, other := left, right
+	if !ok {
+		method, ok = recordMethod(right, "__eq__")
+		self, other = right, left
+	}
+	if !ok {
+		return valuesEqual(left, right), true
+	}
+	return v.callPredicate(method, "__eq__", self, other)
+}
+
+// equal compares two values as == does: records, also inside arrays, tuples and enum values,
+// are compared with their __eq__. Sets cannot contain records that define __eq__.
+func (v *VM) equal(a, b interface{}) (bool, bool) {
+	if isRecord(a) || isRecord(b) {
+		return v.recordEqual(a, b)
+	}
+	var left, right []interface{}
+	switch x := a.(type) {
+	case *Array:
+		y, ok := b.(*Array)
+		if !ok {
+			return false, true
+		}
+		left, right = x.Elements, y.Elements
+	case *Tuple:
+		y, ok := b.(*Tuple)
+		if !ok {
+			return false, true
+		}
+		left, right = x.Elements, y.Elements
+	case *EnumValue:
+		y, ok := b.(*EnumValue)
+		if !ok || x.Variant != y.Variant {
+			return false, true
+		}
+		left, right = x.Values, y.Values
+	default:
+		return valuesEqual(a, b), true
+	}
+	if len(left) != len(right) {
+		return false, true
+	}
+	for i := range left {
+		if same, ok := v.equal(left[i], right[i]); !ok || !same {
+			return same, ok
+		}
+	}
+	return true, true
+}
+
+// recordLess computes left < right with the __lt__ method of left
+func (v *VM) recordLess(left, right interface{}) (bool, bool) {
+	method, ok := recordMethod(left, "__lt__")
+	if !ok {
+		v.addError(customError.RecordCompare, "comparison operation", typeName(left), typeName(right))
+		return false, false
+	}
+	return v.callPredicate(method, "__lt__", left, right)
+}
+
+// callPredicate calls a special method that must return a boolean
+func (v *VM) callPredicate(method *bytecode.Function, name string, self, other interface{}) (bool, bool) {
+	result, ok := v.callRecordMethod(method, self, other)
+	if !ok {
+		return false, false
+	}
+	b, ok := result.(bool)
+	if !ok {
+		v.addError(customError.BoolResult, method.Name, name, typeName(result))
+		return false, false
+	}
+	return b, true
+}
+
+// recordComparison implements == and != with __eq__, and <, >, <=, >= with __lt__
+// (a > b is b < a, a <= b is !(b < a))
+func (v *VM) recordComparison(op string, left, right interface{}) {
+	var result, ok bool
+	switch op {
+	case "==":
+		result, ok = v.recordEqual(left, right)
+	case "!=":
+		result, ok = v.recordEqual(left, right)
+		result = !result
+	case "<":
+		result, ok = v.recordLess(left, right)
+	case ">":
+		result, ok = v.recordLess(right, left)
+	case "<=":
+		result, ok = v.recordLess(right, left)
+		result = !result
+	case ">=":
+		result, ok = v.recordLess(left, right)
+		result = !result
+	default:
+		v.addError(customError.UnsupportedComparison, "comparison operation", op)
+		return
+	}
+	if ok {
+		v.push(result)
+	}
+}
+
+// lessThan computes a < b for any two values, as the < operator does
+func (v *VM) lessThan(a, b interface{}) (bool, bool) {
+	errors := len(v.Errors)
+	v.push(a)
+	v.push(b)
+	v.executeComparison("<")
+	if len(v.Errors) > errors {
+		return false, false
+	}
+	result, ok := v.pop().(bool)
+	return result, ok
+}
+
+// recordIndex calls __index__ for record[index]
+func (v *VM) recordIndex(record, index interface{}) {
+	method, ok := recordMethod(record, "__index__")
+	if !ok {
+		v.addError(customError.RecordNotIndexable, "array get", typeName(record))
+		return
+	}
+	if result, ok := v.callRecordMethod(method, record, index); ok {
+		v.push(result)
+	}
+}
+
+// recordNegate calls __neg__ for -record
+func (v *VM) recordNegate(record interface{}) {
+	method, ok := recordMethod(record, "__neg__")
+	if !ok {
+		v.addError(customError.NegateRecord, "unary operation", typeName(record))
+		return
+	}
+	if result, ok := v.callRecordMethod(method, record); ok {
+		v.push(result)
+	}
+}
+
+// display converts a value to the text shown by print and string interpolation,
+// using __str__ for records (also inside arrays, tuples and sets)
+func (v *VM) display(val interface{}) (string, bool) {
+	switch x := val.(type) {
+	case *Record:
+		method, ok := recordMethod(x, "__str__")
+		if !ok {
+			return v.displayRecord(x)
+		}
+		result, ok := v.callRecordMethod(method, x)
+		if !ok {
+			return "", false
+		}
+		str, ok := result.(string)
+		if !ok {
+			v.addError(customError.StrResult, method.Name, typeName(result))
+			return "", false
+		}
+		return str, true
+	case *Array:
+		return v.displayElements("[", x.Elements, " ", "]", false)
+	case *Tuple:
+		if len(x.Elements) == 1 {
+			return v.displayElements("(", x.Elements, ", ", ",)", true)
+		}
+		return v.displayElements("(", x.Elements, ", ", ")", true)
+	case *Set:
+		return v.displayElements("#{", x.Values(), ", ", "}", true)
+	case *EnumValue:
+		return v.displayElements(x.Variant.Name+"(", x.Values, ", ", ")", false)
+	}
+	return stringify(val), true
+}
+
+func (v *VM) displayRecord(record *Record) (string, bool) {
+	parts := make([]string, len(record.Fields))
+	for i, field := range record.Fields {
+		str, ok := v.display(field)
+		if !ok {
+			return "", false
+		}
+		parts[i] = record.Type.Fields[i] + ": " + str
+	}
+	return record.Type.Name + "(" + strings.Join(parts, ", ") + ")", true
+}
+
+// displayElements joins the displayed elements; quote puts strings in quotes (see elementString)
+func (v *VM) displayElements(open string, elements []interface{}, sep, close string, quote bool) (string, bool) {
+	parts := make([]string, len(elements))
+	for i, elem := range elements {
+		if s, ok := elem.(string); ok && quote {
+			parts[i] = strconv.Quote(s)
+			continue
+		}
+		str, ok := v.display(elem)
+		if !ok {
+			return "", false
+		}
+		parts[i] = str
+	}
+	return open + strings.Join(parts, sep) + close, true
+}
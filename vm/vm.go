@@ -1,8 +1,8 @@
 package vm
 
 import (
-	"fmt"
 	"pun/bytecode"
+	"pun/decimal"
 	"pun/error"
 )
 
@@ -20,8 +20,14 @@ type VM struct {
 	CurrentScope *Scope                     //Scope hiện tại
 	Sp           int                        // Stack pointer
 	Ip           int                        // Instruction pointer
+	Lines        *bytecode.LineTable        // Vị trí trong source của bytecode (từ compiler), dùng cho thông báo lỗi
+	pc           int                        // Vị trí của lệnh đang chạy (Ip đã chỉ sang lệnh sau)
+	Frames       []*Frame                   // Các lời gọi hàm đang chạy (trong cùng ở cuối)
 	Builtins     map[string]BuiltinFunction //Lưu built-in function
+	Rounding     decimal.RoundingMode       // Cách làm tròn của phép chia decimal và round()
 	Errors       []customError.RuntimeError
+	handled      int  // Số lỗi đã bắt đầu unwind
+	unwinding    bool // Đang bỏ các frame sau lỗi runtime (vẫn chạy defer)
 }
 
 func NewVM(constants []interface{}, code []byte, globalsSize int) *VM {
@@ -46,17 +52,48 @@ func NewVM(constants []interface{}, code []byte, globalsSize int) *VM {
 
 	vm.Builtins["print"] = vm.builtinPrint
 	vm.Builtins["ask"] = vm.builtinAsk
+	vm.Builtins["bigint"] = vm.builtinBigInt
+	vm.Builtins["number"] = vm.builtinNumber
+	vm.Builtins["decimal"] = vm.builtinDecimal
+	vm.Builtins["round"] = vm.builtinRound
+	vm.Builtins["rounding"] = vm.builtinRounding
+	vm.Builtins["set"] = vm.builtinSet
+	vm.Builtins["freeze"] = vm.builtinFreeze
+	vm.Builtins["isFrozen"] = vm.builtinIsFrozen
+	vm.Builtins["copy"] = vm.builtinCopy
+	vm.Builtins["sort"] = vm.builtinSort
 
 	return vm
 }
 
 func (v *VM) Run() {
-	for v.Ip < len(v.Code) {
-		if v.HasErrors() {
-			return
+	v.run(0)
+}
+
+// run executes instructions until the program ends. A nested run started by callSync (depth > 0)
+// stops as soon as the frames drop below depth, or at the first runtime error, which is then
+// handled by the outermost run. It reports whether it stopped without an error.
+func (v *VM) run(depth int) bool {
+	for {
+		if len(v.Errors) > v.handled {
+			if depth > 0 {
+				return false
+			}
+			// Lỗi mới: bỏ các frame đang chạy nhưng vẫn chạy các lời gọi đã defer
+			v.handled = len(v.Errors)
+			v.unwind()
+			v.handled = len(v.Errors)
+			continue
+		}
+		if depth > 0 && len(v.Frames) < depth {
+			return true
+		}
+		if v.Ip >= len(v.Code) {
+			return depth == 0
 		}
 
 		// Get current opcode
+		v.pc = v.Ip
 		op := bytecode.Opcode(v.Code[v.Ip])
 		v.Ip++
 
@@ -73,14 +110,14 @@ func (v *VM) Run() {
 		case bytecode.OP_LOAD_GLOBAL:
 			slot := operand
 			if slot >= len(v.Globals) {
-				v.addError(fmt.Sprintf("global variable slot %d out of bounds", slot), 0, 0, "runtime")
+				v.addError(customError.GlobalSlotOutOfBounds, "runtime", slot)
 				continue
 			}
 			v.push(v.Globals[slot])
 		case bytecode.OP_STORE_GLOBAL:
 			slot := operand
 			if slot >= len(v.Globals) {
-				v.addError(fmt.Sprintf("global variable slot %d out of bounds", slot), 0, 0, "runtime")
+				v.addError(customError.GlobalSlotOutOfBounds, "runtime", slot)
 				continue
 			}
 			v.Globals[slot] = v.pop()
@@ -101,10 +138,7 @@ func (v *VM) Run() {
 		case bytecode.OP_JUMP:
 			v.Ip = operand
 		case bytecode.OP_JUMP_IF_FALSE:
-			condition := v.pop().(bool)
-			if !condition {
-				v.Ip = operand
-			}
+			v.executeJumpIfFalse(operand)
 		case bytecode.OP_RETURN:
 			v.executeReturn()
 		case bytecode.OP_MAKE_ARRAY:
@@ -115,6 +149,30 @@ func (v *VM) Run() {
 			v.executeArraySet()
 		case bytecode.OP_MAKE_FUNCTION:
 			v.executeMakeFunction()
+		case bytecode.OP_BUILD_STRING:
+			v.executeBuildString(operand)
+		case bytecode.OP_FORMAT:
+			v.executeFormat(operand)
+		case bytecode.OP_POP:
+			v.pop()
+		case bytecode.OP_JUMP_IF_NOTHING:
+			if v.peek() == nil {
+				v.Ip = operand
+			}
+		case bytecode.OP_JUMP_IF_NOT_NOTHING:
+			if v.peek() != nil {
+				v.Ip = operand
+			}
+		case bytecode.OP_GET_PROPERTY:
+			v.executeGetProperty(operand)
+		case bytecode.OP_CALL_METHOD:
+			v.executeCallMethod(operand>>8, operand&0xff)
+		case bytecode.OP_TAIL_CALL:
+			v.executeTailCall(operand)
+		case bytecode.OP_DEFER:
+			v.executeDefer(operand)
+		case bytecode.OP_DEFER_METHOD:
+			v.executeDeferMethod(operand>>8, operand&0xff)
 		case bytecode.OP_ADD:
 			v.executeArithmetic("+")
 		case bytecode.OP_SUB:
@@ -147,8 +205,32 @@ func (v *VM) Run() {
 			v.executeNot()
 		case bytecode.OP_NEG:
 			v.executeNegate()
+		case bytecode.OP_BIT_AND:
+			v.executeBitwise("&")
+		case bytecode.OP_BIT_OR:
+			v.executeBitwise("|")
+		case bytecode.OP_BIT_XOR:
+			v.executeBitwise("^")
+		case bytecode.OP_SHL:
+			v.executeBitwise("<<")
+		case bytecode.OP_SHR:
+			v.executeBitwise(">>")
+		case bytecode.OP_BIT_NOT:
+			v.executeBitNot()
+		case bytecode.OP_MAKE_TUPLE:
+			v.executeMakeTuple(operand)
+		case bytecode.OP_MAKE_SET:
+			v.executeMakeSet(operand)
+		case bytecode.OP_FREEZE:
+			v.executeFreeze()
+		case bytecode.OP_IS_VARIANT:
+			v.executeIsVariant(operand)
+		case bytecode.OP_ITER:
+			v.executeIter()
+		case bytecode.OP_ITER_NEXT:
+			v.executeIterNext(operand)
 		default:
-			v.addError(fmt.Sprintf("unknown opcode: %d", op), 0, 0, "runtime")
+			v.addError(customError.UnknownOpcode, "runtime", op)
 		}
 	}
 }
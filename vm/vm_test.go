@@ -0,0 +1,617 @@
+package vm
+
+import (
+	"io"
+	"os"
+	"pun/compiler"
+	"pun/error"
+	"pun/lexer"
+	"pun/parser"
+	"strings"
+	"testing"
+)
+
+// run compiles and runs input, returning what it printed and the runtime errors
+func run(t *testing.T, input string, tailCalls bool) (string, []customError.RuntimeError) {
+	t.Helper()
+	p := parser.NewParser(lexer.NewLexer(input))
+	program := p.ParseProgram()
+	if p.HasErrors() {
+		t.Fatalf("parse %q: %v", input, p.Diagnostics())
+	}
+	c := compiler.NewCompiler()
+	c.TailCalls = tailCalls
+	c.CompileProgram(program)
+	if c.HasErrors() {
+		t.Fatalf("compile %q: %v", input, c.Diagnostics())
+	}
+
+	// print ghi ra os.Stdout: chuyển tạm sang một pipe để đọc lại
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(reader)
+		output <- string(data)
+	}()
+
+	v := NewVM(c.Constants, c.Code, len(c.GlobalSymbols))
+	v.Lines = c.Lines
+	v.Run()
+
+	os.Stdout = stdout
+	writer.Close()
+	return <-output, v.Errors
+}
+
+// expectOutput runs each program and compares what it printed, without the space print puts
+// after each value
+func expectOutput(t *testing.T, tests []struct{ input, expected string }) {
+	t.Helper()
+	for _, tt := range tests {
+		output, errors := run(t, tt.input, true)
+		if len(errors) > 0 {
+			t.Errorf("%q: runtime error %s", tt.input, errors[0].Message)
+			continue
+		}
+		lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimRight(line, " ")
+		}
+		if got := strings.Join(lines, "\n"); got != tt.expected {
+			t.Errorf("%q printed\n%s\nwant\n%s", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestTailCalls(t *testing.T) {
+	// Đệ quy đuôi sâu chạy được nhờ dùng lại frame
+	deep := `
+func count(n, acc) {
+    if n == 0 {
+        return acc
+    }
+    return count(n - 1, acc + 1)
+}
+print(count(100000, 0))`
+	output, errors := run(t, deep, true)
+	if len(errors) > 0 || output != "100000 \n" {
+		t.Errorf("deep tail recursion printed %q, errors %v", output, errors)
+	}
+
+	failing := `
+func down(n) {
+    if n == 0 {
+        return [][1]
+    }
+    return down(n - 1)
+}
+down(5)`
+	tests := []struct {
+		tailCalls bool
+		frames    int // Số frame của stack trace, gồm <main>
+	}{
+		{true, 2},
+		{false, 7},
+	}
+	for _, tt := range tests {
+		_, errors := run(t, failing, tt.tailCalls)
+		if len(errors) != 1 {
+			t.Fatalf("tailCalls=%v: got %d errors, want 1", tt.tailCalls, len(errors))
+		}
+		if got := len(errors[0].Stack); got != tt.frames {
+			t.Errorf("tailCalls=%v: stack has %d frames, want %d\n%s", tt.tailCalls, got, tt.frames, errors[0].Trace())
+		}
+	}
+}
+
+func TestInterpolation(t *testing.T) {
+	expectOutput(t, []struct{ input, expected string }{
+		{`name = "Pun"
+print("hello ${name}!")`, "hello Pun!"},
+		{`print("${1 + 2} ${[1, 2][1]} ${"nested ${3 * 3}"}")`, "3 2 nested 9"},
+		{`price = 3.14159
+print("${price:.2f}|${42:>5}|${42:<5}|${7:03}")`, "3.14|   42|42   |007"},
+		{`c = true
+print("${c ? 1 : 2} ${!c ? "yes" : "no"}")`, "1 no"},
+		{`c = false
+print("${c ? 1.5 : 2.25:.1f}")`, "2.2"},
+		{`x = nothing
+print("${x ?? "none"}")`, "none"},
+	})
+}
+
+func TestForIn(t *testing.T) {
+	expectOutput(t, []struct{ input, expected string }{
+		{`for x in [1, 2, 3] { print(x) }`, "1\n2\n3"},
+		{`for x in ("a", 2) { print(x) }`, "a\n2"},
+		{`for x in #{3, 1, 3, 2} { print(x) }`, "3\n1\n2"},
+		{`for x in [] { print(x) } else { print("empty") }`, "empty"},
+		{`outer: for a in [1, 2, 3] {
+    for b in [10, 20] {
+        if b == 20 { continue outer }
+        if a == 3 { break outer }
+        print(a * b)
+    }
+} else {
+    print("no break")
+}
+print("done")`, "10\n20\ndone"},
+		{`xs = [1, 2]
+for x in xs {
+    xs[1] = 99
+    print(x)
+}`, "1\n2"},
+		{`func find(s) {
+    for x in s {
+        if x > 1 { return x }
+    }
+}
+print(find(#{1, 5, 7}))`, "5"},
+		{`x = "outer"
+for x in [1] {}
+print(x)`, "outer"},
+	})
+
+	_, errors := run(t, "for x in 5 {}", true)
+	if len(errors) != 1 || errors[0].Code != customError.NotIterable {
+		t.Errorf("for-in over a number: got errors %v, want %s", errors, customError.NotIterable)
+	}
+}
+
+func TestCollections(t *testing.T) {
+	expectOutput(t, []struct{ input, expected string }{
+		// Chuỗi trong tuple và set được đặt trong dấu nháy
+		{`print((1, "a"), (1,), (), (1, (2, "b, c")), #{"x"})`, `(1, "a") (1,) () (1, (2, "b, c")) #{"x"}`},
+		// 1, 1n và 1.0d bằng nhau nên chỉ giữ một; thứ tự thêm vào được giữ nguyên
+		{`print(#{1, 1n, 1.0d, 2}, #{"b", "a", "b"})`, `#{1, 2} #{"b", "a"}`},
+		{`a = #{1, 2, 3}
+b = #{2, 3, 4}
+print(a | b, a & b, a - b, a ^ b, a.union(b))`, "#{1, 2, 3, 4} #{2, 3} #{1} #{1, 4} #{1, 2, 3, 4}"},
+		{`print(#{1} < #{1, 2}, #{1, 2} <= #{1, 2}, #{1, 2} < #{1, 2}, #{3} > #{1}, #{1, 2} == #{2, 1})`,
+			"true true false false true"},
+		{`print((1, "a") == (1, "a"), (1, 2) == (2, 1), (1,) == [1], (1, 2) != (1, 2, 3), (1, 2n) == (1n, 2.0d))`,
+			"true false false true true"},
+		// Tuple, array đã đóng băng và set làm phần tử set; array thường vẫn tìm được
+		{`s = #{(1, 2), (1, 2), (2, 1), #[1, 2], #[1, 2], #{1, 2}, #{2, 1}}
+print(s, s.contains((2, 1)), s.contains([1, 2]), s.contains((3,)))`, "#{(1, 2), (2, 1), [1 2], #{1, 2}} true true false"},
+		{`print((1,) + (2, 3), set([1, 2, 2]), set("aba"), set((1, 1)), (1, 2).toArray())`,
+			`(1, 2, 3) #{1, 2} #{"a", "b"} #{1} [1 2]`},
+		// == trên tuple và array dùng __eq__ của record bên trong
+		{`record P(x, y)
+func P.__eq__(other) { return self.x == other.x }
+print(P(1, 2) == P(1, 3), (P(1, 2), 0) == (P(1, 3), 0), [P(1, 2)] == [P(1, 3)], (P(1, 2),) != (P(2, 2),))`,
+			"true true true true"},
+		{`record Q(x)
+print(#{Q(1), Q(1), Q(2)}, (Q(1),) == (Q(1),))`, "#{Q(x: 1), Q(x: 2)} true"},
+	})
+
+	tests := []struct {
+		input    string
+		expected customError.Code
+	}{
+		{"#{[1]}", customError.Unhashable},
+		{"#{(1, [2])}", customError.Unhashable},
+		{"record P(x)\nfunc P.__eq__(other) { return true }\n#{P(1)}", customError.CustomEqualityUnhashable},
+		{"record P(x)\nfunc P.__eq__(other) { return true }\n#{(1, P(1))}", customError.CustomEqualityUnhashable},
+		{"#{1} | [1]", customError.SetOperands},
+		{"(1,) - (1,)", customError.TupleOperator},
+		{"#{1} < [1]", customError.EqualityOnly},
+		{"set(1)", customError.SetFrom},
+	}
+	for _, tt := range tests {
+		_, errors := run(t, tt.input, false)
+		if len(errors) != 1 || errors[0].Code != tt.expected {
+			t.Errorf("%q: got errors %v, want %s", tt.input, errors, tt.expected)
+		}
+	}
+}
+
+func TestBigInt(t *testing.T) {
+	expectOutput(t, []struct{ input, expected string }{
+		// Phép tính tràn 64 bit tự chuyển sang bigint
+		{`print(9223372036854775807 + 1)`, "9223372036854775808"},
+		{`print(-9223372036854775807 - 10)`, "-9223372036854775817"},
+		{`print(3037000500 * 3037000500)`, "9223372037000250000"},
+		{`print(2 ** 100)`, "1267650600228229401496703205376"},
+		{`print(2 ** 64 - 2 ** 64 + 1)`, "1"},
+		// Quanh 2^53: kết quả float64 làm tròn thành 2^53 phải được tính lại
+		{`x = 9007199254740991
+print(x + 1, x + 2, -x - 2, 2 ** 53 + 1, 94906267 * 94906267)`,
+			"9007199254740992 9007199254740993 -9007199254740993 9007199254740993 9007199515875289"},
+		{`print(2 ** 53 % 7, 9007199254740993 % 10)`, "4 3"},
+		// % của số không nguyên không cắt phần lẻ, cùng dấu với số bị chia
+		{`print(100 % 0.5, 7.5 % 2, -7.5 % 2, 7 % -3)`, "0 1.5 -1.5 1"},
+		// Hậu tố n và các phép toán khác
+		{`print(10n, 2n ** 64 % 1000)`, "10 616"},
+		// Chia hết giữ bigint, không hết thì thành decimal để không mất chữ số
+		{`print(10n / 2n, 10n / 4, 10n / 4 == 2.5d, 10n / 3n, 10n / 2.5)`, "5 2.5 true 3.3333333333333333 4"},
+		{`print((10n ** 30 + 1) / 3n)`, "333333333333333333333333333333.6666666666666667"},
+		{`print((1n << 70) >> 68, ~0n, 6n & 3, 6n | 1, 6n ^ 3)`, "4 -1 2 7 5"},
+		{`print(2n ** 64 == 18446744073709551616, 2n ** 64 > 2 ** 63, 1n == 1)`, "true true true"},
+		// Chuyển đổi
+		{`print(bigint(12) + 1, number(2n ** 10))`, "13 1024"},
+	})
+
+	for _, input := range []string{"print(1n / 0)", "print(100 % 0)", "print(1.5 % 0)", "print(3n % 0)"} {
+		_, errors := run(t, input, true)
+		if len(errors) != 1 || errors[0].Code != customError.DivisionByZero {
+			t.Errorf("%s: got errors %v, want %s", input, errors, customError.DivisionByZero)
+		}
+	}
+	// Thương decimal không trộn được với số thực
+	_, errors := run(t, "print(10n / 3n + 0.5)", true)
+	if len(errors) != 1 || errors[0].Code != customError.InexactDecimal {
+		t.Errorf("10n / 3n + 0.5: got errors %v, want %s", errors, customError.InexactDecimal)
+	}
+}
+
+func TestDefer(t *testing.T) {
+	expectOutput(t, []struct{ input, expected string }{
+		// Chạy theo thứ tự LIFO, argument tính tại chỗ defer
+		{`func f() {
+    x = 1
+    defer print("first", x)
+    x = 2
+    defer print("second", x)
+    print("body")
+}
+f()`, "body\nsecond 2\nfirst 1"},
+		// return có giá trị: defer chạy trước khi giá trị được trả về
+		{`func g() {
+    defer print("cleanup")
+    return 5
+}
+print(g())`, "cleanup\n5"},
+		{`func f() {
+    for i = 0; i < 3; i = i + 1 {
+        defer print(i)
+    }
+    print("end")
+}
+f()`, "end\n2\n1\n0"},
+		{`record P(n)
+func P.show() {
+    defer print("after", self.n)
+    print("show")
+}
+P(1).show()`, "show\nafter 1"},
+	})
+
+	// Lỗi runtime bỏ frame nhưng vẫn chạy các lời gọi đã defer
+	output, errors := run(t, `func h() {
+    defer print("cleanup")
+    [][1]
+}
+h()
+print("after")`, true)
+	if len(errors) != 1 || errors[0].Code != customError.IndexOutOfBounds {
+		t.Errorf("error in h: got errors %v, want %s", errors, customError.IndexOutOfBounds)
+	}
+	if output != "cleanup \n" {
+		t.Errorf("error in h printed %q, want %q", output, "cleanup \n")
+	}
+}
+
+func TestLoops(t *testing.T) {
+	expectOutput(t, []struct{ input, expected string }{
+		{`for i = 0; i < 3; i = i + 1 { print(i) } else { print("done") }`, "0\n1\n2\ndone"},
+		{`for i = 0; i < 3; i = i + 1 {
+    if i == 1 { break }
+} else {
+    print("not printed")
+}
+print("end")`, "end"},
+		{`i = 0
+while i < 2 { i = i + 1 } else { print(i) }`, "2"},
+		{`i = 0
+until i == 2 { i = i + 1 } else { print(i) }`, "2"},
+		{`outer: for i = 0; i < 3; i = i + 1 {
+    for j = 0; j < 3; j = j + 1 {
+        if j == 1 { continue outer }
+        if i == 2 { break outer }
+        print(i, j)
+    }
+} else {
+    print("not printed")
+}`, "0 0\n1 0"},
+		// continue trong for kiểu C vẫn chạy phần cập nhật
+		{`for i = 0; i < 4; i = i + 1 {
+    if i % 2 == 0 { continue }
+    print(i)
+}`, "1\n3"},
+		// break nhãn ngoài từ trong vòng lặp trong vẫn chạy đúng các vòng sau
+		{`a: while true {
+    b: while true { break a }
+}
+n = 0
+for i = 0; i < 2; i = i + 1 { n = n + 1 }
+print(n)`, "2"},
+	})
+}
+
+func TestNumericLiterals(t *testing.T) {
+	expectOutput(t, []struct{ input, expected string }{
+		{`print(0xFF_FF, 0o17, 0b101, 1_000.5, 1e-3, 2.5e2, 1E3)`, "65535 15 5 1000.5 0.001 250 1000"},
+		{`print(2.50d, 0.1d + 0.2d, 0.1 + 0.2 == 0.3)`, "2.50 0.3 false"},
+		{`print(10n, 0xFFn, 1_000_000n)`, "10 255 1000000"},
+		// Số nguyên lớn hơn 2^53 vẫn chính xác
+		{`print(9007199254740993, 18446744073709551616)`, "9007199254740993 18446744073709551616"},
+	})
+}
+
+func TestConditionalAndNothing(t *testing.T) {
+	// boom in ra khi bị tính: các vế bị bỏ qua không được chạy
+	const boom = "func boom() {\n    print(\"evaluated\")\n    return 1\n}\n"
+	expectOutput(t, []struct{ input, expected string }{
+		{boom + `print(true ? 1 : boom(), false ? boom() : 2)`, "1 2"},
+		{boom + `c = 5
+print(c > 3 ? "big" : c > 0 ? "small" : "neg", c < 0 ? 1 : c < 9 ? 2 : 3)`, "big 2"},
+		// ?? chỉ thay nothing, không thay 0, false hay ""
+		{boom + `x = nothing
+print(x ?? 5, 0 ?? 5, false ?? 5, "" ?? 5, 1)`, "5 0 false  1"},
+		{boom + `print(1 ?? boom())`, "1"},
+		{boom + `x = nothing
+print(x?.size, x?.[0], x?.foo(boom()), x?.a.b.c)`, "nothing nothing nothing nothing"},
+		{`record P(n)
+p = P([10, 20])
+q = P(nothing)
+print(p?.n, p?.n?.[1], q.n?.[0] ?? "none")`, "[10 20] 20 none"},
+	})
+}
+
+func TestConditionNotBoolean(t *testing.T) {
+	// Pun không coi 0, "" hay nothing là false: điều kiện khác boolean là lỗi, không làm sập vm
+	tests := []struct {
+		input string
+		line  int
+	}{
+		{`print(1 ? 2 : 3)`, 1},
+		{"x = 0\nif x { print(1) }", 2},
+		{"x = 1\nif x > 2 { print(1) } elif \"yes\" { print(2) }", 2},
+		{"while nothing {}", 1},
+		{"v = if [] { 1 } else { 2 }", 1},
+		{"for i = 0; i; i = i + 1 {}", 1},
+	}
+	for _, tt := range tests {
+		output, errors := run(t, tt.input, false)
+		if len(errors) != 1 || errors[0].Code != customError.ConditionNotBoolean || errors[0].Line != tt.line {
+			t.Errorf("%q: got errors %v, want %s on line %d", tt.input, errors, customError.ConditionNotBoolean, tt.line)
+		}
+		if output != "" {
+			t.Errorf("%q: got output %q after the error", tt.input, output)
+		}
+	}
+}
+
+func TestFrozen(t *testing.T) {
+	expectOutput(t, []struct{ input, expected string }{
+		{`a = #[1, [2, 3]]
+print(isFrozen(a), isFrozen(a[1]), isFrozen([1]))`, "true true false"},
+		{`c = [1, [2]]
+d = freeze(c)
+print(isFrozen(c), isFrozen(c[1]), d == c)`, "true true true"},
+		{`print(isFrozen(freeze(#{1})), isFrozen(freeze((1, [2]))[1]))`, "true true"},
+		// copy() trả về bản có thể sửa, kể cả các phần tử lồng nhau
+		{`a = #[1, [2, 3]]
+b = copy(a)
+b[0] = 9
+b[1][0] = 8
+print(a, b, isFrozen(b[1]))`, "[1 [2 3]] [9 [8 3]] false"},
+		// Sửa bản copy không ảnh hưởng bản gốc
+		{`e = [1, [2]]
+f = copy(e)
+f[0] = 5
+f[1][0] = 6
+print(e, f)`, "[1 [2]] [5 [6]]"},
+	})
+
+	tests := []string{
+		"a = #[1]\na[0] = 2",
+		"a = freeze([[1]])\na[0][0] = 2",
+		"func mutate(x) {\n    x[0] = 0\n}\nmutate(#[1])",
+	}
+	for _, input := range tests {
+		_, errors := run(t, input, true)
+		if len(errors) != 1 || errors[0].Code != customError.FrozenArray {
+			t.Errorf("%q: got errors %v, want %s", input, errors, customError.FrozenArray)
+		}
+	}
+}
+
+func TestOperatorOverloading(t *testing.T) {
+	const vec = `record Vec(x, y)
+func Vec.__add__(other) {
+    return Vec(self.x + other.x, self.y + other.y)
+}
+func Vec.__mul__(k) {
+    return Vec(self.x * k, self.y * k)
+}
+func Vec.__rmul__(k) {
+    return self * k
+}
+func Vec.__neg__() {
+    return Vec(-self.x, -self.y)
+}
+func Vec.__str__() {
+    return "<${self.x}, ${self.y}>"
+}
+func Vec.__index__(i) {
+    return i == 0 ? self.x : self.y
+}
+a = Vec(1, 2)
+b = Vec(3, 4)
+`
+	const money = `record Money(cents)
+func Money.__lt__(other) {
+    return self.cents < other.cents
+}
+func Money.__str__() {
+    return "${self.cents / 100:.2f}"
+}
+`
+	expectOutput(t, []struct{ input, expected string }{
+		{vec + `print(a + b, a * 3, 2 * b, -a)`, "<4, 6> <3, 6> <6, 8> <-1, -2>"},
+		{vec + `print("sum is ${a + b}", [a, b], (a,))`, "sum is <4, 6> [<1, 2> <3, 4>] (<1, 2>,)"},
+		{vec + `print(a[0], a[1])`, "1 2"},
+		// Không có __eq__: so sánh theo giá trị các field
+		{vec + `print(a == Vec(1, 2), a != b)`, "true true"},
+		{money + `print(sort([Money(500), Money(120), Money(990)]))`, "[1.20 5.00 9.90]"},
+		{money + `print(Money(1) > Money(0), Money(1) <= Money(1), Money(2) < Money(1))`, "true true false"},
+		{`record P(a)
+print(P(1), P(1) == P(1), #{P(1), P(1)}.toArray())`, "P(a: 1) true [P(a: 1)]"},
+	})
+
+	// Lỗi trong method đặc biệt có frame riêng trong stack trace
+	_, errors := run(t, `record R(v)
+func R.__str__() {
+    return 1 / 0
+}
+print(R(5))`, true)
+	if len(errors) != 1 || errors[0].Code != customError.DivisionByZero || errors[0].Stack[0].Function != "R.__str__" {
+		t.Errorf("error in __str__: got %v", errors)
+	}
+}
+
+func TestEnums(t *testing.T) {
+	const shape = `enum Shape {
+    Circle(r),
+    Square(side),
+    Empty
+}
+`
+	expectOutput(t, []struct{ input, expected string }{
+		{shape + `func area(s) {
+    return match s {
+        Shape.Circle(r) { 3 * r * r }
+        Shape.Square(side) { side * side }
+        Shape.Empty { 0 }
+    }
+}
+print(area(Shape.Circle(2)), area(Shape.Square(3)), area(Shape.Empty))`, "12 9 0"},
+		{shape + `print(Shape.Circle(2), Shape.Empty, Shape.Square(4).side)`, "Circle(2) Empty 4"},
+		{shape + `print(Shape.Circle(1) == Shape.Circle(1), Shape.Circle(1) == Shape.Circle(2), Shape.Empty == Shape.Empty)`, "true false true"},
+		// Pattern không có ngoặc khớp variant bất kể giá trị các field
+		{shape + `match Shape.Square(4) {
+    Shape.Circle, Shape.Empty { print("round or empty") }
+    Shape.Square { print("square") }
+}`, "square"},
+		{shape + `s = Shape.Empty
+print(if s == Shape.Empty { "empty" } elif s == Shape.Circle(1) { "unit" } else { "other" })`, "empty"},
+		{`match 3 {
+    1, 2 { print("small") }
+    else { print("other") }
+}`, "other"},
+		// Variant so sánh theo identity, không theo tên; giá trị đi kèm so sánh theo giá trị
+		{`enum Result { Ok(value), Err(msg) }
+enum Other { Ok(value), Red }
+enum Color { Red, Green }
+print(Result.Ok(1) == Result.Ok(1), Result.Ok(1) == Result.Err(1), Result.Ok(1) == Other.Ok(1))
+print(Color.Red == Other.Red, Color.Red == "Red", Color.Red == Color.Red, Color.Red != Color.Green)
+print(#{Result.Ok(1), Result.Ok(1), Other.Ok(1), Color.Red, Other.Red}.length)`,
+			"true false false\nfalse false true true\n4"},
+	})
+}
+
+func TestBlockExpressions(t *testing.T) {
+	expectOutput(t, []struct{ input, expected string }{
+		{`x = if 1 > 2 { "a" } elif 2 > 1 { "b" } else { "c" }
+print(x, if true { 1 } else { 2 } + 10)`, "b 11"},
+		{`y = {
+    t = 3
+    t * 2
+}
+print(y)`, "6"},
+		{`print(match 2 { 1 { "one" } 2 { "two" } else { "many" } })`, "two"},
+		// Không có nhánh nào chạy, hoặc lệnh cuối không phải biểu thức: giá trị là nothing
+		{`x = if false { 1 }
+y = { z = 1 }
+print(x, y)`, "nothing nothing"},
+		{`i = 0
+while (n := i * 2) < 6 {
+    print(n)
+    i = i + 1
+}`, "0\n2\n4"},
+		{`print((w := 5) + w, [(k := 2), k * 3])`, "10 [2 6]"},
+		{`func f() {
+    return (a := 3) * a
+}
+print(f())`, "9"},
+	})
+}
+
+func TestPostfixChains(t *testing.T) {
+	expectOutput(t, []struct{ input, expected string }{
+		{`matrix = [[1, 2], [3, 4]]
+print(matrix[1][0], [10, 20][1], (1, (2, 3))[1][0])`, "3 20 2"},
+		{`func double(x) {
+    return x * 2
+}
+func getFn() {
+    return double
+}
+print(getFn()(4), (double)(5))`, "8 10"},
+		{`record Box(v)
+b = Box(Box([7, 8]))
+print(b.v.v[1], #{1, 2}.toArray().contains(2))`, "8 true"},
+		{`print(-[1, 2][0] ** 2, 2 ** 3 ** 2)`, "-1 512"},
+	})
+}
+
+func TestStackTrace(t *testing.T) {
+	_, errors := run(t, `func inner(a) {
+    return a[5] + 1
+}
+func outer(a) {
+    x = inner(a)
+    return x
+}
+record R(v)
+func R.get() {
+    v = outer(self.v)
+    return v
+}
+R([1]).get()`, true)
+	if len(errors) != 1 {
+		t.Fatalf("got %d errors, want 1", len(errors))
+	}
+	err := errors[0]
+	if err.Code != customError.IndexOutOfBounds || err.Line != 2 || err.Column != 12 || err.EndColumn != 16 {
+		t.Errorf("got error %s at %d:%d-%d, want %s at 2:12-16", err.Code, err.Line, err.Column, err.EndColumn, customError.IndexOutOfBounds)
+	}
+	expected := []customError.StackFrame{
+		{Function: "inner", Line: 2, Column: 12},
+		{Function: "outer", Line: 5, Column: 9},
+		{Function: "R.get", Line: 10, Column: 9},
+		{Function: "<main>", Line: 13, Column: 1},
+	}
+	if len(err.Stack) != len(expected) {
+		t.Fatalf("got stack\n%s", err.Trace())
+	}
+	for i, frame := range err.Stack {
+		if frame != expected[i] {
+			t.Errorf("frame %d: got %+v, want %+v", i, frame, expected[i])
+		}
+	}
+
+	// Đệ quy sâu: trace chỉ giữ các frame ở hai đầu
+	_, errors = run(t, `func down(n) {
+    if n == 0 {
+        return [][0]
+    }
+    x = down(n - 1)
+    return x
+}
+down(50)`, true)
+	if len(errors) != 1 || len(errors[0].Stack) != 52 {
+		t.Fatalf("deep recursion: got %v", errors)
+	}
+	trace := errors[0].Trace()
+	if lines := strings.Count(trace, "\n"); lines != 21 || !strings.Contains(trace, "... 32 more calls ...") {
+		t.Errorf("deep recursion trace has %d lines:\n%s", lines, trace)
+	}
+}